@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// LockKey identifies an advisory lock, either by a single 64 bit key or by a
+// pair of 32 bit keys. The two key spaces do not overlap.
+type LockKey struct {
+	id     int64
+	hi, lo int32
+	pair   bool
+}
+
+// Key64 returns the lock key id.
+func Key64(id int64) LockKey {
+	return LockKey{id: id}
+}
+
+// Key32 returns the lock key made of the pair (hi, lo), e.g. a table oid
+// and a row id.
+func Key32(hi, lo int32) LockKey {
+	return LockKey{hi: hi, lo: lo, pair: true}
+}
+
+// KeyFor returns a 64 bit lock key derived from name, for locks identified by
+// a string such as the name of a leader elected job.
+func KeyFor(name string) LockKey {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return Key64(int64(h.Sum64()))
+}
+
+func (k LockKey) call(fn string) (string, []interface{}) {
+	if k.pair {
+		return "SELECT " + fn + "($1, $2)", []interface{}{k.hi, k.lo}
+	}
+	return "SELECT " + fn + "($1)", []interface{}{k.id}
+}
+
+// AdvisoryLock is a session level advisory lock. It holds on to the
+// connection it was acquired on until Unlock is called.
+type AdvisoryLock struct {
+	db      model.SQLCommon
+	release func()
+	key     LockKey
+	shared  bool
+}
+
+// ErrLockReleased is returned when unlocking an advisory lock twice.
+var ErrLockReleased = errors.New("advisory lock has already been released")
+
+// AdvisoryLock waits until the exclusive session level advisory lock key is
+// acquired or ctx is done.
+func (s Postgres) AdvisoryLock(ctx context.Context, key LockKey) (*AdvisoryLock, error) {
+	return s.advisoryLock(ctx, key, false)
+}
+
+// AdvisoryLockShared is like AdvisoryLock but acquires the lock in shared
+// mode, which only conflicts with exclusive holders.
+func (s Postgres) AdvisoryLockShared(ctx context.Context, key LockKey) (*AdvisoryLock, error) {
+	return s.advisoryLock(ctx, key, true)
+}
+
+// TryAdvisoryLock acquires the exclusive session level advisory lock key if
+// it is free, without waiting. The lock is nil when it is held elsewhere.
+func (s Postgres) TryAdvisoryLock(ctx context.Context, key LockKey) (*AdvisoryLock, error) {
+	if s.PgBouncer {
+		return nil, ErrPgBouncer
+	}
+	db, release, err := s.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ok bool
+	query, args := key.call("pg_try_advisory_lock")
+	if err := db.QueryRow(query, args...).Scan(&ok); err != nil || !ok {
+		release()
+		return nil, err
+	}
+	return &AdvisoryLock{db: db, release: release, key: key}, nil
+}
+
+func (s Postgres) advisoryLock(ctx context.Context, key LockKey, shared bool) (*AdvisoryLock, error) {
+	if s.PgBouncer {
+		return nil, ErrPgBouncer
+	}
+	db, release, err := s.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fn := "pg_advisory_lock"
+	if shared {
+		fn = "pg_advisory_lock_shared"
+	}
+	query, args := key.call(fn)
+	if _, err := db.Exec(query, args...); err != nil {
+		release()
+		return nil, err
+	}
+	return &AdvisoryLock{db: db, release: release, key: key, shared: shared}, nil
+}
+
+// Unlock releases the lock and the connection it was held on.
+func (l *AdvisoryLock) Unlock() error {
+	if l.release == nil {
+		return ErrLockReleased
+	}
+	defer func() {
+		l.release()
+		l.release = nil
+	}()
+	fn := "pg_advisory_unlock"
+	if l.shared {
+		fn = "pg_advisory_unlock_shared"
+	}
+	// The lock must be released even when the context it was acquired
+	// with is done by now.
+	db := l.db
+	if c, ok := db.(pinnedConn); ok {
+		c.ctx = context.WithoutCancel(c.ctx)
+		db = c
+	}
+	var ok bool
+	query, args := l.key.call(fn)
+	if err := db.QueryRow(query, args...).Scan(&ok); err != nil {
+		// The session may still hold the lock: it must not be reused.
+		discard(l.db)
+		return err
+	}
+	if !ok {
+		return ErrLockReleased
+	}
+	return nil
+}
+
+// AdvisoryXactLock waits for the exclusive transaction level advisory lock
+// key. It is released automatically when the transaction ends, so it needs
+// no connection of its own and is safe behind transaction pooling.
+func (t *Tx) AdvisoryXactLock(key LockKey) error {
+	query, args := key.call("pg_advisory_xact_lock")
+	_, err := t.Exec(query, args...)
+	return err
+}
+
+// TryAdvisoryXactLock acquires the transaction level advisory lock key if it
+// is free, reporting whether it was.
+func (t *Tx) TryAdvisoryXactLock(key LockKey) (bool, error) {
+	var ok bool
+	query, args := key.call("pg_try_advisory_xact_lock")
+	err := t.QueryRow(query, args...).Scan(&ok)
+	return ok, err
+}
@@ -0,0 +1,348 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ChildrenJSON returns a select list item aggregating, as a jsonb array
+// named alias, the rows of childTable whose foreignKey matches parentKey of
+// parentTable. Scanned with ScanRows into a []Child field it loads parents
+// and their children in one query instead of one query per parent:
+//
+//	SELECT orders.*, <s.ChildrenJSON("order_lines", "order_id", "orders", "id", "lines")>
+//	FROM   orders
+//
+// Parents without children get an empty array.
+func (s Postgres) ChildrenJSON(childTable, foreignKey, parentTable, parentKey, alias string) string {
+	return fmt.Sprintf("(SELECT coalesce(jsonb_agg(to_jsonb(c)), '[]') FROM %v AS c WHERE c.%v = %v.%v) AS %v",
+		s.Quote(childTable), s.Quote(foreignKey), s.Quote(parentTable), s.Quote(parentKey), s.Quote(alias))
+}
+
+// isNestedType reports whether fields of type t hold rows nested in a
+// column, such as jsonb_agg(to_jsonb(t)) or array_agg(t) into a slice of
+// structs, or row_to_json(t) into a struct.
+func isNestedType(t reflect.Type) bool {
+	// Types scanning themselves, such as type Lines []Line with a Scan
+	// method, are left to it.
+	if isScannerType(t) {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	if _, ok := mapperOf(t); ok {
+		return false
+	}
+	return t.Kind() == reflect.Struct && t != timeType && !isScannerType(t)
+}
+
+// aggScanner scans a column of nested rows into the field v. The rows can
+// be JSON objects, as produced by to_jsonb and row_to_json, or composite
+// values, as produced by array_agg(t), whose fields are assigned in the
+// order of the struct fields.
+type aggScanner struct {
+	v reflect.Value
+}
+
+func (a aggScanner) Scan(src interface{}) error {
+	switch x := src.(type) {
+	case nil:
+		a.v.Set(reflect.Zero(a.v.Type()))
+		return nil
+	case []byte:
+		return assignNested(a.v, string(x))
+	case string:
+		return assignNested(a.v, x)
+	}
+	// Drivers decoding JSON themselves hand over maps and slices.
+	b, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return assignNested(a.v, string(b))
+}
+
+func assignNested(v reflect.Value, text string) error {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr {
+		p := reflect.New(t.Elem())
+		if err := assignNested(p.Elem(), text); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	text = strings.TrimSpace(text)
+	switch t.Kind() {
+	case reflect.Slice:
+		var items []*string
+		if strings.HasPrefix(text, "[") {
+			var raws []json.RawMessage
+			if err := json.Unmarshal([]byte(text), &raws); err != nil {
+				return err
+			}
+			for _, raw := range raws {
+				if string(raw) == "null" {
+					items = append(items, nil)
+					continue
+				}
+				item := string(raw)
+				items = append(items, &item)
+			}
+		} else {
+			var err error
+			if items, err = parseArray(text); err != nil {
+				return err
+			}
+		}
+		out := reflect.MakeSlice(t, 0, len(items))
+		for _, item := range items {
+			e := reflect.New(t.Elem()).Elem()
+			if item != nil {
+				if err := assignNested(e, *item); err != nil {
+					return err
+				}
+			}
+			out = reflect.Append(out, e)
+		}
+		v.Set(out)
+		return nil
+	case reflect.Struct:
+		if strings.HasPrefix(text, "{") {
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(text), &obj); err != nil {
+				return err
+			}
+			byName := columnIndex(t)
+			for name, raw := range obj {
+				index, ok := byName[name]
+				if !ok {
+					continue
+				}
+				if err := assignNestedJSON(fieldByIndex(v, index), raw); err != nil {
+					return fmt.Errorf("%s.%s: %v", t, name, err)
+				}
+			}
+			return nil
+		}
+		fields, err := parseComposite(text)
+		if err != nil {
+			return err
+		}
+		i := 0
+		for _, c := range structColumns(t) {
+			if c.system {
+				continue
+			}
+			if i == len(fields) {
+				break
+			}
+			if err := assignValue(fieldByIndex(v, c.index), fields[i]); err != nil {
+				return fmt.Errorf("%s.%s: %v", t, c.name, err)
+			}
+			i++
+		}
+		return nil
+	}
+	return fmt.Errorf("can not scan nested rows into %s", t)
+}
+
+// assignNestedJSON assigns the JSON value raw of a nested row to v.
+func assignNestedJSON(v reflect.Value, raw json.RawMessage) error {
+	if string(raw) == "null" {
+		return assignValue(v, nil)
+	}
+	text := string(raw)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return err
+		}
+	}
+	return assignValue(v, &text)
+}
+
+// assignValue assigns the text form of a value, nil for NULL, to v.
+func assignValue(v reflect.Value, text *string) error {
+	if text == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if m, ok := mapperOf(v.Type()); ok {
+		return mappedScanner{v: v, m: m}.Scan(*text)
+	}
+	if v.Kind() == reflect.Ptr && !isScannerType(v.Type()) {
+		p := reflect.New(v.Type().Elem())
+		if err := assignValue(p.Elem(), text); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if isNestedType(v.Type()) {
+		return assignNested(v, *text)
+	}
+	if sc, ok := v.Addr().Interface().(sql.Scanner); ok {
+		err := sc.Scan(*text)
+		if err != nil {
+			// Scanners of times, such as sql.NullTime, need a time.
+			if t, terr := parseTimestamp(*text); terr == nil {
+				return sc.Scan(t)
+			}
+		}
+		return err
+	}
+	if v.Type() == timeType {
+		t, err := parseTimestamp(*text)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(*text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(*text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(*text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(*text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(*text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := []byte(*text)
+			if strings.HasPrefix(*text, `\x`) {
+				var err error
+				if b, err = hex.DecodeString((*text)[2:]); err != nil {
+					return err
+				}
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		if strings.HasPrefix(*text, "{") {
+			items, err := parseArray(*text)
+			if err != nil {
+				return err
+			}
+			out := reflect.MakeSlice(v.Type(), len(items), len(items))
+			for i, item := range items {
+				if err := assignValue(out.Index(i), item); err != nil {
+					return err
+				}
+			}
+			v.Set(out)
+			return nil
+		}
+		return json.Unmarshal([]byte(*text), v.Addr().Interface())
+	default:
+		return json.Unmarshal([]byte(*text), v.Addr().Interface())
+	}
+	return nil
+}
+
+var errArrayLiteral = errors.New("invalid array literal")
+
+// parseArray splits the one dimensional array literal s, such as
+// {1,"a b",NULL}, into its elements, nil for NULL.
+func parseArray(s string) ([]*string, error) {
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, errArrayLiteral
+	}
+	return splitLiteral(s[1:len(s)-1], true)
+}
+
+// parseComposite splits the composite literal s, such as (1,"a b",), into
+// its fields, nil for NULL.
+func parseComposite(s string) ([]*string, error) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, fmt.Errorf("invalid composite literal %q", s)
+	}
+	return splitLiteral(s[1:len(s)-1], false)
+}
+
+// splitLiteral splits the body of an array or composite literal on commas.
+// Unquoted NULL is null in arrays, and empty fields are null in composites.
+func splitLiteral(s string, array bool) ([]*string, error) {
+	if array && s == "" {
+		return nil, nil
+	}
+	var items []*string
+	var buf strings.Builder
+	quoted := false
+	flush := func() {
+		item := buf.String()
+		buf.Reset()
+		if !quoted && (array && strings.EqualFold(item, "NULL") || !array && item == "") {
+			items = append(items, nil)
+		} else {
+			items = append(items, &item)
+		}
+		quoted = false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = true
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, errArrayLiteral
+				}
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				} else if s[i] == '"' {
+					// Composites double the quotes inside quoted fields.
+					if !array && i+1 < len(s) && s[i+1] == '"' {
+						i++
+					} else {
+						break
+					}
+				}
+				buf.WriteByte(s[i])
+			}
+		case c == '\\' && i+1 < len(s):
+			i++
+			buf.WriteByte(s[i])
+		case c == ',':
+			flush()
+		case c == '{' && array:
+			return nil, errors.New("multidimensional arrays are not supported")
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	flush()
+	return items, nil
+}
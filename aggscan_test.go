@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+// literalItems returns items as strings, with nil for NULL, for comparison.
+func literalItems(items []*string) []interface{} {
+	out := make([]interface{}, len(items))
+	for i, item := range items {
+		if item != nil {
+			out[i] = *item
+		}
+	}
+	return out
+}
+
+func TestParseArray(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []interface{}
+		err  bool
+	}{
+		{in: `{}`, want: []interface{}{}},
+		{in: `{1,2,3}`, want: []interface{}{"1", "2", "3"}},
+		{in: `{NULL,"NULL",null}`, want: []interface{}{nil, "NULL", nil}},
+		{in: `{"a b","c,d"}`, want: []interface{}{"a b", "c,d"}},
+		{in: `{"a\"b","c\\d"}`, want: []interface{}{`a"b`, `c\d`}},
+		{in: `{a\,b}`, want: []interface{}{"a,b"}},
+		{in: `{""}`, want: []interface{}{""}},
+		{in: `{"(1,\"x y\")","(2,)"}`, want: []interface{}{`(1,"x y")`, `(2,)`}},
+		{in: `{{1,2},{3,4}}`, err: true},
+		{in: `{"unterminated}`, err: true},
+		{in: `1,2`, err: true},
+		{in: ``, err: true},
+	} {
+		items, err := parseArray(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseArray(%q): expected an error, got %v", tc.in, literalItems(items))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseArray(%q): %v", tc.in, err)
+			continue
+		}
+		if got := literalItems(items); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseArray(%q) = %#v, want %#v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseComposite(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []interface{}
+		err  bool
+	}{
+		{in: `(1,foo)`, want: []interface{}{"1", "foo"}},
+		{in: `(1,,"")`, want: []interface{}{"1", nil, ""}},
+		{in: `()`, want: []interface{}{nil}},
+		{in: `(,)`, want: []interface{}{nil, nil}},
+		{in: `(1,"a ""quoted"" word")`, want: []interface{}{"1", `a "quoted" word`}},
+		{in: `(1,"back\\slash")`, want: []interface{}{"1", `back\slash`}},
+		{in: `("a,b",NULL)`, want: []interface{}{"a,b", "NULL"}},
+		{in: `("2024-01-02 03:04:05+00",t)`, want: []interface{}{"2024-01-02 03:04:05+00", "t"}},
+		{in: `(1,2`, err: true},
+		{in: `(1,"open)`, err: true},
+		{in: `1,2`, err: true},
+	} {
+		items, err := parseComposite(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseComposite(%q): expected an error, got %v", tc.in, literalItems(items))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseComposite(%q): %v", tc.in, err)
+			continue
+		}
+		if got := literalItems(items); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseComposite(%q) = %#v, want %#v", tc.in, got, tc.want)
+		}
+	}
+}
+
+type nestedLine struct {
+	ID   int
+	Name string
+}
+
+type nestedLines []nestedLine
+
+func (l *nestedLines) Scan(src interface{}) error { return nil }
+
+func TestIsNestedType(t *testing.T) {
+	for _, tc := range []struct {
+		v    interface{}
+		want bool
+	}{
+		{v: []nestedLine(nil), want: true},
+		{v: []*nestedLine(nil), want: true},
+		{v: nestedLine{}, want: true},
+		{v: (*nestedLine)(nil), want: true},
+		{v: nestedLines(nil), want: false},
+		{v: (*nestedLines)(nil), want: false},
+		{v: []string(nil), want: false},
+		{v: []byte(nil), want: false},
+	} {
+		typ := reflect.TypeOf(tc.v)
+		if got := isNestedType(typ); got != tc.want {
+			t.Errorf("isNestedType(%v) = %v, want %v", typ, got, tc.want)
+		}
+	}
+}
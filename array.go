@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// In returns the predicate column = ANY(values), where values is a slice
+// bound as a single array parameter. Unlike an IN list the statement text
+// does not grow with the number of values, so it is planned once and never
+// runs into the bind parameter limit.
+func (s Postgres) In(column string, values interface{}) Expr {
+	return s.arrayOp(column, "= ANY(?)", values)
+}
+
+// NotIn returns the predicate column <> ALL(values).
+func (s Postgres) NotIn(column string, values interface{}) Expr {
+	return s.arrayOp(column, "<> ALL(?)", values)
+}
+
+// Overlaps returns the predicate column && values, true when the array
+// column shares at least one element with values.
+func (s Postgres) Overlaps(column string, values interface{}) Expr {
+	return s.arrayOp(column, "&& ?", values)
+}
+
+// Contains returns the predicate column @> values, true when the array
+// column holds every element of values.
+func (s Postgres) Contains(column string, values interface{}) Expr {
+	return s.arrayOp(column, "@> ?", values)
+}
+
+// ContainedBy returns the predicate column <@ values, true when every
+// element of the array column is in values.
+func (s Postgres) ContainedBy(column string, values interface{}) Expr {
+	return s.arrayOp(column, "<@ ?", values)
+}
+
+func (s Postgres) arrayOp(column, op string, values interface{}) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("%v %v", s.Quote(column), op),
+		Args: []interface{}{pq.Array(values)},
+	}
+}
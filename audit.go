@@ -0,0 +1,171 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// AuditFunction is the name of the trigger function recording changes into
+// audit tables.
+const AuditFunction = "record_audit"
+
+// auditFunctionSQL creates the audit trigger function. Its arguments are
+// the audit table, the setting holding the actor and the columns left out
+// of the recorded rows.
+const auditFunctionSQL = `
+CREATE OR REPLACE FUNCTION record_audit() RETURNS trigger AS $$
+DECLARE
+	old_row jsonb;
+	new_row jsonb;
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		old_row := to_jsonb(OLD) - TG_ARGV[2:];
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		new_row := to_jsonb(NEW) - TG_ARGV[2:];
+	END IF;
+	IF TG_OP = 'UPDATE' AND old_row = new_row THEN
+		RETURN NULL;
+	END IF;
+	EXECUTE format('INSERT INTO %s (table_name, operation, actor, old_row, new_row) VALUES ($1, $2, $3, $4, $5)', TG_ARGV[0])
+	USING TG_TABLE_NAME, TG_OP, nullif(current_setting(TG_ARGV[1], true), ''), old_row, new_row;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql
+`
+
+// Audit configures the audit log, which records every insert, update and
+// delete of the audited tables along with the acting user. It is kept by
+// triggers, so changes made outside the ORM are recorded too.
+type Audit struct {
+	// Table is the audit table. Defaults to "audit_log".
+	Table string
+
+	// ActorSetting is the session variable holding the acting user, set
+	// with WithActor. Defaults to "app.actor".
+	ActorSetting string
+
+	// Exclude lists columns left out of the recorded rows, such as
+	// updated_at or secrets. Updates changing only these are not recorded.
+	Exclude []string
+}
+
+func (a Audit) table() string {
+	if a.Table == "" {
+		return "audit_log"
+	}
+	return a.Table
+}
+
+func (a Audit) actorSetting() string {
+	if a.ActorSetting == "" {
+		return "app.actor"
+	}
+	return a.ActorSetting
+}
+
+// AuditEntry is a row of the audit table. OldRow is null for inserts and
+// NewRow for deletes.
+type AuditEntry struct {
+	ID        int64
+	TableName string
+	Operation string
+	Actor     sql.NullString
+	OldRow    json.RawMessage
+	NewRow    json.RawMessage
+	ChangedAt time.Time
+}
+
+func auditTrigger(tableName string, a Audit) Trigger {
+	return Trigger{
+		Name:       tableName + "_record_audit",
+		Timing:     After,
+		Events:     []string{OnInsert, OnUpdate, OnDelete},
+		ForEachRow: true,
+		Function:   AuditFunction,
+		Args:       append([]string{Postgres{}.Quote(a.table()), a.actorSetting()}, a.Exclude...),
+	}
+}
+
+// EnableAudit creates the audit table and the audit trigger function if
+// needed, and attaches the trigger to tables, replacing any previous
+// configuration of their audit.
+func (s Postgres) EnableAudit(a Audit, tables ...string) error {
+	return s.withTx(func(db model.SQLCommon) error {
+		stmts := []string{
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %v (
+	id bigserial PRIMARY KEY,
+	table_name text NOT NULL,
+	operation text NOT NULL,
+	actor text,
+	old_row jsonb,
+	new_row jsonb,
+	changed_at timestamptz NOT NULL DEFAULT now()
+)`, s.Quote(a.table())),
+			fmt.Sprintf("CREATE INDEX IF NOT EXISTS %v ON %v (table_name, changed_at)",
+				s.Quote(a.table()+"_table_idx"), s.Quote(a.table())),
+			auditFunctionSQL,
+		}
+		for _, table := range tables {
+			t := auditTrigger(table, a)
+			create, err := s.CreateTriggerSQL(table, t)
+			if err != nil {
+				return err
+			}
+			stmts = append(stmts,
+				fmt.Sprintf("DROP TRIGGER IF EXISTS %v ON %v", s.Quote(t.Name), s.Quote(table)),
+				create)
+		}
+		for _, stmt := range stmts {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DisableAudit stops auditing tables. Their audit entries are kept.
+func (s Postgres) DisableAudit(tables ...string) error {
+	for _, table := range tables {
+		if err := s.DropTrigger(table, auditTrigger(table, Audit{}).Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithActor runs fn in a transaction whose changes are attributed to actor
+// in the audit log of a. The actor is set with SET LOCAL semantics, so it
+// never leaks to other users of the pooled connection.
+func (s Postgres) WithActor(a Audit, actor string, fn func(db model.SQLCommon) error) error {
+	return s.withTx(func(db model.SQLCommon) error {
+		if err := setLocal(db, a.actorSetting(), actor); err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+// AuditTrail returns the audit entries of tableName matching where, which
+// may be empty, oldest first. Conditions can look into the recorded rows,
+// e.g. Raw("new_row->>'id' = ?", "42").
+func (s Postgres) AuditTrail(a Audit, tableName string, where Expr) ([]AuditEntry, error) {
+	e := And(Expr{SQL: "table_name = ?", Args: []interface{}{tableName}}, where)
+	e = Join(" WHERE ", Expr{SQL: "SELECT * FROM " + s.Quote(a.table())}, e)
+	e.SQL += " ORDER BY id"
+	query, args := s.Build(e)
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []AuditEntry
+	if err := ScanRows(rows, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff is a retry schedule with exponentially growing, jittered delays.
+type Backoff struct {
+	// Attempts is the maximum number of attempts, including the first.
+	Attempts int
+
+	// Initial is the delay before the second attempt. It doubles after each
+	// further attempt, up to Max when it is set.
+	Initial time.Duration
+	Max     time.Duration
+}
+
+// DefaultOpenBackoff waits up to about half a minute for the server when
+// opening a pool.
+var DefaultOpenBackoff = Backoff{Attempts: 10, Initial: 250 * time.Millisecond, Max: 5 * time.Second}
+
+// Delay returns the delay before attempt, numbered from 1. The delay is
+// picked at random between half and all of its nominal value so that
+// clients failing together do not retry in lockstep.
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt <= 1 {
+		return 0
+	}
+	d := b.Initial
+	for i := 2; i < attempt && (b.Max <= 0 || d < b.Max); i++ {
+		if d > math.MaxInt64/2 {
+			d = math.MaxInt64
+			break
+		}
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// sleep waits for the delay before attempt or until ctx is done.
+func (b Backoff) sleep(ctx context.Context, attempt int) error {
+	t := time.NewTimer(b.Delay(attempt))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// WaitReady pings db until it answers, retrying as scheduled by b. It
+// returns the last error when every attempt failed.
+func WaitReady(ctx context.Context, db *sql.DB, b Backoff) error {
+	attempts := b.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			if serr := b.sleep(ctx, attempt); serr != nil {
+				return err
+			}
+		}
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+	}
+	return err
+}
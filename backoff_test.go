@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	for _, tc := range []struct {
+		b       Backoff
+		attempt int
+		want    time.Duration
+	}{
+		{b: Backoff{Initial: time.Second}, attempt: 1, want: 0},
+		{b: Backoff{Initial: time.Second}, attempt: 2, want: time.Second},
+		{b: Backoff{Initial: time.Second}, attempt: 5, want: 8 * time.Second},
+		{b: Backoff{Initial: time.Second, Max: 3 * time.Second}, attempt: 5, want: 3 * time.Second},
+		{b: Backoff{Initial: time.Second}, attempt: 200, want: math.MaxInt64},
+		{b: Backoff{}, attempt: 3, want: 0},
+	} {
+		got := tc.b.Delay(tc.attempt)
+		if got < tc.want/2 || got > tc.want {
+			t.Errorf("%+v.Delay(%d) = %v, want between %v and %v", tc.b, tc.attempt, got, tc.want/2, tc.want)
+		}
+	}
+}
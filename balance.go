@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// hostRetryAfter is how long a host that failed to connect is tried last.
+const hostRetryAfter = 30 * time.Second
+
+// HostState tracks a server of a multi-host Connector.
+type HostState struct {
+	addr string
+	base driver.Connector
+
+	mu          sync.Mutex
+	latency     time.Duration
+	failures    int
+	lastFailure time.Time
+	readOnly    bool
+	known       bool
+}
+
+// Addr returns the host and port of the server.
+func (h *HostState) Addr() string {
+	return h.addr
+}
+
+// Healthy reports whether the last connection attempt succeeded, or failed
+// long enough ago to try again.
+func (h *HostState) Healthy() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.failures == 0 || time.Since(h.lastFailure) > hostRetryAfter
+}
+
+// Latency returns the moving average of the time taken to connect.
+func (h *HostState) Latency() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latency
+}
+
+// Primary reports whether the server accepted writes when last connected
+// to.
+func (h *HostState) Primary() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.known && !h.readOnly
+}
+
+func (h *HostState) setReadOnly(readOnly bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readOnly, h.known = readOnly, true
+}
+
+func (h *HostState) observe(d time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil && err != ErrReadOnlyServer {
+		h.failures++
+		h.lastFailure = time.Now()
+		return
+	}
+	h.failures = 0
+	if h.latency == 0 {
+		h.latency = d
+	} else {
+		h.latency = (3*h.latency + d) / 4
+	}
+}
+
+// Balancer orders the hosts of a multi-host Connector for a new connection.
+type Balancer interface {
+	Order(hosts []*HostState) []*HostState
+}
+
+// BalancerFunc adapts a function to Balancer.
+type BalancerFunc func(hosts []*HostState) []*HostState
+
+// Order calls f(hosts).
+func (f BalancerFunc) Order(hosts []*HostState) []*HostState {
+	return f(hosts)
+}
+
+// Balancers provided by the package. They all try unhealthy hosts last.
+var (
+	// InOrder tries the hosts in the configured order, which fails over to
+	// the next host when one is down.
+	InOrder Balancer = BalancerFunc(func(hosts []*HostState) []*HostState {
+		return healthyFirst(hosts, nil)
+	})
+
+	// LatencyAware prefers the hosts that were fastest to connect to.
+	LatencyAware Balancer = BalancerFunc(func(hosts []*HostState) []*HostState {
+		return healthyFirst(hosts, func(a, b *HostState) bool {
+			return a.Latency() < b.Latency()
+		})
+	})
+
+	// PrimaryPreferred tries the host last seen accepting writes first.
+	PrimaryPreferred Balancer = BalancerFunc(func(hosts []*HostState) []*HostState {
+		return healthyFirst(hosts, func(a, b *HostState) bool {
+			return a.Primary() && !b.Primary()
+		})
+	})
+)
+
+// NewRoundRobin returns a Balancer spreading connections evenly over the
+// hosts.
+func NewRoundRobin() Balancer {
+	var next uint32
+	return BalancerFunc(func(hosts []*HostState) []*HostState {
+		n := int(atomic.AddUint32(&next, 1)-1) % len(hosts)
+		rotated := append(append([]*HostState{}, hosts[n:]...), hosts[:n]...)
+		return healthyFirst(rotated, nil)
+	})
+}
+
+// healthyFirst returns hosts with the healthy ones first, each group sorted
+// by less when given.
+func healthyFirst(hosts []*HostState, less func(a, b *HostState) bool) []*HostState {
+	out := append([]*HostState{}, hosts...)
+	healthy := make(map[*HostState]bool, len(out))
+	for _, h := range out {
+		healthy[h] = h.Healthy()
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if healthy[out[i]] != healthy[out[j]] {
+			return healthy[out[i]]
+		}
+		return less != nil && less(out[i], out[j])
+	})
+	return out
+}
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MaxBindParams is the largest number of bind parameters a single statement
+// may carry.
+const MaxBindParams = 65535
+
+// Default stands for the DEFAULT keyword in the rows given to BatchInsert,
+// leaving a column of a row to its server default.
+var Default = serverDefault{}
+
+type serverDefault struct{}
+
+// BatchInsertSQL returns a single INSERT statement adding rows to columns of
+// tableName using a multi-row VALUES list.
+func (s Postgres) BatchInsertSQL(tableName string, columns []string, rows [][]interface{}) (string, []interface{}, error) {
+	if len(columns) == 0 || len(rows) == 0 {
+		return "", nil, errors.New("batch insert needs columns and rows")
+	}
+	if len(columns)*len(rows) > MaxBindParams {
+		return "", nil, fmt.Errorf("batch of %d rows exceeds %d bind parameters", len(rows), MaxBindParams)
+	}
+	groups := make([]string, len(rows))
+	args := make([]interface{}, 0, len(columns)*len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return "", nil, fmt.Errorf("row %d has %d values for %d columns", i, len(row), len(columns))
+		}
+		marks := make([]string, len(row))
+		for j, v := range row {
+			if _, ok := v.(serverDefault); ok {
+				marks[j] = "DEFAULT"
+				continue
+			}
+			marks[j] = "?"
+			args = append(args, v)
+		}
+		groups[i] = "(" + strings.Join(marks, ", ") + ")"
+	}
+	query, args := s.Build(Expr{
+		SQL: fmt.Sprintf("INSERT INTO %v (%v) VALUES %v", s.Quote(tableName),
+			s.quoteColumns(columns), strings.Join(groups, ", ")),
+		Args: args,
+	})
+	return query, args, nil
+}
+
+// BatchInsert inserts rows into columns of tableName, sending chunkSize rows
+// per statement. Chunks are capped so no statement exceeds MaxBindParams; a
+// chunkSize of zero uses the largest chunk that fits. It returns the number
+// of rows inserted.
+func (s Postgres) BatchInsert(tableName string, columns []string, rows [][]interface{}, chunkSize int) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("batch insert needs columns")
+	}
+	if max := MaxBindParams / len(columns); chunkSize <= 0 || chunkSize > max {
+		chunkSize = max
+	}
+	var total int64
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		query, args, err := s.BatchInsertSQL(tableName, columns, rows[start:end])
+		if err != nil {
+			return total, err
+		}
+		res, err := s.DB.Exec(query, args...)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	return total, nil
+}
+
+// InsertModels inserts the elements of models, a slice of structs or struct
+// pointers, into tableName using BatchInsert. When columns is empty every
+// column except the primary key is inserted, leaving it to its sequence.
+// Zero values of fields tagged with a DEFAULT are left to the server
+// default, as ngorm does when creating a single model.
+func (s Postgres) InsertModels(tableName string, models interface{}, columns []string, chunkSize int) (int64, error) {
+	columns, rows, err := modelRows(models, columns, true)
+	if err != nil {
+		return 0, err
+	}
+	return s.BatchInsert(tableName, columns, rows, chunkSize)
+}
+
+// modelRows extracts the values of columns from every element of models.
+// With defaults, the zero values of columns with a server default are
+// replaced by Default.
+func modelRows(models interface{}, columns []string, defaults bool) ([]string, [][]interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(models))
+	if v.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("expected a slice of models, got %T", models)
+	}
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("expected a slice of models, got %T", models)
+	}
+	var index [][]int
+	var hasDefault []bool
+	cols := structColumns(elem)
+	if len(columns) == 0 {
+		for _, c := range cols {
+			if !c.primary && !c.system && !c.relation {
+				columns = append(columns, c.name)
+				index = append(index, c.index)
+				hasDefault = append(hasDefault, c.hasDefault)
+			}
+		}
+	} else {
+		byName := make(map[string]structColumn, len(cols))
+		for _, c := range cols {
+			byName[c.name] = c
+		}
+		for _, name := range columns {
+			c, ok := byName[name]
+			if !ok {
+				return nil, nil, fmt.Errorf("%s has no field for column %s", elem, name)
+			}
+			index = append(index, c.index)
+			hasDefault = append(hasDefault, c.hasDefault)
+		}
+	}
+	rows := make([][]interface{}, v.Len())
+	for i := range rows {
+		item := reflect.Indirect(v.Index(i))
+		row := make([]interface{}, len(index))
+		for j, x := range index {
+			row[j] = valueByIndex(item, x)
+			if defaults && hasDefault[j] && isZeroValue(row[j]) {
+				row[j] = Default
+			}
+		}
+		rows[i] = row
+	}
+	return columns, rows, nil
+}
+
+// valueByIndex returns the value of the field at index, or nil when an
+// embedded struct pointer on the way is nil.
+func valueByIndex(v reflect.Value, index []int) interface{} {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return fieldValue(v)
+}
+
+// isZeroValue reports whether v, a value about to be written, is the zero
+// value of its type.
+func isZeroValue(v interface{}) bool {
+	if m, ok := v.(mappedValuer); ok {
+		v = m.v
+	}
+	if v == nil {
+		return true
+	}
+	return reflect.DeepEqual(v, reflect.Zero(reflect.TypeOf(v)).Interface())
+}
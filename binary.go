@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// binaryFormats requests the binary result format for the types whose
+// text form is the most expensive to parse.
+var binaryFormats = pgx.QueryResultFormatsByOID{
+	16:   1, // bool
+	17:   1, // bytea
+	20:   1, // int8
+	21:   1, // int2
+	23:   1, // int4
+	700:  1, // float4
+	701:  1, // float8
+	1114: 1, // timestamp
+	1184: 1, // timestamptz
+	2950: 1, // uuid
+}
+
+// QueryInto runs query and scans the result into dest like ScanRows. On
+// connections using the pgx driver the common numeric, boolean, time, uuid
+// and bytea columns are transferred in binary format and decoded by pgx
+// straight into the struct fields, which avoids parsing text on large
+// results. Other connections fall back to ScanRows.
+func (s Postgres) QueryInto(dest interface{}, query string, args ...interface{}) error {
+	ctx := context.Background()
+	ok, err := s.withPgx(ctx, func(conn *pgx.Conn) error {
+		rows, err := conn.Query(ctx, query, append([]interface{}{binaryFormats}, args...)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		fields := rows.FieldDescriptions()
+		cols := make([]string, len(fields))
+		for i, f := range fields {
+			cols[i] = f.Name
+		}
+		return scanInto(rows, cols, dest)
+	})
+	if ok || err != nil {
+		return err
+	}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	return ScanRows(rows, dest)
+}
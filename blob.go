@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// DefaultBlobChunk is the size of the chunks transferred by WriteBlob and
+// ReadBlob.
+const DefaultBlobChunk = 1 << 20
+
+// ErrBlobNotFound is returned when the row of a Blob does not exist.
+var ErrBlobNotFound = errors.New("blob row not found")
+
+// Blob identifies a bytea column of a single row.
+type Blob struct {
+	Table  string
+	Column string
+
+	// Where selects the row.
+	Where Expr
+
+	// Chunk is the transfer size, DefaultBlobChunk when zero.
+	Chunk int
+}
+
+func (b Blob) chunk() int {
+	if b.Chunk <= 0 {
+		return DefaultBlobChunk
+	}
+	return b.Chunk
+}
+
+// WriteBlob replaces the value of b with the content of r, which is sent in
+// chunks so it never has to fit in memory. The chunks are appended in a
+// single transaction; each append rewrites the stored value, so columns
+// holding large values should use the EXTERNAL storage mode. It returns the
+// number of bytes written.
+func (s Postgres) WriteBlob(b Blob, r io.Reader) (int64, error) {
+	var n int64
+	err := s.withTx(func(db model.SQLCommon) error {
+		col := s.Quote(b.Column)
+		reset := Join(" WHERE ",
+			Expr{SQL: fmt.Sprintf("UPDATE %v SET %v = ''::bytea", s.Quote(b.Table), col)},
+			b.Where)
+		if err := s.execBlob(db, reset); err != nil {
+			return err
+		}
+		buf := make([]byte, b.chunk())
+		for {
+			read, err := io.ReadFull(r, buf)
+			if read > 0 {
+				appendChunk := Join(" WHERE ",
+					Expr{SQL: fmt.Sprintf("UPDATE %v SET %v = %v || ?", s.Quote(b.Table), col, col), Args: []interface{}{buf[:read]}},
+					b.Where)
+				if err := s.execBlob(db, appendChunk); err != nil {
+					return err
+				}
+				n += int64(read)
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+	return n, err
+}
+
+func (s Postgres) execBlob(db model.SQLCommon, e Expr) error {
+	query, args := s.Build(e)
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrBlobNotFound
+	}
+	return nil
+}
+
+// ReadBlob copies the value of b to w in chunks, so it never has to fit in
+// memory. The row is locked in share mode while it is read, so the value can
+// not change in between. It returns the number of bytes written.
+func (s Postgres) ReadBlob(b Blob, w io.Writer) (int64, error) {
+	var n int64
+	err := s.withTx(func(db model.SQLCommon) error {
+		table, col := s.Quote(b.Table), s.Quote(b.Column)
+		query, args := s.Build(Join(" WHERE ",
+			Expr{SQL: fmt.Sprintf("SELECT octet_length(%v) FROM %v", col, table)},
+			Join(" ", b.Where, Expr{SQL: "FOR SHARE"})))
+		var length sql.NullInt64
+		if err := db.QueryRow(query, args...).Scan(&length); err != nil {
+			if IsNotFound(err) {
+				return ErrBlobNotFound
+			}
+			return err
+		}
+		chunk := int64(b.chunk())
+		for n < length.Int64 {
+			query, args := s.Build(Join(" WHERE ",
+				Expr{SQL: fmt.Sprintf("SELECT substring(%v FROM ? FOR ?) FROM %v", col, table), Args: []interface{}{n + 1, chunk}},
+				b.Where))
+			var data []byte
+			if err := db.QueryRow(query, args...).Scan(&data); err != nil {
+				return err
+			}
+			if len(data) == 0 {
+				return io.ErrUnexpectedEOF
+			}
+			written, err := w.Write(data)
+			n += int64(written)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+var stageSeq uint64
+
+// BulkUpsert merges rows into columns of tableName in three steps run in one
+// transaction: the rows are COPYed into a temporary staging table, which is
+// then merged into tableName with a single INSERT ... SELECT ... ON CONFLICT
+// resolving conflicts as c describes, and finally dropped. For large syncs
+// this is dramatically faster than upserting row by row.
+//
+// When c has conflict target columns, rows that collide on them within the
+// input are collapsed to the last one, as a single statement may not update
+// the same row twice. It returns the number of rows inserted or updated.
+func (s Postgres) BulkUpsert(tableName string, columns []string, rows [][]interface{}, c OnConflict) (int64, error) {
+	i := 0
+	return s.bulkUpsert(tableName, columns, c, func() ([]interface{}, bool) {
+		if i == len(rows) {
+			return nil, false
+		}
+		i++
+		return rows[i-1], true
+	})
+}
+
+// BulkUpsertModels is like BulkUpsert for models, a slice of structs or
+// struct pointers. Columns are chosen as in InsertModels.
+func (s Postgres) BulkUpsertModels(tableName string, models interface{}, columns []string, c OnConflict) (int64, error) {
+	columns, rows, err := modelRows(models, columns, false)
+	if err != nil {
+		return 0, err
+	}
+	return s.BulkUpsert(tableName, columns, rows, c)
+}
+
+func (s Postgres) bulkUpsert(tableName string, columns []string, c OnConflict, next func() ([]interface{}, bool)) (int64, error) {
+	clause, err := s.OnConflictClause(tableName, c)
+	if err != nil {
+		return 0, err
+	}
+	stage := fmt.Sprintf("ngorm_stage_%d", atomic.AddUint64(&stageSeq, 1))
+	cols := s.quoteColumns(columns)
+	var n int64
+	err = s.withCopyTx(func(db model.SQLCommon) error {
+		_, err := db.Exec(fmt.Sprintf("CREATE TEMP TABLE %v ON COMMIT DROP AS SELECT %v FROM %v WITH NO DATA",
+			s.Quote(stage), cols, s.Quote(tableName)))
+		if err != nil {
+			return err
+		}
+		if _, err := copyInto(db, stage, columns, next); err != nil {
+			return err
+		}
+		sel := fmt.Sprintf("SELECT %v FROM %v", cols, s.Quote(stage))
+		if len(c.Columns) > 0 {
+			keys := s.quoteColumns(c.Columns)
+			sel = fmt.Sprintf("SELECT DISTINCT ON (%v) %v FROM %v ORDER BY %v, ctid DESC",
+				keys, cols, s.Quote(stage), keys)
+		}
+		query, args := s.Build(Join(" ",
+			Expr{SQL: fmt.Sprintf("INSERT INTO %v (%v) %v", s.Quote(tableName), cols, sel)},
+			clause))
+		res, err := db.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		n, _ = res.RowsAffected()
+		_, err = db.Exec("DROP TABLE " + s.Quote(stage))
+		return err
+	})
+	return n, err
+}
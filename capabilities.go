@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// Capabilities lists the features of a server that change the SQL the
+// dialect generates.
+type Capabilities struct {
+	// IdentityColumns: GENERATED ... AS IDENTITY, PostgreSQL 10.
+	IdentityColumns bool
+
+	// FastColumnDefaults: adding a column with a constant default without
+	// rewriting the table, PostgreSQL 11.
+	FastColumnDefaults bool
+
+	// ReindexConcurrently: REINDEX CONCURRENTLY, PostgreSQL 12.
+	ReindexConcurrently bool
+
+	// GenRandomUUID: gen_random_uuid() without pgcrypto, PostgreSQL 13.
+	GenRandomUUID bool
+
+	// Compression: the COMPRESSION column option and lz4, PostgreSQL 14.
+	Compression bool
+
+	// Merge: the MERGE statement, PostgreSQL 15.
+	Merge bool
+
+	// NullsNotDistinct: UNIQUE NULLS NOT DISTINCT, PostgreSQL 15.
+	NullsNotDistinct bool
+}
+
+// CapabilitiesOf returns the capabilities of servers of version v.
+func CapabilitiesOf(v Version) Capabilities {
+	return Capabilities{
+		IdentityColumns:     v.AtLeast(10, 0),
+		FastColumnDefaults:  v.AtLeast(11, 0),
+		ReindexConcurrently: v.AtLeast(12, 0),
+		GenRandomUUID:       v.AtLeast(13, 0),
+		Compression:         v.AtLeast(14, 0),
+		Merge:               v.AtLeast(15, 0),
+		NullsNotDistinct:    v.AtLeast(15, 0),
+	}
+}
+
+// allCapabilities is assumed when the server can not be asked.
+var allCapabilities = CapabilitiesOf(1 << 30)
+
+// Capabilities returns the capabilities the dialect generates SQL for:
+// Target when set, and those of the server otherwise.
+func (s Postgres) Capabilities() (Capabilities, error) {
+	if s.Target != nil {
+		return *s.Target, nil
+	}
+	v, err := s.Version()
+	if err != nil {
+		return Capabilities{}, err
+	}
+	return CapabilitiesOf(v), nil
+}
+
+// caps is like Capabilities, assuming a recent server when there is no
+// connection to ask.
+func (s Postgres) caps() Capabilities {
+	if s.Target == nil && s.DB == nil {
+		return allCapabilities
+	}
+	c, err := s.Capabilities()
+	if err != nil {
+		return allCapabilities
+	}
+	return c
+}
+
+// identityType returns the column type of an identity column, declared
+// with the IDENTITY tag setting, replacing the serial types.
+func (s Postgres) identityType(sqlType string) (string, error) {
+	if !s.caps().IdentityColumns {
+		return "", fmt.Errorf("identity columns require PostgreSQL 10")
+	}
+	switch sqlType {
+	case "serial":
+		sqlType = "integer"
+	case "bigserial":
+		sqlType = "bigint"
+	}
+	return sqlType + " GENERATED BY DEFAULT AS IDENTITY", nil
+}
+
+// UUIDDefault returns the expression generating random UUIDs, to be used as
+// the DEFAULT of uuid columns. Before PostgreSQL 13 it needs pgcrypto, see
+// EnsureUUIDDefault.
+func (Postgres) UUIDDefault() string {
+	return "gen_random_uuid()"
+}
+
+// EnsureUUIDDefault installs pgcrypto when the server does not provide
+// gen_random_uuid itself.
+func (s Postgres) EnsureUUIDDefault() error {
+	c, err := s.Capabilities()
+	if err != nil {
+		return err
+	}
+	if c.GenRandomUUID {
+		return nil
+	}
+	return s.EnsureExtension("pgcrypto")
+}
@@ -0,0 +1,278 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Kinds of change delivered by a ChangeFeed.
+const (
+	ChangeInsert = "INSERT"
+	ChangeUpdate = "UPDATE"
+	ChangeDelete = "DELETE"
+)
+
+// RowChange is a row modification decoded from the write-ahead log.
+type RowChange struct {
+	// LSN is the log position of the change.
+	LSN string
+
+	Kind   string
+	Schema string
+	Table  string
+
+	// Row is the new row, or the key of a deleted row. It is a pointer to a
+	// new value of the model registered for Table, or a map of column names
+	// to values for unregistered tables.
+	Row interface{}
+}
+
+// ChangeFeed consumes a logical replication slot decoded by the wal2json
+// output plugin, which must be installed on the server. It polls the slot
+// with the SQL interface, which lib/pq supports, so the binary pgoutput
+// plugin can not be used. Changes are delivered at least once: the slot is
+// advanced past a batch only once every change of it has been delivered.
+type ChangeFeed struct {
+	s    Postgres
+	slot string
+
+	// PollInterval is the wait between polls of an idle slot. Defaults to a
+	// second.
+	PollInterval time.Duration
+
+	// BatchSize bounds the changes read per poll, rounded up to whole
+	// transactions. Defaults to 1000.
+	BatchSize int
+
+	models map[string]reflect.Type
+}
+
+// CreateSlot creates the logical replication slot name using wal2json. The
+// slot retains the log from now on until it is consumed, so it must be
+// dropped when no longer used.
+func (s Postgres) CreateSlot(name string) error {
+	_, err := s.DB.Exec("SELECT pg_create_logical_replication_slot($1, 'wal2json')", name)
+	return err
+}
+
+// DropSlot drops the replication slot name.
+func (s Postgres) DropSlot(name string) error {
+	_, err := s.DB.Exec("SELECT pg_drop_replication_slot($1)", name)
+	return err
+}
+
+// HasSlot reports whether the replication slot name exists.
+func (s Postgres) HasSlot(name string) bool {
+	ok, _ := s.SlotExists(name)
+	return ok
+}
+
+// SlotExists is like HasSlot but reports the errors of the lookup.
+func (s Postgres) SlotExists(name string) (bool, error) {
+	return s.exists("SELECT count(*) FROM pg_replication_slots WHERE slot_name = $1", name)
+}
+
+// NewChangeFeed returns a ChangeFeed consuming the slot created with
+// CreateSlot.
+func (s Postgres) NewChangeFeed(slot string) *ChangeFeed {
+	return &ChangeFeed{s: s, slot: slot, models: map[string]reflect.Type{}}
+}
+
+// Register decodes the rows of tableName into new values of the type of
+// model, a struct or a pointer to one.
+func (f *ChangeFeed) Register(tableName string, model interface{}) {
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	f.models[tableName] = t
+}
+
+// Run delivers changes on out until ctx is done or an error occurs.
+func (f *ChangeFeed) Run(ctx context.Context, out chan<- RowChange) error {
+	interval := f.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for {
+		n, err := f.poll(ctx, out)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// wal2jsonChange is a change in wal2json format version 2.
+type wal2jsonChange struct {
+	Action   string           `json:"action"`
+	Schema   string           `json:"schema"`
+	Table    string           `json:"table"`
+	Columns  []wal2jsonColumn `json:"columns"`
+	Identity []wal2jsonColumn `json:"identity"`
+}
+
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (f *ChangeFeed) poll(ctx context.Context, out chan<- RowChange) (int, error) {
+	size := f.BatchSize
+	if size <= 0 {
+		size = 1000
+	}
+	rows, err := f.s.DB.Query("SELECT lsn::text, data FROM pg_logical_slot_peek_changes($1, NULL, $2, 'format-version', '2')", f.slot, size)
+	if err != nil {
+		return 0, err
+	}
+	var changes []RowChange
+	var last string
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&last, &data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		var raw wal2jsonChange
+		if err := json.Unmarshal(data, &raw); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		c, ok, err := f.decode(last, raw)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if ok {
+			changes = append(changes, c)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if last == "" {
+		return 0, nil
+	}
+	for _, c := range changes {
+		select {
+		case out <- c:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return len(changes) + 1, f.Checkpoint(last)
+}
+
+// Checkpoint advances the slot past lsn, releasing the log before it.
+func (f *ChangeFeed) Checkpoint(lsn string) error {
+	_, err := f.s.DB.Exec("SELECT pg_replication_slot_advance($1, $2::pg_lsn)", f.slot, lsn)
+	return err
+}
+
+func (f *ChangeFeed) decode(lsn string, raw wal2jsonChange) (RowChange, bool, error) {
+	c := RowChange{LSN: lsn, Schema: raw.Schema, Table: raw.Table}
+	cols := raw.Columns
+	switch raw.Action {
+	case "I":
+		c.Kind = ChangeInsert
+	case "U":
+		c.Kind = ChangeUpdate
+	case "D":
+		c.Kind = ChangeDelete
+		cols = raw.Identity
+	default:
+		// Transaction boundaries and messages.
+		return c, false, nil
+	}
+	t, ok := f.models[raw.Table]
+	if !ok {
+		row := make(map[string]interface{}, len(cols))
+		for _, col := range cols {
+			var v interface{}
+			if err := json.Unmarshal(col.Value, &v); err != nil {
+				return c, false, err
+			}
+			row[col.Name] = v
+		}
+		c.Row = row
+		return c, true, nil
+	}
+	v := reflect.New(t)
+	fields := columnIndex(t)
+	for _, col := range cols {
+		index, ok := fields[col.Name]
+		if !ok {
+			continue
+		}
+		if err := assignJSON(fieldByIndex(v.Elem(), index), col.Value); err != nil {
+			return c, false, fmt.Errorf("decoding %s.%s: %v", raw.Table, col.Name, err)
+		}
+	}
+	c.Row = v.Interface()
+	return c, true, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// assignJSON stores the wal2json value raw in field.
+func assignJSON(field reflect.Value, raw json.RawMessage) error {
+	if field.Type() == timeType || (field.Kind() == reflect.Ptr && field.Type().Elem() == timeType) {
+		var s *string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return err
+		}
+		if s == nil {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		t, err := parseTimestamp(*s)
+		if err != nil {
+			return err
+		}
+		if field.Kind() == reflect.Ptr {
+			field.Set(reflect.ValueOf(&t))
+		} else {
+			field.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		return scanner.Scan(v)
+	}
+	return json.Unmarshal(raw, field.Addr().Interface())
+}
+
+// parseTimestamp parses the text and JSON forms of timestamp and timestamptz
+// values.
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range []string{
+		"2006-01-02T15:04:05.999999999Z07:00",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999Z07",
+		"2006-01-02 15:04:05.999999999Z07:00",
+		"2006-01-02 15:04:05.999999999",
+		"2006-01-02",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
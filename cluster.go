@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// Replica selection policies of a Cluster.
+const (
+	// RoundRobin uses the replicas in turn.
+	RoundRobin = "round-robin"
+
+	// LeastConnections uses the replica with the fewest connections in use.
+	LeastConnections = "least-connections"
+)
+
+// Cluster is a connection to a primary server and its read replicas. It can
+// be used wherever a connection is expected: statements that only read are
+// sent to a replica and everything else, including transactions, to the
+// primary. Replicas may lag behind the primary, so reads that must observe a
+// preceding write should use Primary directly.
+type Cluster struct {
+	Primary  *sql.DB
+	Replicas []*sql.DB
+
+	// Policy selects a replica, RoundRobin when empty.
+	Policy string
+
+	next uint32
+}
+
+// NewCluster returns a Cluster of primary and replicas.
+func NewCluster(primary *sql.DB, replicas ...*sql.DB) *Cluster {
+	return &Cluster{Primary: primary, Replicas: replicas}
+}
+
+// Replica returns the replica to use for the next read, or the primary when
+// there are no replicas.
+func (c *Cluster) Replica() *sql.DB {
+	switch len(c.Replicas) {
+	case 0:
+		return c.Primary
+	case 1:
+		return c.Replicas[0]
+	}
+	if c.Policy == LeastConnections {
+		best := c.Replicas[0]
+		for _, db := range c.Replicas[1:] {
+			if db.Stats().InUse < best.Stats().InUse {
+				best = db
+			}
+		}
+		return best
+	}
+	n := atomic.AddUint32(&c.next, 1)
+	return c.Replicas[int(n-1)%len(c.Replicas)]
+}
+
+// Exec runs query on the primary.
+func (c *Cluster) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.Primary.Exec(query, args...)
+}
+
+// Prepare prepares query on the primary.
+func (c *Cluster) Prepare(query string) (*sql.Stmt, error) {
+	return c.Primary.Prepare(query)
+}
+
+// Query runs query on a replica when it only reads, and on the primary
+// otherwise.
+func (c *Cluster) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.route(query).Query(query, args...)
+}
+
+// QueryRow runs query on a replica when it only reads, and on the primary
+// otherwise.
+func (c *Cluster) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.route(query).QueryRow(query, args...)
+}
+
+// Begin starts a transaction on the primary.
+func (c *Cluster) Begin() (*sql.Tx, error) {
+	return c.Primary.Begin()
+}
+
+// Conn returns a dedicated connection to the primary.
+func (c *Cluster) Conn(ctx context.Context) (*sql.Conn, error) {
+	return c.Primary.Conn(ctx)
+}
+
+// Close closes the primary and every replica.
+func (c *Cluster) Close() error {
+	err := c.Primary.Close()
+	for _, db := range c.Replicas {
+		if e := db.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func (c *Cluster) route(query string) *sql.DB {
+	if readOnlyQuery(query) {
+		return c.Replica()
+	}
+	return c.Primary
+}
+
+var (
+	readKeyword  = regexp.MustCompile(`(?i)^\s*(SELECT|WITH|TABLE|VALUES|SHOW|EXPLAIN)\b`)
+	writeKeyword = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|MERGE|FOR\s+(NO\s+KEY\s+)?UPDATE|FOR\s+(KEY\s+)?SHARE|NEXTVAL|SETVAL|CURRVAL|LASTVAL|SET_CONFIG|PG_(TRY_)?ADVISORY\w*|PG_NOTIFY|INTO)\b`)
+)
+
+// readOnlyQuery reports whether query can run on a replica. It errs on the
+// side of the primary: a query mentioning a write anywhere, even in a string
+// literal, is not considered read only. Session functions such as currval or
+// set_config go to the primary too, so they see the session of the writes.
+func readOnlyQuery(query string) bool {
+	query = strings.TrimLeft(query, "( \t\r\n")
+	return readKeyword.MatchString(query) && !writeKeyword.MatchString(query)
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ClusterIndexer is implemented by models whose table is kept physically
+// ordered by one of its indexes, e.g. events read by time range.
+type ClusterIndexer interface {
+	ClusterIndex() string
+}
+
+// ClusterTable rewrites tableName in the order of indexName and records it
+// as the table's clustering index, so later calls can omit it. When
+// indexName is empty the recorded index is used. The order is not
+// maintained by later writes, so the table must be clustered again from time
+// to time; it is locked exclusively while it is rewritten.
+func (s Postgres) ClusterTable(tableName, indexName string) error {
+	query := "CLUSTER " + s.Quote(tableName)
+	if indexName != "" {
+		query += " USING " + s.Quote(indexName)
+	}
+	_, err := s.DB.Exec(query)
+	return err
+}
+
+// SetClusterIndex records indexName as the clustering index of tableName
+// without rewriting the table.
+func (s Postgres) SetClusterIndex(tableName, indexName string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("ALTER TABLE %v CLUSTER ON %v", s.Quote(tableName), s.Quote(indexName)))
+	return err
+}
+
+// ClusterIndexOf returns the clustering index recorded for tableName, or
+// the empty string if there is none.
+func (s Postgres) ClusterIndexOf(tableName string) (string, error) {
+	query := `
+SELECT c.relname
+FROM   pg_index i
+       JOIN pg_class c ON c.oid = i.indexrelid
+       JOIN pg_class t ON t.oid = i.indrelid
+WHERE  t.relname = $1
+       AND pg_table_is_visible(t.oid)
+       AND i.indisclustered
+	`
+	var name string
+	err := s.DB.QueryRow(query, tableName).Scan(&name)
+	if IsNotFound(err) {
+		return "", nil
+	}
+	return name, err
+}
+
+// ClusterModel records the index declared by value, when it implements
+// ClusterIndexer, as the clustering index of tableName, and clusters the
+// table if recluster is set.
+func (s Postgres) ClusterModel(tableName string, value interface{}, recluster bool) error {
+	c, ok := value.(ClusterIndexer)
+	if !ok || c.ClusterIndex() == "" {
+		return errors.New("model does not declare a cluster index")
+	}
+	if recluster {
+		return s.ClusterTable(tableName, c.ClusterIndex())
+	}
+	return s.SetClusterIndex(tableName, c.ClusterIndex())
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestClusterRoute(t *testing.T) {
+	primary := sql.OpenDB(stubConnector{})
+	defer primary.Close()
+	replica := sql.OpenDB(stubConnector{})
+	defer replica.Close()
+	c := NewCluster(primary, replica)
+	for _, tc := range []struct {
+		query   string
+		primary bool
+	}{
+		{query: "SELECT * FROM users"},
+		{query: "  (SELECT 1) UNION (SELECT 2)"},
+		{query: "WITH t AS (SELECT 1) SELECT * FROM t"},
+		{query: "TABLE users"},
+		{query: "VALUES (1), (2)"},
+		{query: "SHOW search_path"},
+		{query: "EXPLAIN SELECT 1"},
+		{query: "select id from users where name = $1"},
+		{query: "SELECT intonation FROM notes"},
+		{query: "INSERT INTO users (name) VALUES ($1)", primary: true},
+		{query: "UPDATE users SET name = $1", primary: true},
+		{query: "DELETE FROM users", primary: true},
+		{query: "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", primary: true},
+		{query: "SELECT * FROM users FOR UPDATE", primary: true},
+		{query: "SELECT * FROM users FOR NO KEY UPDATE", primary: true},
+		{query: "SELECT * FROM users FOR SHARE", primary: true},
+		{query: "SELECT * FROM users FOR KEY SHARE", primary: true},
+		{query: "SELECT nextval('users_id_seq')", primary: true},
+		{query: "SELECT setval('users_id_seq', 1)", primary: true},
+		{query: "SELECT currval('users_id_seq')", primary: true},
+		{query: "SELECT lastval()", primary: true},
+		{query: "SELECT set_config('app.user', $1, false)", primary: true},
+		{query: "SELECT pg_advisory_lock(1)", primary: true},
+		{query: "SELECT pg_advisory_xact_lock_shared(1)", primary: true},
+		{query: "SELECT pg_try_advisory_lock(1)", primary: true},
+		{query: "SELECT pg_try_advisory_xact_lock(1, 2)", primary: true},
+		{query: "SELECT pg_notify('events', 'x')", primary: true},
+		{query: "SELECT * INTO archive FROM users", primary: true},
+		{query: "CREATE TABLE t (id int)", primary: true},
+		{query: "SET search_path TO app", primary: true},
+	} {
+		want := replica
+		if tc.primary {
+			want = primary
+		}
+		if got := c.route(tc.query); got != want {
+			t.Errorf("route(%q) went to the wrong server, want primary: %v", tc.query, tc.primary)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Command pgmodels generates ngorm models from an existing postgres schema.
+//
+//	pgmodels -dsn "postgres://localhost/app?sslmode=disable" -pkg models > models.go
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/ngorm/postgres"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"), "connection string")
+	pkg := flag.String("pkg", "models", "package name of the generated file")
+	schema := flag.String("schema", "", "schema to read, defaults to the current schema")
+	flag.Parse()
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	d := &postgres.Postgres{}
+	d.SetDB(db)
+	i := d.Inspector()
+	i.Schema = *schema
+	tables, err := i.All()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := postgres.GenerateModels(os.Stdout, *pkg, tables); err != nil {
+		log.Fatal(err)
+	}
+}
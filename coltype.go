@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// ColumnDrift describes how a live column differs from the field it is
+// declared by.
+type ColumnDrift struct {
+	Table  string
+	Column string
+
+	DeclaredType string
+	LiveType     string
+
+	DeclaredNullable bool
+	LiveNullable     bool
+}
+
+// TypeChanged reports whether the column type differs.
+func (d *ColumnDrift) TypeChanged() bool {
+	return !sameType(d.DeclaredType, d.LiveType)
+}
+
+// NullabilityChanged reports whether the column nullability differs.
+func (d *ColumnDrift) NullabilityChanged() bool {
+	return d.DeclaredNullable != d.LiveNullable
+}
+
+func (d *ColumnDrift) String() string {
+	var parts []string
+	if d.TypeChanged() {
+		parts = append(parts, fmt.Sprintf("type is %s, declared %s", d.LiveType, d.DeclaredType))
+	}
+	if d.NullabilityChanged() {
+		parts = append(parts, fmt.Sprintf("nullable is %v, declared %v", d.LiveNullable, d.DeclaredNullable))
+	}
+	return fmt.Sprintf("column %s.%s: %s", d.Table, d.Column, strings.Join(parts, ", "))
+}
+
+// HasColumnType reports whether tableName has a column columnName of type
+// sqlType. Type aliases such as int4 and integer are treated as equal.
+func (s Postgres) HasColumnType(tableName, columnName, sqlType string) bool {
+	ok, _ := s.ColumnTypeIs(tableName, columnName, sqlType)
+	return ok
+}
+
+// ColumnTypeIs is like HasColumnType but reports the errors of the lookup,
+// ErrNotFound when the column does not exist.
+func (s Postgres) ColumnTypeIs(tableName, columnName, sqlType string) (bool, error) {
+	var live string
+	query := `
+SELECT format_type(a.atttypid, a.atttypmod)
+FROM   pg_attribute a
+WHERE  a.attrelid = $1 :: regclass :: oid
+       AND a.attname = $2
+       AND NOT a.attisdropped
+	`
+	if err := s.DB.QueryRow(query, s.Quote(tableName), columnName).Scan(&live); err != nil {
+		return false, NotFound(err)
+	}
+	return sameType(sqlType, live), nil
+}
+
+// CheckColumn compares field against its column in tableName. It returns nil
+// when the column matches the declared type and nullability, and an error if
+// the column does not exist.
+func (s Postgres) CheckColumn(tableName string, field *model.StructField) (*ColumnDrift, error) {
+	cols, err := s.Inspector().Columns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	var live *ColumnInfo
+	for i := range cols {
+		if cols[i].Name == field.DBName {
+			live = &cols[i]
+		}
+	}
+	if live == nil {
+		return nil, errMissingColumn(tableName, field.DBName)
+	}
+	sqlType, additionalType, err := s.sqlTypeOf(field)
+	if err != nil {
+		return nil, err
+	}
+	d := &ColumnDrift{
+		Table:            tableName,
+		Column:           field.DBName,
+		DeclaredType:     sqlType,
+		LiveType:         live.DataType,
+		DeclaredNullable: declaredNullable(field, additionalType),
+		LiveNullable:     live.Nullable,
+	}
+	if !d.TypeChanged() && !d.NullabilityChanged() {
+		return nil, nil
+	}
+	return d, nil
+}
+
+// FixColumnSQL returns the statements that bring the column described by d in
+// line with its declaration.
+func (s Postgres) FixColumnSQL(d *ColumnDrift) []string {
+	var stmts []string
+	alter := fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v", s.Quote(d.Table), s.Quote(d.Column))
+	if d.TypeChanged() {
+		stmts = append(stmts, fmt.Sprintf("%v TYPE %v", alter, alterableType(d.DeclaredType)))
+	}
+	if d.NullabilityChanged() {
+		if d.DeclaredNullable {
+			stmts = append(stmts, alter+" DROP NOT NULL")
+		} else {
+			stmts = append(stmts, alter+" SET NOT NULL")
+		}
+	}
+	return stmts
+}
+
+// FixColumn alters the column described by d to match its declaration.
+func (s Postgres) FixColumn(d *ColumnDrift) error {
+	for _, query := range s.FixColumnSQL(d) {
+		if _, err := s.DB.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func declaredNullable(field *model.StructField, additionalType string) bool {
+	if field.IsPrimaryKey {
+		return false
+	}
+	return !strings.Contains(strings.ToUpper(additionalType), "NOT NULL")
+}
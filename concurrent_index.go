@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ApplyOptions control how ApplyPlanWith executes a plan.
+type ApplyOptions struct {
+	// ConcurrentIndexes creates indexes with CREATE INDEX CONCURRENTLY,
+	// which does not block writes to the table while the index is built.
+	// Such statements can not run in a transaction, so the dialect's
+	// connection must not be one.
+	ConcurrentIndexes bool
+
+	// IndexRetries is the number of times a concurrent index build that
+	// failed, leaving an invalid index behind, is cleaned up and tried
+	// again.
+	IndexRetries int
+
+	// Backfill configures the batches filling the columns of
+	// BackfillColumn changes.
+	Backfill Backfill
+}
+
+// ApplyPlanWith is like ApplyPlan with the options opts.
+func (s Postgres) ApplyPlanWith(plan Plan, opts ApplyOptions) error {
+	for _, c := range plan {
+		var err error
+		switch {
+		case c.Kind == CreateIndex && opts.ConcurrentIndexes:
+			err = s.CreateIndexConcurrently(c.Object, c.SQL, opts.IndexRetries)
+		case c.Kind == BackfillColumn && c.column != nil:
+			err = s.AddColumnOnline(context.Background(), c.Table, c.Object, c.column.sqlType,
+				Raw(c.column.def), c.column.notNull, opts.Backfill)
+		default:
+			_, err = s.DB.Exec(c.SQL)
+		}
+		if err != nil {
+			return fmt.Errorf("%s on %s: %v", c.Kind, c.Table, err)
+		}
+	}
+	return nil
+}
+
+// ErrInvalidIndex is returned when a concurrent index build left an invalid
+// index behind after all its attempts.
+var ErrInvalidIndex = errors.New("index build failed and left an invalid index")
+
+// CreateIndexConcurrently runs the CREATE INDEX statement createSQL of the
+// index indexName with CONCURRENTLY. A concurrent build that fails, for
+// instance on a deadlock or a duplicate value of a unique index, leaves an
+// invalid index that is maintained on every write but never used. Such an
+// index, whether left by this call or an earlier one, is dropped before the
+// build is tried again, up to retries times.
+func (s Postgres) CreateIndexConcurrently(indexName, createSQL string, retries int) error {
+	if _, ok := s.DB.(txBeginner); !ok {
+		return errors.New("CREATE INDEX CONCURRENTLY can not run inside a transaction")
+	}
+	query := concurrentIndexSQL(createSQL)
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		exists, valid, verr := s.indexState(indexName)
+		if verr != nil {
+			return verr
+		}
+		if exists && valid {
+			return nil
+		}
+		if exists {
+			if derr := s.dropIndexConcurrently(indexName); derr != nil {
+				return derr
+			}
+		}
+		if _, err = s.DB.Exec(query); err == nil {
+			return nil
+		}
+	}
+	if exists, valid, _ := s.indexState(indexName); exists && !valid {
+		return fmt.Errorf("%v: %v: %v", ErrInvalidIndex, indexName, err)
+	}
+	return err
+}
+
+// InvalidIndexes returns the names of the invalid indexes of tableName, left
+// behind by failed concurrent builds.
+func (s Postgres) InvalidIndexes(tableName string) ([]string, error) {
+	query := `
+SELECT c.relname
+FROM   pg_index i
+       JOIN pg_class c ON c.oid = i.indexrelid
+       JOIN pg_class t ON t.oid = i.indrelid
+WHERE  t.relname = $1
+       AND pg_table_is_visible(t.oid)
+       AND NOT i.indisvalid
+ORDER  BY c.relname
+	`
+	rows, err := s.DB.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// DropInvalidIndexes drops the invalid indexes of tableName.
+func (s Postgres) DropInvalidIndexes(tableName string) error {
+	names, err := s.InvalidIndexes(tableName)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := s.dropIndexConcurrently(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexState reports whether the index indexName exists and is valid.
+func (s Postgres) indexState(indexName string) (exists, valid bool, err error) {
+	query := `
+SELECT i.indisvalid
+FROM   pg_index i
+       JOIN pg_class c ON c.oid = i.indexrelid
+WHERE  c.relname = $1
+       AND pg_table_is_visible(c.oid)
+	`
+	err = s.DB.QueryRow(query, indexName).Scan(&valid)
+	if IsNotFound(err) {
+		return false, false, nil
+	}
+	return err == nil, valid, err
+}
+
+func (s Postgres) dropIndexConcurrently(indexName string) error {
+	_, err := s.DB.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + s.Quote(indexName))
+	return err
+}
+
+// concurrentIndexSQL adds CONCURRENTLY to a CREATE INDEX statement.
+func concurrentIndexSQL(query string) string {
+	if strings.Contains(strings.ToUpper(query), " CONCURRENTLY ") {
+		return query
+	}
+	i := strings.Index(strings.ToUpper(query), "INDEX ")
+	if i < 0 {
+		return query
+	}
+	i += len("INDEX ")
+	return query[:i] + "CONCURRENTLY " + query[i:]
+}
@@ -0,0 +1,305 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Config describes a connection. It is turned into a key=value connection
+// string understood by both lib/pq and pgx, so values never have to be quoted
+// by hand.
+type Config struct {
+	// Driver is DriverPQ (the default) or DriverPgx.
+	Driver string
+
+	// Host is a host name, an IP address or, when it starts with a slash,
+	// the directory holding the server's unix socket, such as
+	// /var/run/postgresql. Over a unix socket the server can authenticate
+	// the operating system user with peer authentication, in which case
+	// Password is left empty and User defaults to the operating system user.
+	Host string
+	Port int
+
+	// Hosts lists several servers, as host or host:port, which are tried in
+	// order. It takes precedence over Host and Port.
+	Hosts []string
+
+	// TargetSessionAttrs selects which of Hosts is acceptable: TargetAny
+	// (the default) or TargetReadWrite, the primary.
+	TargetSessionAttrs string
+
+	// Balancer orders Hosts for every new connection, see
+	// Connector.Balancer. pgx tries the hosts in order when nil.
+	Balancer Balancer
+
+	User     string
+	Password string
+	DBName   string
+
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or
+	// verify-full.
+	SSLMode string
+
+	// TLS holds the certificates for SSL connections.
+	TLS *TLSConfig
+
+	// SearchPath is applied with SET search_path on every new connection.
+	SearchPath []string
+
+	// Location, when set, is the TimeZone of every connection, sent in the
+	// startup message, and the zone scanned timestamptz values are in. It
+	// must be a named IANA zone. See TimeZone.
+	Location *time.Location
+
+	// ApplicationName is reported in pg_stat_activity.
+	ApplicationName string
+
+	// ConnectTimeout bounds the time spent establishing a connection. It is
+	// rounded up to whole seconds.
+	ConnectTimeout time.Duration
+
+	// StatementTimeout and LockTimeout set statement_timeout and
+	// lock_timeout for every connection. They are sent in the startup
+	// message, so no statement runs without them. PgBouncer does not
+	// forward them to the server; set them on its side instead.
+	StatementTimeout time.Duration
+	LockTimeout      time.Duration
+
+	// Pool sizes the connection pool returned by Open. DefaultPool is used
+	// when nil.
+	Pool *PoolConfig
+
+	// OpenBackoff schedules the connection attempts made by Open while the
+	// server is not reachable yet. DefaultOpenBackoff is used when nil.
+	OpenBackoff *Backoff
+
+	// AfterConnect hooks run on every new connection, see
+	// Connector.AfterConnect.
+	AfterConnect []func(conn SessionConn) error
+
+	// OnNotice receives the notices sent by the server, see
+	// Connector.OnNotice.
+	OnNotice func(n Notice)
+
+	// PgBouncer configures the driver for transaction pooling PgBouncer,
+	// where consecutive transactions may run on different server sessions.
+	// See Postgres.PgBouncer.
+	PgBouncer bool
+
+	// Params holds additional connection parameters, which the server
+	// applies as session settings, e.g. "timezone": "UTC".
+	Params map[string]string
+}
+
+// String returns c as a key=value connection string. Certificates given as
+// files are passed by name, so a TLS configuration mixing them with PEM data
+// is only usable through Connector, which reads the files.
+func (c Config) String() string {
+	params := map[string]string{}
+	for k, v := range c.Params {
+		params[k] = v
+	}
+	set := func(key, value string) {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	set("host", c.Host)
+	if c.Port != 0 {
+		set("port", strconv.Itoa(c.Port))
+	}
+	if len(c.Hosts) > 0 {
+		hosts, ports := c.hostPorts()
+		set("host", strings.Join(hosts, ","))
+		set("port", strings.Join(ports, ","))
+	}
+	if c.Location != nil {
+		set("timezone", c.Location.String())
+	}
+	set("target_session_attrs", c.TargetSessionAttrs)
+	set("user", c.User)
+	set("password", c.Password)
+	set("dbname", c.DBName)
+	set("sslmode", c.SSLMode)
+	if c.SSLMode == "" && c.unixSocket() {
+		// SSL is not available over unix sockets, and lib/pq would require
+		// it by default.
+		set("sslmode", "disable")
+	}
+	if c.TLS != nil {
+		for k, v := range c.TLS.params() {
+			set(k, v)
+		}
+	}
+	set("application_name", c.ApplicationName)
+	if c.ConnectTimeout > 0 {
+		secs := (c.ConnectTimeout + time.Second - 1) / time.Second
+		set("connect_timeout", strconv.FormatInt(int64(secs), 10))
+	}
+	if c.StatementTimeout > 0 {
+		set("statement_timeout", durationSetting(c.StatementTimeout))
+	}
+	if c.LockTimeout > 0 {
+		set("lock_timeout", durationSetting(c.LockTimeout))
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + quoteDSNValue(params[k])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// unixSocket reports whether every configured host is a unix socket
+// directory.
+func (c Config) unixSocket() bool {
+	if len(c.Hosts) == 0 {
+		return strings.HasPrefix(c.Host, "/")
+	}
+	for _, h := range c.Hosts {
+		if !strings.HasPrefix(h, "/") {
+			return false
+		}
+	}
+	return true
+}
+
+// hostPorts splits Hosts into host names and ports, using Port or 5432 for
+// hosts without one.
+func (c Config) hostPorts() (hosts, ports []string) {
+	port := "5432"
+	if c.Port != 0 {
+		port = strconv.Itoa(c.Port)
+	}
+	for _, h := range c.Hosts {
+		host, p, err := net.SplitHostPort(h)
+		if err != nil || strings.HasPrefix(h, "/") {
+			host, p = h, port
+		}
+		hosts = append(hosts, host)
+		ports = append(ports, p)
+	}
+	return hosts, ports
+}
+
+// quoteDSNValue quotes v for a key=value connection string.
+func quoteDSNValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(v) + "'"
+}
+
+// Connector returns a Connector for c.
+func (c Config) Connector() (*Connector, error) {
+	if c.PgBouncer && len(c.SearchPath) > 0 {
+		return nil, fmt.Errorf("%v: search_path can not be set per connection", ErrPgBouncer)
+	}
+	if c.TLS != nil {
+		if err := c.TLS.validate(); err != nil {
+			return nil, err
+		}
+		tls, err := c.TLS.inlined()
+		if err != nil {
+			return nil, err
+		}
+		c.TLS = tls
+	}
+	if c.Location == time.Local {
+		return nil, errors.New("time zone must be a named location, not time.Local")
+	}
+	if c.Location != nil {
+		c.AfterConnect = append([]func(conn SessionConn) error{scanLocation(c.Location)}, c.AfterConnect...)
+	}
+	var conn *Connector
+	var err error
+	switch {
+	case len(c.Hosts) > 0 && (c.Balancer != nil || c.Driver == DriverPQ || c.Driver == ""):
+		return c.multiHostConnector()
+	case c.Driver == DriverPgx:
+		var opts []func(*pgx.ConnConfig) error
+		if c.TLS != nil {
+			// pgx does not read in-memory certificates from the connection
+			// string, so the TLS configuration is set up here instead.
+			opts = append(opts, c.TLS.pgxTLS(c.SSLMode))
+			c.TLS = nil
+		}
+		if c.PgBouncer {
+			opts = append(opts, pgxPgBouncer)
+		}
+		conn, err = pgxConnector(c.String(), opts...)
+	default:
+		conn, err = NewConnectorDriver(c.Driver, c.String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	conn.SearchPath = c.SearchPath
+	conn.AfterConnect = c.AfterConnect
+	conn.OnNotice = c.OnNotice
+	return conn, nil
+}
+
+// multiHostConnector returns a Connector choosing among Hosts itself, as
+// lib/pq only connects to a single host and pgx does not balance.
+func (c Config) multiHostConnector() (*Connector, error) {
+	hosts, ports := c.hostPorts()
+	single := c
+	single.Hosts = nil
+	single.TargetSessionAttrs = ""
+	conn := &Connector{
+		Balancer:           c.Balancer,
+		TargetSessionAttrs: c.TargetSessionAttrs,
+		SearchPath:         c.SearchPath,
+		AfterConnect:       c.AfterConnect,
+		OnNotice:           c.OnNotice,
+	}
+	for i, host := range hosts {
+		single.Host = host
+		single.Port, _ = strconv.Atoi(ports[i])
+		hc, err := single.Connector()
+		if err != nil {
+			return nil, err
+		}
+		conn.hosts = append(conn.hosts, &HostState{addr: net.JoinHostPort(host, ports[i]), base: hc.base})
+	}
+	conn.base = conn.hosts[0].base
+	return conn, nil
+}
+
+// Open returns a connection pool for c, once a connection could be made.
+func (c Config) Open() (*sql.DB, error) {
+	conn, err := c.Connector()
+	if err != nil {
+		return nil, err
+	}
+	db := conn.DB()
+	pool := DefaultPool
+	if c.Pool != nil {
+		pool = *c.Pool
+	}
+	pool.Apply(db)
+	backoff := DefaultOpenBackoff
+	if c.OpenBackoff != nil {
+		backoff = *c.OpenBackoff
+	}
+	if err := WaitReady(context.Background(), db, backoff); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// conner is implemented by connection pools that can hand out a dedicated
+// connection, such as *sql.DB.
+type conner interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// pinnedConn adapts a dedicated *sql.Conn to model.SQLCommon, so that
+// session state such as advisory locks or temporary tables stays with the
+// statements that rely on it.
+type pinnedConn struct {
+	ctx  context.Context
+	conn *sql.Conn
+}
+
+func (c pinnedConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.conn.ExecContext(c.ctx, query, args...)
+}
+
+func (c pinnedConn) Prepare(query string) (*sql.Stmt, error) {
+	return c.conn.PrepareContext(c.ctx, query)
+}
+
+func (c pinnedConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.conn.QueryContext(c.ctx, query, args...)
+}
+
+func (c pinnedConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.conn.QueryRowContext(c.ctx, query, args...)
+}
+
+// pin returns a connection bound to a single server session along with a
+// function releasing it. When the dialect's connection already is bound to a
+// session, such as a transaction, it is returned as is.
+func (s Postgres) pin(ctx context.Context) (model.SQLCommon, func(), error) {
+	c, ok := s.DB.(conner)
+	if !ok {
+		return s.DB, func() {}, nil
+	}
+	conn, err := c.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pinnedConn{ctx: ctx, conn: conn}, func() { conn.Close() }, nil
+}
+
+// discard makes the pool close the pinned connection db rather than reuse
+// it, for connections left in an unknown session state. Other connections
+// are left alone.
+func discard(db model.SQLCommon) {
+	if c, ok := db.(pinnedConn); ok {
+		c.conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+}
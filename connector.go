@@ -0,0 +1,256 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Connector is a driver.Connector that configures every new connection before
+// it is handed to the pool, so session settings hold no matter which pooled
+// connection ends up serving a query.
+type Connector struct {
+	base driver.Connector
+
+	// hosts are the servers of a multi-host Connector, base being the
+	// first. They are tried in the order chosen by Balancer until one can be
+	// reached and satisfies TargetSessionAttrs.
+	hosts []*HostState
+
+	// Balancer orders the hosts of a multi-host Connector for every new
+	// connection. Defaults to InOrder.
+	Balancer Balancer
+
+	// SearchPath, when set, is applied with SET search_path on every new
+	// connection.
+	SearchPath []string
+
+	// AfterConnect hooks run in order on every new connection, after
+	// SearchPath was applied and before the connection joins the pool. A
+	// failing hook discards the connection.
+	AfterConnect []func(conn SessionConn) error
+
+	// TargetSessionAttrs, when TargetReadWrite, only accepts connections to
+	// a server that is not in read only mode, so new connections follow the
+	// primary after a failover.
+	TargetSessionAttrs string
+
+	// OnNotice, when set, receives the notices and warnings sent by the
+	// server on every connection, which are otherwise dropped. LogNotice
+	// writes them to the standard logger.
+	OnNotice func(n Notice)
+}
+
+// Values of TargetSessionAttrs.
+const (
+	TargetAny       = "any"
+	TargetReadWrite = "read-write"
+)
+
+// ErrReadOnlyServer is returned when no server accepting writes was found.
+var ErrReadOnlyServer = errors.New("server is read only")
+
+// NewConnector returns a Connector for the lib/pq connection string dsn.
+func NewConnector(dsn string) (*Connector, error) {
+	base, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{base: base}, nil
+}
+
+// Connect implements driver.Connector.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if len(c.hosts) == 0 {
+		return c.connect(ctx, c.base, nil)
+	}
+	balancer := c.Balancer
+	if balancer == nil {
+		balancer = InOrder
+	}
+	var err error
+	for _, h := range balancer.Order(c.hosts) {
+		var conn driver.Conn
+		start := time.Now()
+		conn, err = c.connect(ctx, h.base, h)
+		h.observe(time.Since(start), err)
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// Hosts returns the servers of a multi-host Connector along with their
+// health.
+func (c *Connector) Hosts() []*HostState {
+	return c.hosts
+}
+
+func (c *Connector) connect(ctx context.Context, base driver.Connector, h *HostState) (driver.Conn, error) {
+	conn, err := base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.noticeConn(base, conn)
+	if h != nil {
+		readOnly, err := queryConn(ctx, conn, "SHOW transaction_read_only")
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		h.setReadOnly(readOnly == "on")
+	}
+	if err := c.setup(ctx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Driver implements driver.Connector.
+func (c *Connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// DB returns a connection pool using c.
+func (c *Connector) DB() *sql.DB {
+	return sql.OpenDB(c)
+}
+
+func (c *Connector) setup(ctx context.Context, conn driver.Conn) error {
+	if c.TargetSessionAttrs == TargetReadWrite {
+		readOnly, err := queryConn(ctx, conn, "SHOW transaction_read_only")
+		if err != nil {
+			return err
+		}
+		if readOnly == "on" {
+			return ErrReadOnlyServer
+		}
+	}
+	if len(c.SearchPath) > 0 {
+		if err := execConn(ctx, conn, searchPathSQL(c.SearchPath)); err != nil {
+			return err
+		}
+	}
+	for _, hook := range c.AfterConnect {
+		if err := hook(SessionConn{ctx: ctx, conn: conn}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SessionConn is a new connection being set up by an AfterConnect hook.
+type SessionConn struct {
+	ctx  context.Context
+	conn driver.Conn
+}
+
+// Exec runs query with args bound to its $n parameters. The arguments must
+// be driver values, such as strings.
+func (c SessionConn) Exec(query string, args ...driver.Value) error {
+	return execConn(c.ctx, c.conn, query, args...)
+}
+
+// QueryValue returns the single value returned by query, which can not have
+// parameters.
+func (c SessionConn) QueryValue(query string) (string, error) {
+	return queryConn(c.ctx, c.conn, query)
+}
+
+// Driver returns the driver's connection.
+func (c SessionConn) Driver() driver.Conn {
+	return c.conn
+}
+
+// SetSession returns an AfterConnect hook setting the session setting name
+// to value, e.g. SetSession("timezone", "UTC").
+func SetSession(name, value string) func(conn SessionConn) error {
+	return func(conn SessionConn) error {
+		return conn.Exec("SELECT set_config($1, $2, false)", name, value)
+	}
+}
+
+// execConn runs query with args on a raw driver connection.
+func execConn(ctx context.Context, conn driver.Conn, query string, args ...driver.Value) error {
+	if e, ok := conn.(driver.ExecerContext); ok {
+		named := make([]driver.NamedValue, len(args))
+		for i, arg := range args {
+			named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+		}
+		_, err := e.ExecContext(ctx, query, named)
+		return err
+	}
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if _, err := stmt.Exec(args); err != nil {
+		return err
+	}
+	return nil
+}
+
+// queryConn returns the single value returned by query on a raw driver
+// connection.
+func queryConn(ctx context.Context, conn driver.Conn, query string) (string, error) {
+	q, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return "", fmt.Errorf("can not query on %T", conn)
+	}
+	rows, err := q.QueryContext(ctx, query, nil)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, len(rows.Columns()))
+	if err := rows.Next(dest); err != nil {
+		return "", err
+	}
+	if len(dest) == 0 || dest[0] == nil {
+		return "", nil
+	}
+	switch v := dest[0].(type) {
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	}
+	return fmt.Sprint(dest[0]), nil
+}
+
+func searchPathSQL(schemas []string) string {
+	quoted := make([]string, len(schemas))
+	for i, schema := range schemas {
+		quoted[i] = pq.QuoteIdentifier(schema)
+	}
+	return fmt.Sprintf("SET search_path TO %v", strings.Join(quoted, ", "))
+}
+
+// SetSearchPath sets the search_path of the session behind the dialect's
+// connection. On a pool this only affects one connection; use
+// Connector.SearchPath to configure every connection. In PgBouncer mode the
+// dialect's connection must be a transaction, to which the setting is
+// confined.
+func (s Postgres) SetSearchPath(schemas ...string) error {
+	if len(schemas) == 0 {
+		return errors.New("search_path needs at least one schema")
+	}
+	query := searchPathSQL(schemas)
+	if s.PgBouncer {
+		if _, ok := s.DB.(txBeginner); ok {
+			return ErrPgBouncer
+		}
+		query = strings.Replace(query, "SET ", "SET LOCAL ", 1)
+	}
+	_, err := s.DB.Exec(query)
+	return err
+}
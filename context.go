@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// contextCommon is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type contextCommon interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ctxDB runs every statement of a connection with a context.
+type ctxDB struct {
+	ctx context.Context
+	db  contextCommon
+}
+
+func (c ctxDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.db.ExecContext(c.ctx, query, args...)
+}
+
+func (c ctxDB) Prepare(query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(c.ctx, query)
+}
+
+func (c ctxDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.db.QueryContext(c.ctx, query, args...)
+}
+
+func (c ctxDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.db.QueryRowContext(c.ctx, query, args...)
+}
+
+// contextPool is implemented by connection pools taking a context, such as
+// *sql.DB.
+type contextPool interface {
+	contextCommon
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// ctxPool is a ctxDB over a pool, which can begin transactions.
+type ctxPool struct {
+	ctxDB
+	pool contextPool
+}
+
+// Begin starts a transaction bound to the context, so that it is rolled
+// back when the context is done.
+func (c ctxPool) Begin() (*sql.Tx, error) {
+	return c.pool.BeginTx(c.ctx, nil)
+}
+
+func (c ctxPool) Conn(ctx context.Context) (*sql.Conn, error) {
+	return c.pool.Conn(ctx)
+}
+
+// WithContext returns a copy of the dialect whose statements run with ctx.
+// When ctx is cancelled or times out, both lib/pq and pgx send a cancel
+// request to the server, so the running statement is actually stopped
+// instead of merely being abandoned by the client. Connections that do not
+// take a context, such as a LoggedDB, are used as is.
+func (s Postgres) WithContext(ctx context.Context) Postgres {
+	var db interface{} = s.DB
+	switch c := db.(type) {
+	case ctxPool:
+		db = c.pool
+	case ctxDB:
+		db = c.db
+	}
+	switch c := db.(type) {
+	case contextPool:
+		s.DB = ctxPool{ctxDB: ctxDB{ctx: ctx, db: c}, pool: c}
+	case contextCommon:
+		s.DB = ctxDB{ctx: ctx, db: c}
+	}
+	return s
+}
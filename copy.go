@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/lib/pq"
+	"github.com/ngorm/ngorm/model"
+)
+
+// CopyFrom loads rows into columns of tableName with COPY FROM STDIN, which
+// is much faster than INSERT for large imports. It returns the number of rows
+// copied.
+//
+// With DriverPgx the copy goes through pgx's native CopyFrom, which needs the
+// dialect's connection to be a pool: it fails inside a transaction begun by
+// the caller, as database/sql gives no access to its connection.
+func (s Postgres) CopyFrom(tableName string, columns []string, rows [][]interface{}) (int64, error) {
+	i := 0
+	return s.copyIn(tableName, columns, func() ([]interface{}, bool) {
+		if i == len(rows) {
+			return nil, false
+		}
+		i++
+		return rows[i-1], true
+	})
+}
+
+// CopyFromChan is like CopyFrom but streams rows from a channel until it is
+// closed, so the input never has to be held in memory. If the copy fails the
+// rest of the channel is drained so the sender does not block.
+func (s Postgres) CopyFromChan(tableName string, columns []string, rows <-chan []interface{}) (int64, error) {
+	n, err := s.copyIn(tableName, columns, func() ([]interface{}, bool) {
+		row, ok := <-rows
+		return row, ok
+	})
+	if err != nil {
+		go func() {
+			for range rows {
+			}
+		}()
+	}
+	return n, err
+}
+
+// CopyModels loads models, a slice of structs or struct pointers, into
+// tableName with COPY. Columns are chosen as in InsertModels.
+func (s Postgres) CopyModels(tableName string, models interface{}, columns []string) (int64, error) {
+	columns, rows, err := modelRows(models, columns, false)
+	if err != nil {
+		return 0, err
+	}
+	return s.CopyFrom(tableName, columns, rows)
+}
+
+func (s Postgres) copyIn(tableName string, columns []string, next func() ([]interface{}, bool)) (int64, error) {
+	var n int64
+	err := s.withCopyTx(func(db model.SQLCommon) error {
+		var err error
+		n, err = copyInto(db, tableName, columns, next)
+		return err
+	})
+	return n, err
+}
+
+// copyTx is a transaction run on a dedicated connection, which copyInto
+// reaches for the native COPY of pgx.
+type copyTx struct {
+	*sql.Tx
+	ctx  context.Context
+	conn *sql.Conn
+}
+
+// withCopyTx is like withTx, but runs the transaction as a copyTx when the
+// dialect's connection is a pool.
+func (s Postgres) withCopyTx(fn func(db model.SQLCommon) error) error {
+	c, ok := s.DB.(conner)
+	if _, isPool := s.DB.(txBeginner); !ok || !isPool {
+		return s.withTx(fn)
+	}
+	ctx := context.Background()
+	if p, ok := s.DB.(ctxPool); ok {
+		ctx = p.ctx
+	}
+	conn, err := c.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	return s.runTx(tx, copyTx{Tx: tx, ctx: ctx, conn: conn}, fn)
+}
+
+// copyInto runs a COPY into tableName on db, which must be bound to a single
+// connection. The rows are sent with pgx's CopyFrom when db is a copyTx on a
+// pgx connection, and with lib/pq's COPY statement otherwise.
+func copyInto(db model.SQLCommon, tableName string, columns []string, next func() ([]interface{}, bool)) (int64, error) {
+	if tx, ok := db.(copyTx); ok {
+		var n int64
+		native := false
+		err := tx.conn.Raw(func(dc interface{}) error {
+			c, ok := dc.(*stdlib.Conn)
+			if !ok {
+				return nil
+			}
+			native = true
+			var err error
+			n, err = c.Conn().CopyFrom(tx.ctx, pgx.Identifier{tableName}, columns, pgx.CopyFromFunc(func() ([]interface{}, error) {
+				row, _ := next()
+				return row, nil
+			}))
+			return err
+		})
+		if native || err != nil {
+			return n, err
+		}
+	}
+	stmt, err := db.Prepare(pq.CopyIn(tableName, columns...))
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for {
+		row, ok := next()
+		if !ok {
+			break
+		}
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return n, err
+		}
+		n++
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return n, err
+	}
+	return n, stmt.Close()
+}
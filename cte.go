@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Materialization hints for common table expressions. They require
+// Postgres 12 or later.
+const (
+	MaterializeDefault = iota
+	Materialized
+	NotMaterialized
+)
+
+// CTE is a common table expression attached to a query with With.
+type CTE struct {
+	Name string
+
+	// Columns optionally renames the columns produced by Query.
+	Columns []string
+
+	Query Expr
+
+	// Materialize is one of MaterializeDefault, Materialized or
+	// NotMaterialized.
+	Materialize int
+}
+
+// With prefixes query with a WITH clause defining ctes, in order.
+//
+//	q := s.With(postgres.Raw("SELECT * FROM recent WHERE total > ?", 100),
+//		postgres.CTE{Name: "recent", Query: postgres.Raw("SELECT ...")})
+//	db.Raw(q.SQL, q.Args...)
+func (s Postgres) With(query Expr, ctes ...CTE) Expr {
+	return s.with("WITH ", query, ctes)
+}
+
+// WithRecursive is like With but allows the expressions to refer to
+// themselves.
+func (s Postgres) WithRecursive(query Expr, ctes ...CTE) Expr {
+	return s.with("WITH RECURSIVE ", query, ctes)
+}
+
+func (s Postgres) with(keyword string, query Expr, ctes []CTE) Expr {
+	if len(ctes) == 0 {
+		return query
+	}
+	defs := make([]Expr, len(ctes))
+	for i, c := range ctes {
+		defs[i] = s.cte(c)
+	}
+	clause := Join(", ", defs...)
+	clause.SQL = keyword + clause.SQL
+	return Join(" ", clause, query)
+}
+
+func (s Postgres) cte(c CTE) Expr {
+	var buf strings.Builder
+	buf.WriteString(s.Quote(c.Name))
+	if len(c.Columns) > 0 {
+		fmt.Fprintf(&buf, "(%v)", s.quoteColumns(c.Columns))
+	}
+	buf.WriteString(" AS ")
+	switch c.Materialize {
+	case Materialized:
+		buf.WriteString("MATERIALIZED ")
+	case NotMaterialized:
+		buf.WriteString("NOT MATERIALIZED ")
+	}
+	fmt.Fprintf(&buf, "(%v)", c.Query.SQL)
+	return Expr{SQL: buf.String(), Args: c.Query.Args}
+}
@@ -0,0 +1,126 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// CTIDColumn is the system column holding the physical location of a row
+// version. It changes when the row is updated or the table rewritten, so it
+// only identifies a row within a single transaction or maintenance job.
+const CTIDColumn = "ctid"
+
+// CTID is the physical location of a row version: its page and its position
+// in the page. A model field of type CTID is filled from the ctid column by
+// ScanRows, like XID fields are from xmin.
+type CTID struct {
+	Block  uint32
+	Offset uint16
+}
+
+var ctidType = reflect.TypeOf(CTID{})
+
+// String returns the text form of c, e.g. (0,1).
+func (c CTID) String() string {
+	return fmt.Sprintf("(%d,%d)", c.Block, c.Offset)
+}
+
+// Scan implements sql.Scanner.
+func (c *CTID) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*c = CTID{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return fmt.Errorf("can not scan %T into a CTID", src)
+	}
+	if _, err := fmt.Sscanf(s, "(%d,%d)", &c.Block, &c.Offset); err != nil {
+		return fmt.Errorf("invalid ctid %q", s)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (c CTID) Value() (driver.Value, error) {
+	return c.String(), nil
+}
+
+// WithSystemColumns returns a select list of every column along with
+// columns, or with ctid and xmin when none are given, to be scanned into
+// models with CTID and XID fields:
+//
+//	db.Select(s.WithSystemColumns()).Where(...)
+func (s Postgres) WithSystemColumns(columns ...string) string {
+	if len(columns) == 0 {
+		columns = []string{CTIDColumn, XMinColumn}
+	}
+	return "*, " + s.quoteColumns(columns)
+}
+
+// CTIDIn returns the predicate selecting the row versions at ids, which
+// the server reads with a TID scan instead of going through an index.
+func (s Postgres) CTIDIn(ids ...CTID) Expr {
+	texts := make([]string, len(ids))
+	for i, id := range ids {
+		texts[i] = id.String()
+	}
+	return Expr{SQL: CTIDColumn + " = ANY(?::tid[])", Args: []interface{}{pq.Array(texts)}}
+}
+
+// CTIDPages returns the predicate selecting the rows stored in pages from
+// (inclusive) to to (exclusive), for maintenance jobs walking a table in
+// physical order without relying on an index:
+//
+//	pages, err := s.TablePages("events")
+//	for from := uint32(0); from < pages; from += 1000 {
+//		e := Join(" WHERE ", Raw(`UPDATE events SET ...`), s.CTIDPages(from, from+1000))
+//		...
+//	}
+//
+// Servers from 14 on read only the pages in range; older ones scan the
+// whole table for each batch.
+func (s Postgres) CTIDPages(from, to uint32) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("%v >= ?::tid AND %v < ?::tid", CTIDColumn, CTIDColumn),
+		Args: []interface{}{CTID{Block: from}, CTID{Block: to}},
+	}
+}
+
+// TablePages returns the number of pages tableName currently occupies.
+func (s Postgres) TablePages(tableName string) (uint32, error) {
+	var n uint32
+	err := s.DB.QueryRow("SELECT pg_relation_size($1::regclass) / current_setting('block_size')::int",
+		s.Quote(tableName)).Scan(&n)
+	return n, err
+}
+
+// DeleteDuplicates deletes the rows of tableName that have the same values
+// in columns as another row, keeping one row per set of duplicates: the
+// first by the ORDER BY list keep, e.g. "id" or "created_at DESC", or an
+// arbitrary one when keep is empty. It returns the number of rows deleted.
+// Rows are told apart by ctid, so tables without a primary key can be
+// cleaned up too, for instance before adding a unique index.
+func (s Postgres) DeleteDuplicates(tableName string, columns []string, keep string) (int64, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("no columns to find duplicates of %s by", tableName)
+	}
+	if keep == "" {
+		keep = CTIDColumn
+	}
+	query := fmt.Sprintf(`
+DELETE FROM %v
+WHERE  %v IN (SELECT %v
+              FROM   (SELECT %v, row_number() OVER (PARTITION BY %v ORDER BY %v) AS n
+                      FROM   %v) AS d
+              WHERE  n > 1)
+	`, s.Quote(tableName), CTIDColumn, CTIDColumn, CTIDColumn, s.quoteColumns(columns), keep, s.Quote(tableName))
+	return s.execAffected(query)
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// DefaultFetchSize is the number of rows fetched per round trip by Stream
+// when no fetch size is given.
+const DefaultFetchSize = 1000
+
+var cursorSeq uint64
+
+// Stream runs query through a server side cursor, fetching fetchSize rows at
+// a time, and calls fn once per row with rows positioned on it. Memory use is
+// bounded by the fetch size no matter how large the result is. Returning an
+// error from fn stops the iteration.
+//
+// Cursors live in a transaction: the dialect's transaction is used when it
+// has one, otherwise a transaction is opened for the duration of the stream.
+func (s Postgres) Stream(fetchSize int, query Expr, fn func(rows *sql.Rows) error) error {
+	if fetchSize <= 0 {
+		fetchSize = DefaultFetchSize
+	}
+	name := s.Quote(fmt.Sprintf("ngorm_cursor_%d", atomic.AddUint64(&cursorSeq, 1)))
+	return s.withTx(func(db model.SQLCommon) error {
+		q, args := s.Build(query)
+		if _, err := db.Exec(fmt.Sprintf("DECLARE %v NO SCROLL CURSOR FOR %v", name, q), args...); err != nil {
+			return err
+		}
+		defer db.Exec("CLOSE " + name)
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM %v", fetchSize, name)
+		for {
+			rows, err := db.Query(fetch)
+			if err != nil {
+				return err
+			}
+			n := 0
+			for rows.Next() {
+				n++
+				if err := fn(rows); err != nil {
+					rows.Close()
+					return err
+				}
+			}
+			if err := rows.Err(); err != nil {
+				rows.Close()
+				return err
+			}
+			rows.Close()
+			if n < fetchSize {
+				return nil
+			}
+		}
+	})
+}
+
+// StreamInto is like Stream but scans every row into dest, a pointer to a
+// struct, before calling fn. dest is reused between rows, so fn must copy
+// anything it wants to keep.
+func (s Postgres) StreamInto(fetchSize int, query Expr, dest interface{}, fn func() error) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("stream destination must be a pointer to a struct, got %T", dest)
+	}
+	v = v.Elem()
+	zero := reflect.Zero(v.Type())
+	var cols []string
+	return s.Stream(fetchSize, query, func(rows *sql.Rows) error {
+		if cols == nil {
+			var err error
+			if cols, err = rows.Columns(); err != nil {
+				return err
+			}
+		}
+		v.Set(zero)
+		if err := scanStruct(rows, cols, v); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
@@ -0,0 +1,226 @@
+package postgres
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Formats of the ER diagrams written by WriteDiagram.
+const (
+	// DiagramDOT is the Graphviz language, rendered with e.g.
+	// dot -Tsvg schema.dot > schema.svg.
+	DiagramDOT = "dot"
+
+	// DiagramMermaid is a Mermaid erDiagram, rendered by most code hosts
+	// inside a mermaid code block.
+	DiagramMermaid = "mermaid"
+)
+
+// WriteDiagram writes an entity relationship diagram of tables to w in
+// format, DiagramDOT or DiagramMermaid, with the columns of every table,
+// their primary and foreign keys, and an edge per foreign key.
+func WriteDiagram(w io.Writer, format string, tables []*TableInfo) error {
+	var b strings.Builder
+	switch format {
+	case DiagramDOT:
+		writeDOT(&b, tables)
+	case DiagramMermaid:
+		writeMermaid(&b, tables)
+	default:
+		return fmt.Errorf("unknown diagram format %q", format)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SchemaDiagram writes the diagram of the tables found in the database.
+func (s Postgres) SchemaDiagram(w io.Writer, format string) error {
+	tables, err := s.Inspector().All()
+	if err != nil {
+		return err
+	}
+	return WriteDiagram(w, format, tables)
+}
+
+// ModelDiagram writes the diagram of the tables of the registered models
+// described by e, without a connection. Foreign keys are taken from the
+// FOREIGN KEY constraints of e.
+func (s Postgres) ModelDiagram(w io.Writer, format string, e Exporter) error {
+	tables, err := s.modelTables(e)
+	if err != nil {
+		return err
+	}
+	return WriteDiagram(w, format, tables)
+}
+
+var foreignKeyRe = regexp.MustCompile(`(?i)FOREIGN\s+KEY\s*\(([^)]*)\)\s*REFERENCES\s+("[^"]+"|[\w.]+)\s*(?:\(([^)]*)\))?`)
+
+// modelTables describes the tables of e the way the Inspector would once
+// they are created.
+func (s Postgres) modelTables(e Exporter) ([]*TableInfo, error) {
+	byName := make(map[string]*TableInfo, len(e.Tables))
+	tables := make([]*TableInfo, 0, len(e.Tables))
+	for _, t := range e.Tables {
+		info := &TableInfo{Name: t.Name}
+		var primary []string
+		for i, field := range columnFields(t.Fields) {
+			sqlType, additionalType, err := s.sqlTypeOf(field)
+			if err != nil {
+				return nil, err
+			}
+			info.Columns = append(info.Columns, ColumnInfo{
+				Name:     field.DBName,
+				Position: i + 1,
+				DataType: sqlType,
+				Nullable: declaredNullable(field, additionalType),
+			})
+			if field.IsPrimaryKey {
+				primary = append(primary, field.DBName)
+			}
+		}
+		if len(primary) > 0 {
+			info.Constraints = append(info.Constraints, ConstraintInfo{
+				Name:    t.Name + "_pkey",
+				Type:    PrimaryKeyConstraint,
+				Columns: primary,
+			})
+		}
+		byName[t.Name] = info
+		tables = append(tables, info)
+	}
+	for _, c := range e.Constraints {
+		m := foreignKeyRe.FindStringSubmatch(c.Definition)
+		info := byName[c.Table]
+		if m == nil || info == nil {
+			continue
+		}
+		info.Constraints = append(info.Constraints, ConstraintInfo{
+			Name:       c.Name,
+			Type:       ForeignKeyConstraint,
+			Columns:    identList(m[1]),
+			RefTable:   strings.Trim(m[2], `"`),
+			RefColumns: identList(m[3]),
+			Definition: c.Definition,
+		})
+	}
+	return tables, nil
+}
+
+// identList splits a comma separated list of possibly quoted identifiers.
+func identList(list string) []string {
+	var idents []string
+	for _, ident := range strings.Split(list, ",") {
+		if ident = strings.Trim(strings.TrimSpace(ident), `"`); ident != "" {
+			idents = append(idents, ident)
+		}
+	}
+	return idents
+}
+
+// keyColumns returns the columns of t that are part of a primary key and
+// of a foreign key.
+func keyColumns(t *TableInfo) (primary, foreign map[string]bool) {
+	primary, foreign = map[string]bool{}, map[string]bool{}
+	for _, c := range t.Constraints {
+		for _, col := range c.Columns {
+			switch c.Type {
+			case PrimaryKeyConstraint:
+				primary[col] = true
+			case ForeignKeyConstraint:
+				foreign[col] = true
+			}
+		}
+	}
+	return primary, foreign
+}
+
+func writeDOT(b *strings.Builder, tables []*TableInfo) {
+	b.WriteString("digraph schema {\n\trankdir=LR;\n\tnode [shape=plaintext];\n")
+	for _, t := range tables {
+		primary, foreign := keyColumns(t)
+		fmt.Fprintf(b, "\t%q [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", t.Name)
+		fmt.Fprintf(b, "<tr><td bgcolor=\"lightgrey\"><b>%v</b></td></tr>", html.EscapeString(t.Name))
+		for _, col := range t.Columns {
+			var keys []string
+			if primary[col.Name] {
+				keys = append(keys, "PK")
+			}
+			if foreign[col.Name] {
+				keys = append(keys, "FK")
+			}
+			label := col.Name + " " + col.DataType
+			if len(keys) > 0 {
+				label += " " + strings.Join(keys, ",")
+			}
+			if !col.Nullable {
+				label += " NOT NULL"
+			}
+			fmt.Fprintf(b, "<tr><td port=%q align=\"left\">%v</td></tr>",
+				html.EscapeString(col.Name), html.EscapeString(label))
+		}
+		b.WriteString("</table>>];\n")
+	}
+	for _, t := range tables {
+		for _, c := range t.Constraints {
+			if c.Type != ForeignKeyConstraint || len(c.Columns) == 0 {
+				continue
+			}
+			to := fmt.Sprintf("%q", c.RefTable)
+			if len(c.RefColumns) > 0 {
+				to += fmt.Sprintf(":%q", c.RefColumns[0])
+			}
+			fmt.Fprintf(b, "\t%q:%q -> %v [label=%q];\n", t.Name, c.Columns[0], to, c.Name)
+		}
+	}
+	b.WriteString("}\n")
+}
+
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+
+// mermaidName makes s usable as a Mermaid entity, attribute or type name.
+func mermaidName(s string) string {
+	return strings.Trim(mermaidUnsafe.ReplaceAllString(s, "_"), "_")
+}
+
+func writeMermaid(b *strings.Builder, tables []*TableInfo) {
+	b.WriteString("erDiagram\n")
+	for _, t := range tables {
+		primary, foreign := keyColumns(t)
+		fmt.Fprintf(b, "    %v {\n", mermaidName(t.Name))
+		for _, col := range t.Columns {
+			var keys []string
+			if primary[col.Name] {
+				keys = append(keys, "PK")
+			}
+			if foreign[col.Name] {
+				keys = append(keys, "FK")
+			}
+			fmt.Fprintf(b, "        %v %v", mermaidName(col.DataType), mermaidName(col.Name))
+			if len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ", "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+	for _, t := range tables {
+		for _, c := range t.Constraints {
+			if c.Type != ForeignKeyConstraint {
+				continue
+			}
+			// A row references at most one row, or exactly one when every
+			// column of the key is NOT NULL.
+			ref := "||"
+			for _, name := range c.Columns {
+				if col := t.Column(name); col == nil || col.Nullable {
+					ref = "o|"
+				}
+			}
+			fmt.Fprintf(b, "    %v }o--%v %v : %q\n",
+				mermaidName(t.Name), ref, mermaidName(c.RefTable), strings.Join(c.Columns, ", "))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
@@ -18,6 +19,22 @@ func init() {
 
 type Postgres struct {
 	common.Dialect
+
+	// PgBouncer makes the dialect avoid session level features, which do not
+	// work behind PgBouncer in transaction pooling mode: session advisory
+	// locks and session settings are refused. Use the transaction scoped
+	// alternatives instead.
+	PgBouncer bool
+
+	// Target, when set, is the feature set the generated SQL is restricted
+	// to, e.g. CapabilitiesOf(110000) to keep PostgreSQL 11 supported. The
+	// server's capabilities are used otherwise.
+	Target *Capabilities
+
+	// Tenant, when set, is stored in the TenantSetting of every transaction
+	// the dialect starts, for the row level security policies created by
+	// EnableTenantRLS. See ForTenant.
+	Tenant string
 }
 
 func (Postgres) GetName() string {
@@ -28,7 +45,38 @@ func (Postgres) BindVar(i int) string {
 	return fmt.Sprintf("$%v", i)
 }
 
-func (Postgres) DataTypeOf(field *model.StructField) (string, error) {
+func (s Postgres) DataTypeOf(field *model.StructField) (string, error) {
+	sqlType, additionalType, err := s.sqlTypeOf(field)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := field.TagSettings["IDENTITY"]; ok {
+		if sqlType, err = s.identityType(sqlType); err != nil {
+			return "", err
+		}
+	}
+
+	if method, ok := field.TagSettings["COMPRESSION"]; ok {
+		method = strings.ToLower(strings.TrimSpace(method))
+		if !isCompressionMethod(method) {
+			return "", fmt.Errorf("invalid compression method %s for postgres", method)
+		}
+		// Servers without compression options use pglz for every column.
+		if s.caps().Compression {
+			sqlType = fmt.Sprintf("%v COMPRESSION %v", sqlType, method)
+		}
+	}
+
+	if strings.TrimSpace(additionalType) == "" {
+		return sqlType, nil
+	}
+	return fmt.Sprintf("%v %v", sqlType, additionalType), nil
+}
+
+// sqlTypeOf returns the bare column type of field along with any additional
+// column definition (constraints, defaults) declared in its tags.
+func (Postgres) sqlTypeOf(field *model.StructField) (string, string, error) {
 	dataValue, sqlType, size, additionalType :=
 		model.ParseFieldStructForDialect(field)
 	if sqlType == "" {
@@ -83,66 +131,126 @@ func (Postgres) DataTypeOf(field *model.StructField) (string, error) {
 	}
 
 	if sqlType == "" {
-		return "", fmt.Errorf("invalid sql type %s (%s) for postgres",
-			dataValue.Type().Name(), dataValue.Kind().String())
+		if m, ok := mapperOf(dataValue.Type()); ok {
+			sqlType = m.SQLType
+		}
 	}
 
-	if strings.TrimSpace(additionalType) == "" {
-		return sqlType, nil
+	if sqlType == "" {
+		return "", "", fmt.Errorf("invalid sql type %s (%s) for postgres",
+			dataValue.Type().Name(), dataValue.Kind().String())
 	}
-	return fmt.Sprintf("%v %v", sqlType, additionalType), nil
+	return sqlType, additionalType, nil
 }
 
 func (s Postgres) HasIndex(tableName string, indexName string) bool {
-	var count int
-	s.DB.QueryRow(
-		"SELECT count(*) FROM pg_indexes WHERE tablename = $1 AND indexname = $2",
-		tableName, indexName).Scan(&count)
-	return count > 0
+	ok, _ := s.IndexExists(tableName, indexName)
+	return ok
+}
+
+// IndexExists is like HasIndex but reports the errors of the lookup.
+func (s Postgres) IndexExists(tableName string, indexName string) (bool, error) {
+	return s.exists(
+		"SELECT count(*) FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1 AND indexname = $2",
+		tableName, indexName)
 }
 
 func (s Postgres) HasForeignKey(tableName string, foreignKeyName string) bool {
-	var count int
+	ok, _ := s.ForeignKeyExists(tableName, foreignKeyName)
+	return ok
+}
+
+// ForeignKeyExists is like HasForeignKey but reports the errors of the
+// lookup.
+func (s Postgres) ForeignKeyExists(tableName string, foreignKeyName string) (bool, error) {
 	query := `
 SELECT Count(con.conname)
 FROM   pg_constraint con
-WHERE  $1 :: regclass :: oid = con.conrelid
+       JOIN pg_class t ON t.oid = con.conrelid
+       JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE  n.nspname = current_schema()
+       AND t.relname = $1
        AND con.conname = $2
        AND con.contype = 'f'
 	`
-	s.DB.QueryRow(query, tableName, foreignKeyName).Scan(&count)
-	return count > 0
+	return s.exists(query, tableName, foreignKeyName)
 }
 
 func (s Postgres) HasTable(tableName string) bool {
-	var count int
+	ok, _ := s.TableExists(tableName)
+	return ok
+}
+
+// TableExists is like HasTable but reports the errors of the lookup, such
+// as a lost connection, instead of taking them for a missing table.
+func (s Postgres) TableExists(tableName string) (bool, error) {
 	query := `
 SELECT Count(*)
 FROM   information_schema.tables
-WHERE  table_name = $1
+WHERE  table_schema = current_schema()
+       AND table_name = $1
        AND table_type = 'BASE TABLE'
 	`
-	s.DB.QueryRow(query, tableName).Scan(&count)
-	return count > 0
+	return s.exists(query, tableName)
 }
 
 func (s Postgres) HasColumn(tableName string, columnName string) bool {
-	var count int
+	ok, _ := s.ColumnExists(tableName, columnName)
+	return ok
+}
+
+// ColumnExists is like HasColumn but reports the errors of the lookup.
+func (s Postgres) ColumnExists(tableName string, columnName string) (bool, error) {
 	query := `
 SELECT Count(*)
 FROM   information_schema.columns
-WHERE  table_name = $1
+WHERE  table_schema = current_schema()
+       AND table_name = $1
        AND column_name = $2
 	`
-	s.DB.QueryRow(query, tableName, columnName).Scan(&count)
-	return count > 0
+	return s.exists(query, tableName, columnName)
+}
+
+// exists runs a count(*) query and reports whether it counted any row.
+func (s Postgres) exists(query string, args ...interface{}) (bool, error) {
+	var count int
+	if err := s.DB.QueryRow(query, args...).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 func (s Postgres) CurrentDatabase() (name string) {
-	s.DB.QueryRow("SELECT CURRENT_DATABASE()").Scan(&name)
+	name, _ = s.DatabaseName()
+	return
+}
+
+// DatabaseName is like CurrentDatabase but reports the errors of the
+// lookup.
+func (s Postgres) DatabaseName() (string, error) {
+	var name string
+	err := s.DB.QueryRow("SELECT CURRENT_DATABASE()").Scan(&name)
+	return name, err
+}
+
+func (s Postgres) CurrentSchema() (name string) {
+	name, _ = s.SchemaName()
 	return
 }
 
+// SchemaName is like CurrentSchema but reports the errors of the lookup,
+// ErrNotFound when no schema of the search_path exists.
+func (s Postgres) SchemaName() (string, error) {
+	var name sql.NullString
+	if err := s.DB.QueryRow("SELECT CURRENT_SCHEMA()").Scan(&name); err != nil {
+		return "", err
+	}
+	if !name.Valid {
+		return "", ErrNotFound
+	}
+	return name.String, nil
+}
+
 func (s Postgres) LastInsertIDReturningSuffix(tableName, key string) string {
 	return fmt.Sprintf("RETURNING %v.%v", tableName, key)
 }
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// DistinctOn returns the DISTINCT ON (columns) prefix of a select list, e.g.
+//
+//	db.Select(s.DistinctOn("user_id") + " *").Order("user_id, created_at DESC")
+//
+// Postgres requires the ORDER BY clause to start with the same columns.
+func (s Postgres) DistinctOn(columns ...string) string {
+	return fmt.Sprintf("DISTINCT ON (%v)", s.quoteColumns(columns))
+}
+
+// FirstPerGroup returns a query selecting, for every distinct combination of
+// groupBy in tableName, the first row according to orderBy: with orderBy
+// "created_at DESC" it yields the latest row per group. The ORDER BY clause
+// is assembled with the group columns first, as DISTINCT ON requires.
+func (s Postgres) FirstPerGroup(tableName string, groupBy []string, orderBy string, where Expr) Expr {
+	e := Expr{SQL: fmt.Sprintf("SELECT %v * FROM %v", s.DistinctOn(groupBy...), s.Quote(tableName))}
+	if where.SQL != "" {
+		e = Join(" WHERE ", e, where)
+	}
+	e.SQL += " ORDER BY " + s.quoteColumns(groupBy)
+	if orderBy != "" {
+		e.SQL += ", " + orderBy
+	}
+	return e
+}
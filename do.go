@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Do runs the anonymous PL/pgSQL block body with DO, for migrations that
+// need conditional logic, such as creating a type unless it exists on servers
+// without CREATE TYPE IF NOT EXISTS:
+//
+//	err := s.Do(`
+//	BEGIN
+//		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = ?) THEN
+//			CREATE TYPE mood AS ENUM ('sad', 'ok', 'happy');
+//		END IF;
+//	END`, "mood")
+//
+// DO takes no parameters, so the ? placeholders of body are replaced by args
+// rendered as SQL literals; ?? is a literal question mark. Server errors are
+// translated with TranslateError.
+func (s Postgres) Do(body string, args ...interface{}) error {
+	query, err := DoSQL(body, args...)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return TranslateError(err)
+}
+
+// DoSQL returns the DO statement run by Do.
+func DoSQL(body string, args ...interface{}) (string, error) {
+	inlined, err := inlineArgs(body, args)
+	if err != nil {
+		return "", err
+	}
+	tag := "$do$"
+	for i := 0; strings.Contains(inlined, tag); i++ {
+		tag = fmt.Sprintf("$do%d$", i)
+	}
+	return fmt.Sprintf("DO %v%v%v", tag, inlined, tag), nil
+}
+
+// inlineArgs replaces the ? placeholders of query, outside strings, quoted
+// identifiers, comments and dollar quoted bodies, by the literal form of
+// args.
+func inlineArgs(query string, args []interface{}) (string, error) {
+	return inline(query, args, false)
+}
+
+// inlineBound replaces the $n bind variables of query, outside strings,
+// quoted identifiers, comments and dollar quoted bodies, by the literal form
+// of args, for statements that must be run without parameters, such as those
+// of a script.
+func inlineBound(query string, args []interface{}) (string, error) {
+	return inline(query, args, true)
+}
+
+func inline(query string, args []interface{}, numbered bool) (string, error) {
+	var buf strings.Builder
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if j := quotedEnd(query, i); j > i {
+			buf.WriteString(query[i:j])
+			i = j - 1
+			continue
+		}
+		c := query[i]
+		switch {
+		case numbered && c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			k, _ := strconv.Atoi(query[i+1 : j])
+			if k < 1 || k > len(args) {
+				return "", fmt.Errorf("got %d arguments for $%d", len(args), k)
+			}
+			lit, err := sqlLiteral(args[k-1])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(lit)
+			i = j - 1
+			continue
+		case !numbered && c == '?':
+			if i+1 < len(query) && query[i+1] == '?' {
+				i++
+				break
+			}
+			if n >= len(args) {
+				return "", fmt.Errorf("got %d arguments for more placeholders", len(args))
+			}
+			lit, err := sqlLiteral(args[n])
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(lit)
+			n++
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	if !numbered && n != len(args) {
+		return "", fmt.Errorf("got %d arguments for %d placeholders", len(args), n)
+	}
+	return buf.String(), nil
+}
+
+// quotedEnd returns the end of the string, quoted identifier, comment or
+// dollar quoted body starting at query[i], or i when none starts there.
+// Unterminated ones run to the end of query.
+func quotedEnd(query string, i int) int {
+	c := query[i]
+	switch {
+	case c == '\'' || c == '"':
+		escapes := c == '\'' && i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') &&
+			(i < 2 || !isIdentChar(query[i-2]))
+		for j := i + 1; j < len(query); j++ {
+			if escapes && query[j] == '\\' {
+				j++
+				continue
+			}
+			if query[j] == c {
+				if j+1 < len(query) && query[j+1] == c {
+					j++
+					continue
+				}
+				return j + 1
+			}
+		}
+		return len(query)
+	case c == '-' && i+1 < len(query) && query[i+1] == '-':
+		if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+			return i + j
+		}
+		return len(query)
+	case c == '/' && i+1 < len(query) && query[i+1] == '*':
+		depth := 0
+		for j := i; j+1 < len(query); j++ {
+			if strings.HasPrefix(query[j:], "/*") {
+				depth++
+				j++
+			} else if strings.HasPrefix(query[j:], "*/") {
+				depth--
+				j++
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+		return len(query)
+	case c == '$' && (i == 0 || !isIdentChar(query[i-1])):
+		tag := dollarTag(query[i:])
+		if tag == "" {
+			return i
+		}
+		if end := strings.Index(query[i+len(tag):], tag); end >= 0 {
+			return i + len(tag) + end + len(tag)
+		}
+		return len(query)
+	}
+	return i
+}
+
+// sqlLiteral renders v as a SQL literal.
+func sqlLiteral(v interface{}) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		x, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		v = x
+	}
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteLiteral(x), nil
+	case []byte:
+		return `E'\\x` + hex.EncodeToString(x) + `'::bytea`, nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(x), nil
+	case float32:
+		return strconv.FormatFloat(float64(x), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	case time.Time:
+		return quoteLiteral(x.Format("2006-01-02 15:04:05.999999999Z07:00")) + "::timestamptz", nil
+	}
+	return "", fmt.Errorf("can not render %T as a SQL literal", v)
+}
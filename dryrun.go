@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// ErrDryRun is returned by the operations a DryRunDB can neither run nor
+// record.
+var ErrDryRun = errors.New("not supported in a dry run")
+
+// DryRunDB is a connection that records the statements passed to Exec
+// instead of running them, so the DDL automigrate would execute can be
+// reviewed and applied separately. Queries, such as the catalog lookups of
+// HasTable, still run on the wrapped connection, which should use a role that
+// can only read.
+type DryRunDB struct {
+	recorder
+	db model.SQLCommon
+}
+
+// NewDryRunDB returns a DryRunDB reading from db.
+func NewDryRunDB(db model.SQLCommon) *DryRunDB {
+	return &DryRunDB{db: db}
+}
+
+// Exec implements model.SQLCommon. It records query and its arguments, and
+// reports no affected rows.
+func (d *DryRunDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	d.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+// Prepare implements model.SQLCommon. Prepared statements could run
+// anything, so it fails with ErrDryRun.
+func (d *DryRunDB) Prepare(query string) (*sql.Stmt, error) {
+	return nil, ErrDryRun
+}
+
+// Query implements model.SQLCommon.
+func (d *DryRunDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.db.Query(query, args...)
+}
+
+// QueryRow implements model.SQLCommon.
+func (d *DryRunDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.db.QueryRow(query, args...)
+}
+
+// Script returns the recorded statements as a script, one statement per
+// line. Scripts take no parameters, so the arguments of the statements are
+// inlined as SQL literals; an argument without a literal form is an error.
+func (d *DryRunDB) Script() (string, error) {
+	var buf strings.Builder
+	for _, stmt := range d.Statements() {
+		query, err := inlineBound(stmt.SQL, stmt.Args)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(strings.TrimSuffix(query, ";"))
+		buf.WriteString(";\n")
+	}
+	return buf.String(), nil
+}
+
+// WriteTo writes Script to w.
+func (d *DryRunDB) WriteTo(w io.Writer) (int64, error) {
+	script, err := d.Script()
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.WriteString(w, script)
+	return int64(n), err
+}
+
+// DryRun calls fn with a copy of the dialect whose connection records the
+// statements instead of running them, and returns them as a script. fn
+// typically runs automigrate with the dialect it is given.
+func (s Postgres) DryRun(fn func(s Postgres) error) (string, error) {
+	d := NewDryRunDB(s.DB)
+	s.DB = d
+	if err := fn(s); err != nil {
+		return "", err
+	}
+	return d.Script()
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// EncryptionKeySetting is the session variable holding the key of the
+// encrypted columns.
+const EncryptionKeySetting = "app.encryption_key"
+
+// EncryptFunction is the name of the trigger function encrypting columns.
+const EncryptFunction = "encrypt_columns"
+
+// encryptFunctionSQL creates the encryption trigger function, whose
+// arguments are the columns to encrypt. Inserted values, and updated values
+// that changed, are encrypted with pgp_sym_encrypt_bytea. Writing fails
+// when no key is set rather than storing plain text.
+const encryptFunctionSQL = `
+CREATE OR REPLACE FUNCTION encrypt_columns() RETURNS trigger AS $$
+DECLARE
+	key text := current_setting('app.encryption_key', true);
+	col text;
+	plain bytea;
+	unchanged boolean;
+	changes jsonb := '{}';
+BEGIN
+	IF coalesce(key, '') = '' THEN
+		RAISE EXCEPTION 'app.encryption_key is not set';
+	END IF;
+	FOREACH col IN ARRAY TG_ARGV LOOP
+		EXECUTE format('SELECT ($1).%I', col) INTO plain USING NEW;
+		IF plain IS NULL THEN
+			CONTINUE;
+		END IF;
+		IF TG_OP = 'UPDATE' THEN
+			EXECUTE format('SELECT ($1).%I IS NOT DISTINCT FROM ($2).%I', col, col) INTO unchanged USING NEW, OLD;
+			IF unchanged THEN
+				CONTINUE;
+			END IF;
+		END IF;
+		changes := changes || jsonb_build_object(col, '\x' || encode(pgp_sym_encrypt_bytea(plain, key), 'hex'));
+	END LOOP;
+	RETURN jsonb_populate_record(NEW, changes);
+END;
+$$ LANGUAGE plpgsql
+`
+
+// Encrypted is a model field stored encrypted with pgcrypto in a bytea
+// column, for personal data at rest. The field holds the plain text; the
+// server encrypts it on write, in a trigger installed by EnableEncryption,
+// and decrypts it on read in the select list built by DecryptedSelect. The
+// key never leaves the session: it is set in EncryptionKeySetting, for every
+// connection with EncryptionKey or per transaction with WithEncryptionKey.
+//
+// Reading an encrypted column without decrypting it returns the cipher text.
+type Encrypted []byte
+
+var encryptedType = reflect.TypeOf(Encrypted(nil))
+
+func (e Encrypted) String() string {
+	return string(e)
+}
+
+// EncryptionKey returns an AfterConnect hook setting the encryption key of
+// every new connection. The key is bound as a parameter, so it stays out of
+// the statement text the server may log.
+func EncryptionKey(key string) func(conn SessionConn) error {
+	return SetSession(EncryptionKeySetting, key)
+}
+
+// WithEncryptionKey runs fn in a transaction using key to encrypt and
+// decrypt. The key is set with SET LOCAL semantics, so it never leaks to
+// other users of the pooled connection.
+func (s Postgres) WithEncryptionKey(key string, fn func(db model.SQLCommon) error) error {
+	if key == "" {
+		return errors.New("encryption key is empty")
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		if err := setLocal(db, EncryptionKeySetting, key); err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+func encryptTrigger(tableName string, columns []string) Trigger {
+	return Trigger{
+		Name:       tableName + "_encrypt_columns",
+		Timing:     Before,
+		Events:     []string{OnInsert, OnUpdate},
+		ForEachRow: true,
+		Function:   EncryptFunction,
+		Args:       columns,
+	}
+}
+
+// EnableEncryption installs pgcrypto and the encryption trigger function if
+// needed and makes the trigger of tableName encrypt columns, replacing the
+// columns it encrypted before. Rows already stored are left as they are.
+func (s Postgres) EnableEncryption(tableName string, columns ...string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("no columns of %s to encrypt", tableName)
+	}
+	t := encryptTrigger(tableName, columns)
+	create, err := s.CreateTriggerSQL(tableName, t)
+	if err != nil {
+		return err
+	}
+	if err := s.EnsureExtension("pgcrypto"); err != nil {
+		return err
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		for _, stmt := range []string{
+			encryptFunctionSQL,
+			fmt.Sprintf("DROP TRIGGER IF EXISTS %v ON %v", s.Quote(t.Name), s.Quote(tableName)),
+			create,
+		} {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EnsureEncryption enables the encryption of the Encrypted fields of value
+// on tableName. Models without such fields are ignored.
+func (s Postgres) EnsureEncryption(tableName string, value interface{}) error {
+	var columns []string
+	for _, c := range modelColumns(value) {
+		if c.typ == encryptedType {
+			columns = append(columns, c.name)
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	return s.EnableEncryption(tableName, columns...)
+}
+
+// DecryptedSelect returns the select list of the columns of value with its
+// Encrypted fields decrypted, for use with the query builder:
+//
+//	db.Select(s.DecryptedSelect(&User{})).Where("id = ?", id).First(&user)
+func (s Postgres) DecryptedSelect(value interface{}) string {
+	var list []string
+	for _, c := range modelColumns(value) {
+		if c.system {
+			continue
+		}
+		if c.typ == encryptedType {
+			list = append(list, fmt.Sprintf("pgp_sym_decrypt_bytea(%v, current_setting(%v)) AS %v",
+				s.Quote(c.name), quoteLiteral(EncryptionKeySetting), s.Quote(c.name)))
+		} else {
+			list = append(list, s.Quote(c.name))
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// typedColumn is a column stored by a struct field along with the field's
+// type.
+type typedColumn struct {
+	structColumn
+	typ reflect.Type
+}
+
+// modelColumns returns the columns of the model value, a struct or a
+// pointer to one.
+func modelColumns(value interface{}) []typedColumn {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var cols []typedColumn
+	for _, c := range structColumns(t) {
+		cols = append(cols, typedColumn{structColumn: c, typ: t.FieldByIndex(c.index).Type})
+	}
+	return cols
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/ngorm/ngorm/errmsg"
+)
+
+// Errors matched by the errors returned by TranslateError, for use with
+// errors.Is.
+var (
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrNotNullViolation     = errors.New("not null violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+)
+
+var errorsByCode = map[string]error{
+	UniqueViolation:      ErrUniqueViolation,
+	ForeignKeyViolation:  ErrForeignKeyViolation,
+	NotNullViolation:     ErrNotNullViolation,
+	CheckViolation:       ErrCheckViolation,
+	SerializationFailure: ErrSerializationFailure,
+}
+
+// Error is a server error translated by TranslateError. It matches the
+// corresponding Err* value with errors.Is, and unwraps to the driver's
+// error.
+type Error struct {
+	// Code is the SQLSTATE of the error.
+	Code    string
+	Message string
+
+	// Detail and Hint complement Message, e.g. "Key (email)=(a@b.c)
+	// already exists.".
+	Detail string
+	Hint   string
+
+	// Schema, Table, Column, DataType and Constraint name the object the
+	// error is about, when the server reports it.
+	Schema     string
+	Table      string
+	Column     string
+	DataType   string
+	Constraint string
+
+	kind error
+	err  error
+}
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Is reports whether target is the Err* value matching the code of e.
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && target == e.kind
+}
+
+// Unwrap returns the driver's error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// TranslateError returns err as an *Error when it was reported by the server
+// through lib/pq or pgx, so callers can test it with errors.Is:
+//
+//	if errors.Is(postgres.TranslateError(err), postgres.ErrUniqueViolation) {
+//		...
+//	}
+//
+// Other errors, including nil, are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		code := string(pqErr.Code)
+		return &Error{
+			Code:       code,
+			Message:    pqErr.Message,
+			Detail:     pqErr.Detail,
+			Hint:       pqErr.Hint,
+			Schema:     pqErr.Schema,
+			Table:      pqErr.Table,
+			Column:     pqErr.Column,
+			DataType:   pqErr.DataTypeName,
+			Constraint: pqErr.Constraint,
+			kind:       errorsByCode[code],
+			err:        err,
+		}
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return &Error{
+			Code:       pgErr.Code,
+			Message:    pgErr.Message,
+			Detail:     pgErr.Detail,
+			Hint:       pgErr.Hint,
+			Schema:     pgErr.SchemaName,
+			Table:      pgErr.TableName,
+			Column:     pgErr.ColumnName,
+			DataType:   pgErr.DataTypeName,
+			Constraint: pgErr.ConstraintName,
+			kind:       errorsByCode[pgErr.Code],
+			err:        err,
+		}
+	}
+	return err
+}
+
+// AsError returns the server error in err along with the objects it is
+// about, so that for instance a violation of the users_email_key constraint
+// can be reported as "email already taken". It reports false when err does
+// not come from the server.
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(TranslateError(err), &e)
+	return e, ok
+}
+
+// sqlState returns the SQLSTATE code reported by the server in err, or the
+// empty string when err does not come from the server.
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// ErrNotFound is returned by the dialect's helpers when a query that should
+// return a row returns none. It matches both the ORM's
+// errmsg.ErrRecordNotFound and sql.ErrNoRows with errors.Is.
+var ErrNotFound error = notFoundError{}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string {
+	return errmsg.ErrRecordNotFound.Error()
+}
+
+func (notFoundError) Is(target error) bool {
+	return target == errmsg.ErrRecordNotFound || target == sql.ErrNoRows
+}
+
+// NotFound maps sql.ErrNoRows to ErrNotFound and returns other errors
+// unchanged.
+func NotFound(err error) error {
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+// IsNotFound reports whether err means that no row was found.
+func IsNotFound(err error) bool {
+	return errors.Is(err, errmsg.ErrRecordNotFound) || errors.Is(err, sql.ErrNoRows)
+}
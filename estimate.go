@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// EstimatedCount returns the number of rows of tableName as estimated by the
+// planner statistics, for pagination of tables too large for count(*). The
+// estimate is reltuples scaled to the current size of the table, as the
+// planner does, so it follows inserts between analyzes.
+//
+// When maxAge is positive and the table was never analyzed or not within
+// maxAge, it is analyzed first. ANALYZE reads a sample of the table, which
+// costs far less than a count but is not free, so maxAge should be in the
+// order of minutes. With a zero maxAge a never analyzed table counts as
+// empty.
+func (s Postgres) EstimatedCount(tableName string, maxAge time.Duration) (int64, error) {
+	n, analyzed, err := s.estimate(tableName)
+	if err != nil {
+		return 0, err
+	}
+	if maxAge > 0 && (analyzed == nil || time.Since(*analyzed) > maxAge) {
+		if err := s.Analyze(tableName); err != nil {
+			return 0, err
+		}
+		n, _, err = s.estimate(tableName)
+	}
+	return n, err
+}
+
+func (s Postgres) estimate(tableName string) (int64, *time.Time, error) {
+	query := `
+SELECT CASE
+         WHEN c.reltuples < 0 THEN 0
+         WHEN c.relpages = 0 THEN c.reltuples
+         ELSE c.reltuples / c.relpages * (pg_relation_size(c.oid) / current_setting('block_size')::int)
+       END::bigint,
+       greatest(st.last_analyze, st.last_autoanalyze)
+FROM   pg_class c
+       LEFT JOIN pg_stat_user_tables st ON st.relid = c.oid
+WHERE  c.oid = to_regclass($1)
+	`
+	var n int64
+	var analyzed *time.Time
+	err := s.DB.QueryRow(query, s.Quote(tableName)).Scan(&n, &analyzed)
+	if err == sql.ErrNoRows {
+		return 0, nil, ErrNotFound
+	}
+	return n, analyzed, err
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ExplainOptions control Explain.
+type ExplainOptions struct {
+	// Analyze executes the query to report actual times and row counts.
+	// Beware that data modifying statements are carried out.
+	Analyze bool
+
+	// Buffers reports buffer usage. It requires Analyze.
+	Buffers bool
+
+	// Verbose includes output columns and schema qualified names.
+	Verbose bool
+}
+
+// QueryPlan is the parsed output of EXPLAIN (FORMAT JSON).
+type QueryPlan struct {
+	Plan          *PlanNode `json:"Plan"`
+	PlanningTime  float64   `json:"Planning Time"`
+	ExecutionTime float64   `json:"Execution Time"`
+}
+
+// PlanNode is a node of a query plan. Actual* fields are only set when the
+// plan was obtained with ExplainOptions.Analyze.
+type PlanNode struct {
+	NodeType     string   `json:"Node Type"`
+	RelationName string   `json:"Relation Name"`
+	Schema       string   `json:"Schema"`
+	Alias        string   `json:"Alias"`
+	IndexName    string   `json:"Index Name"`
+	JoinType     string   `json:"Join Type"`
+	StartupCost  float64  `json:"Startup Cost"`
+	TotalCost    float64  `json:"Total Cost"`
+	PlanRows     float64  `json:"Plan Rows"`
+	PlanWidth    int      `json:"Plan Width"`
+	Filter       string   `json:"Filter"`
+	IndexCond    string   `json:"Index Cond"`
+	HashCond     string   `json:"Hash Cond"`
+	SortKey      []string `json:"Sort Key"`
+
+	ActualStartupTime float64 `json:"Actual Startup Time"`
+	ActualTotalTime   float64 `json:"Actual Total Time"`
+	ActualRows        float64 `json:"Actual Rows"`
+	ActualLoops       float64 `json:"Actual Loops"`
+	RowsRemoved       float64 `json:"Rows Removed by Filter"`
+
+	SharedHitBlocks  int64 `json:"Shared Hit Blocks"`
+	SharedReadBlocks int64 `json:"Shared Read Blocks"`
+
+	Plans []*PlanNode `json:"Plans"`
+}
+
+// Walk calls fn for n and all nodes below it, depth first.
+func (n *PlanNode) Walk(fn func(*PlanNode)) {
+	if n == nil {
+		return
+	}
+	fn(n)
+	for _, c := range n.Plans {
+		c.Walk(fn)
+	}
+}
+
+// Nodes returns the nodes of the plan of type nodeType, e.g. "Seq Scan".
+func (p *QueryPlan) Nodes(nodeType string) []*PlanNode {
+	var nodes []*PlanNode
+	p.Plan.Walk(func(n *PlanNode) {
+		if n.NodeType == nodeType {
+			nodes = append(nodes, n)
+		}
+	})
+	return nodes
+}
+
+// SeqScans returns the names of the tables read with a sequential scan.
+func (p *QueryPlan) SeqScans() []string {
+	var tables []string
+	for _, n := range p.Nodes("Seq Scan") {
+		tables = append(tables, n.RelationName)
+	}
+	return tables
+}
+
+// TotalCost returns the estimated total cost of the plan.
+func (p *QueryPlan) TotalCost() float64 {
+	if p.Plan == nil {
+		return 0
+	}
+	return p.Plan.TotalCost
+}
+
+// Explain runs EXPLAIN (FORMAT JSON) for query and returns the parsed plan.
+func (s Postgres) Explain(query Expr, opts ExplainOptions) (*QueryPlan, error) {
+	if opts.Buffers && !opts.Analyze {
+		return nil, errors.New("explain buffers requires analyze")
+	}
+	options := []string{"FORMAT JSON"}
+	if opts.Analyze {
+		options = append(options, "ANALYZE")
+	}
+	if opts.Buffers {
+		options = append(options, "BUFFERS")
+	}
+	if opts.Verbose {
+		options = append(options, "VERBOSE")
+	}
+	q, args := s.Build(query)
+	var out []byte
+	err := s.DB.QueryRow("EXPLAIN ("+strings.Join(options, ", ")+") "+q, args...).Scan(&out)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlan(out)
+}
+
+func parsePlan(data []byte) (*QueryPlan, error) {
+	var plans []QueryPlan
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, errors.New("explain returned no plan")
+	}
+	return &plans[0], nil
+}
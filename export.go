@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// CopyOptions control the output of CopyTo.
+type CopyOptions struct {
+	// Header writes the column names as the first record.
+	Header bool
+
+	// Delimiter separates fields. Defaults to a comma.
+	Delimiter rune
+
+	// Null is the string written for NULL values. Defaults to the empty
+	// string, as in COPY ... CSV.
+	Null string
+
+	// Binary requests the binary COPY format.
+	Binary bool
+}
+
+// ErrBinaryCopy is returned when the binary COPY format is requested without
+// a pgx backed connection, or for a query with parameters.
+var ErrBinaryCopy = errors.New("binary COPY TO requires the pgx driver and a query without parameters")
+
+// CopyTo writes the result of query to w in the CSV format of
+// COPY (query) TO STDOUT WITH CSV, one row at a time so the result never has
+// to fit in memory. It returns the number of rows written.
+//
+// On connections using the pgx driver a query without parameters is run as
+// an actual COPY, which also supports the binary format. lib/pq does not
+// implement the COPY TO protocol, so otherwise the rows are read with a
+// regular query and encoded client side. Values are rendered the way COPY
+// renders them: bytea as \x hex, booleans as t/f and timestamps in ISO form.
+func (s Postgres) CopyTo(w io.Writer, opts CopyOptions, query string, args ...interface{}) (int64, error) {
+	if len(args) == 0 {
+		var n int64
+		ok, err := s.withPgx(context.Background(), func(conn *pgx.Conn) error {
+			tag, err := conn.PgConn().CopyTo(context.Background(), w, copyToSQL(query, opts))
+			n = tag.RowsAffected()
+			return err
+		})
+		if ok || err != nil {
+			return n, err
+		}
+	}
+	if opts.Binary {
+		return 0, ErrBinaryCopy
+	}
+	delim := opts.Delimiter
+	if delim == 0 {
+		delim = ','
+	}
+	cw := copyCSV{w: bufio.NewWriter(w), delim: delim, null: opts.Null}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	// Drivers return the text form of many types, such as numeric, uuid or
+	// jsonb, as []byte: only bytea values are binary.
+	bytea := make([]bool, len(cols))
+	for i, t := range types {
+		bytea[i] = t.DatabaseTypeName() == "BYTEA"
+	}
+	if opts.Header {
+		if err := cw.write(cols, nil); err != nil {
+			return 0, err
+		}
+	}
+	values := make([]interface{}, len(cols))
+	targets := make([]interface{}, len(cols))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+	record := make([]string, len(cols))
+	nulls := make([]bool, len(cols))
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return n, err
+		}
+		for i, v := range values {
+			record[i], nulls[i] = copyText(v, bytea[i]), v == nil
+		}
+		if err := cw.write(record, nulls); err != nil {
+			return n, err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, cw.w.Flush()
+}
+
+// copyCSV writes records the way COPY ... CSV does. Values are quoted when
+// they contain the delimiter, a quote or a line break, or when they would
+// read as NULL, so that empty strings and NULLs stay apart.
+type copyCSV struct {
+	w     *bufio.Writer
+	delim rune
+	null  string
+}
+
+// write writes record, whose fields are NULL where nulls is set.
+func (c copyCSV) write(record []string, nulls []bool) error {
+	special := string(c.delim) + "\"\r\n"
+	for i, field := range record {
+		if i > 0 {
+			c.w.WriteRune(c.delim)
+		}
+		switch {
+		case nulls != nil && nulls[i]:
+			c.w.WriteString(c.null)
+		case field == c.null || field == `\.` || strings.ContainsAny(field, special):
+			c.w.WriteString(`"` + strings.Replace(field, `"`, `""`, -1) + `"`)
+		default:
+			c.w.WriteString(field)
+		}
+	}
+	// Errors of bufio.Writer are sticky: the last write reports them.
+	_, err := c.w.WriteString("\n")
+	return err
+}
+
+func copyToSQL(query string, opts CopyOptions) string {
+	if opts.Binary {
+		return fmt.Sprintf("COPY (%v) TO STDOUT WITH (FORMAT binary)", query)
+	}
+	options := "FORMAT csv"
+	if opts.Header {
+		options += ", HEADER true"
+	}
+	if opts.Delimiter != 0 {
+		options += ", DELIMITER " + quoteLiteral(string(opts.Delimiter))
+	}
+	if opts.Null != "" {
+		options += ", NULL " + quoteLiteral(opts.Null)
+	}
+	return fmt.Sprintf("COPY (%v) TO STDOUT WITH (%v)", query, options)
+}
+
+// CopyToChan streams the rows of query on the returned channel, which is
+// closed once the result is exhausted or ctx is done. The first error
+// encountered, if any, is sent on the error channel after the rows channel
+// is closed. Consumers stopping early must cancel ctx, which cancels the
+// query, as WithContext does, and releases its connection.
+func (s Postgres) CopyToChan(ctx context.Context, query string, args ...interface{}) (<-chan []interface{}, <-chan error) {
+	out := make(chan []interface{})
+	errc := make(chan error, 1)
+	go func() {
+		err := s.streamRows(ctx, out, query, args...)
+		close(out)
+		errc <- err
+		close(errc)
+	}()
+	return out, errc
+}
+
+func (s Postgres) streamRows(ctx context.Context, out chan<- []interface{}, query string, args ...interface{}) error {
+	rows, err := s.WithContext(ctx).DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		targets := make([]interface{}, len(cols))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+		select {
+		case out <- values:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rows.Err()
+}
+
+// copyText renders v the way COPY renders column values in text form. bytea
+// tells whether v is the value of a bytea column.
+func copyText(v interface{}, bytea bool) string {
+	switch x := v.(type) {
+	case []byte:
+		if !bytea {
+			return string(x)
+		}
+		return `\x` + hex.EncodeToString(x)
+	case string:
+		return x
+	case bool:
+		if x {
+			return "t"
+		}
+		return "f"
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case time.Time:
+		return x.Format("2006-01-02 15:04:05.999999Z07:00")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"strings"
+)
+
+// Expr is a SQL fragment using ? placeholders together with the values bound
+// to them, in the form accepted by the query builder's Where, Select, Joins
+// and Order methods:
+//
+//	e := s.ILike("name", "%bob%")
+//	db.Where(e.SQL, e.Args...)
+//
+// A literal question mark is written as ??.
+type Expr struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Raw returns an Expr for sql and args.
+func Raw(sql string, args ...interface{}) Expr {
+	return Expr{SQL: sql, Args: args}
+}
+
+// Join concatenates exprs separated by sep.
+func Join(sep string, exprs ...Expr) Expr {
+	var e Expr
+	parts := make([]string, 0, len(exprs))
+	for _, x := range exprs {
+		if x.SQL == "" {
+			continue
+		}
+		parts = append(parts, x.SQL)
+		e.Args = append(e.Args, x.Args...)
+	}
+	e.SQL = strings.Join(parts, sep)
+	return e
+}
+
+// And joins exprs with AND, wrapping each in parentheses.
+func And(exprs ...Expr) Expr {
+	return Join(" AND ", wrapAll(exprs)...)
+}
+
+// Or joins exprs with OR, wrapping each in parentheses.
+func Or(exprs ...Expr) Expr {
+	e := Join(" OR ", wrapAll(exprs)...)
+	if e.SQL != "" {
+		e.SQL = "(" + e.SQL + ")"
+	}
+	return e
+}
+
+func wrapAll(exprs []Expr) []Expr {
+	out := make([]Expr, 0, len(exprs))
+	for _, x := range exprs {
+		if x.SQL != "" {
+			out = append(out, Expr{SQL: "(" + x.SQL + ")", Args: x.Args})
+		}
+	}
+	return out
+}
+
+// Build replaces the ? placeholders of e with numbered bind variables. It
+// returns the final statement and its arguments, ready to be executed.
+// Question marks inside quoted strings and identifiers are left alone.
+func (s Postgres) Build(e Expr) (string, []interface{}) {
+	return s.rebind(e.SQL, 0), e.Args
+}
+
+// rebind replaces the placeholders of query with bind variables numbered
+// from offset+1.
+func (s Postgres) rebind(query string, offset int) string {
+	var buf strings.Builder
+	buf.Grow(len(query) + 8)
+	n := offset
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			if i+1 < len(query) && query[i+1] == '?' {
+				i++
+				break
+			}
+			n++
+			buf.WriteString(s.BindVar(n))
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// placeholders returns n comma separated ? placeholders.
+func placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
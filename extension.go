@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// HasExtension reports whether the extension name is installed in the
+// current database.
+func (s Postgres) HasExtension(name string) bool {
+	var count int
+	s.DB.QueryRow("SELECT count(*) FROM pg_extension WHERE extname = $1", name).Scan(&count)
+	return count > 0
+}
+
+// EnsureExtension installs the extension name unless it already is.
+func (s Postgres) EnsureExtension(name string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %v", s.Quote(name)))
+	return err
+}
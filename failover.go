@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// FailoverMonitor checks periodically that a pool is still connected to the
+// primary. New connections made by a Connector with TargetSessionAttrs set to
+// TargetReadWrite go to the new primary after a failover, but idle pooled
+// connections would keep pointing at the old one, now gone or demoted; the
+// monitor closes them when the check fails.
+type FailoverMonitor struct {
+	DB *sql.DB
+
+	// Interval between checks. Defaults to 10 seconds.
+	Interval time.Duration
+
+	// Pool is restored after idle connections were closed. Only the fields
+	// that are set are applied, except MaxIdleConns, which defaults to that
+	// of DefaultPool.
+	Pool PoolConfig
+
+	// OnFailover is called with the error of the failed check.
+	OnFailover func(err error)
+}
+
+// Check returns an error when the pool is not connected to a primary.
+func (m *FailoverMonitor) Check(ctx context.Context) error {
+	var recovery bool
+	err := m.DB.QueryRowContext(ctx, "SELECT pg_is_in_recovery()").Scan(&recovery)
+	if err == nil && recovery {
+		err = ErrReadOnlyServer
+	}
+	return err
+}
+
+// Run checks the pool until ctx is done. Connections in use while a
+// failover is detected are recycled once they reach Pool.ConnMaxLifetime.
+func (m *FailoverMonitor) Run(ctx context.Context) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := m.Check(ctx); err != nil && ctx.Err() == nil {
+			m.DB.SetMaxIdleConns(0)
+			pool := m.Pool
+			if pool.MaxIdleConns == 0 {
+				pool.MaxIdleConns = DefaultPool.MaxIdleConns
+			}
+			pool.applySet(m.DB)
+			if m.OnFailover != nil {
+				m.OnFailover(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// ErrFake is returned by the queries run on a Fake, which has no server to
+// run them.
+var ErrFake = errors.New("fake postgres dialect can not run queries")
+
+// Fake is a postgres dialect for unit tests that keeps the schema in memory
+// instead of talking to a server. It generates the same SQL as Postgres,
+// while HasTable, HasColumn, HasIndex and HasForeignKey answer from a
+// registry fed by the DDL executed through its connection, such as the
+// statements of automigrate, and by AddTable. Queries fail with ErrFake.
+type Fake struct {
+	Postgres
+
+	// Database is returned by CurrentDatabase. Defaults to "fake".
+	Database string
+
+	mu         sync.Mutex
+	tables     map[string]*fakeTable
+	statements []string
+}
+
+type fakeTable struct {
+	columns     []string
+	indexes     map[string]bool
+	foreignKeys map[string]bool
+}
+
+// NewFake returns a Fake with an empty schema. Generated SQL targets the
+// latest server unless Target is set.
+func NewFake() *Fake {
+	f := &Fake{tables: make(map[string]*fakeTable)}
+	f.Postgres.DB = fakeDB{f}
+	return f
+}
+
+// SetDB implements dialects.Dialect. The connection is ignored: the fake
+// keeps executing statements against its registry.
+func (f *Fake) SetDB(db model.SQLCommon) {}
+
+// AddTable registers tableName with columns, as if it had been created.
+func (f *Fake) AddTable(tableName string, columns ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := f.table(tableName)
+	for _, c := range columns {
+		if !containsString(t.columns, c) {
+			t.columns = append(t.columns, c)
+		}
+	}
+}
+
+// AddIndex registers indexName on tableName.
+func (f *Fake) AddIndex(tableName, indexName string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.table(tableName).indexes[indexName] = true
+}
+
+// Tables returns the names of the registered tables, sorted.
+func (f *Fake) Tables() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.tables))
+	for name := range f.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Columns returns the columns of tableName in the order they were added.
+func (f *Fake) Columns(tableName string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if t, ok := f.tables[tableName]; ok {
+		return append([]string(nil), t.columns...)
+	}
+	return nil
+}
+
+// Statements returns the statements executed so far.
+func (f *Fake) Statements() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.statements...)
+}
+
+// HasTable implements dialects.Dialect.
+func (f *Fake) HasTable(tableName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.tables[tableName]
+	return ok
+}
+
+// HasColumn implements dialects.Dialect.
+func (f *Fake) HasColumn(tableName string, columnName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tables[tableName]
+	return ok && containsString(t.columns, columnName)
+}
+
+// HasIndex implements dialects.Dialect.
+func (f *Fake) HasIndex(tableName string, indexName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tables[tableName]
+	return ok && t.indexes[indexName]
+}
+
+// HasForeignKey implements dialects.Dialect.
+func (f *Fake) HasForeignKey(tableName string, foreignKeyName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tables[tableName]
+	return ok && t.foreignKeys[foreignKeyName]
+}
+
+// CurrentDatabase implements dialects.Dialect.
+func (f *Fake) CurrentDatabase() string {
+	if f.Database == "" {
+		return "fake"
+	}
+	return f.Database
+}
+
+func (f *Fake) table(name string) *fakeTable {
+	t, ok := f.tables[name]
+	if !ok {
+		t = &fakeTable{indexes: make(map[string]bool), foreignKeys: make(map[string]bool)}
+		f.tables[name] = t
+	}
+	return t
+}
+
+const fakeIdent = `("(?:[^"]|"")+"|[\w.]+)`
+
+var (
+	fakeCreateTable = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMP\w*\s+|UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + fakeIdent + `\s*\((.*)\)`)
+	fakeDropTable   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?` + fakeIdent)
+	fakeAddColumn   = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:ONLY\s+)?` + fakeIdent + `\s+ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + fakeIdent)
+	fakeDropColumn  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:ONLY\s+)?` + fakeIdent + `\s+DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?` + fakeIdent)
+	fakeForeignKey  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:ONLY\s+)?` + fakeIdent + `\s+ADD\s+CONSTRAINT\s+` + fakeIdent + `\s+FOREIGN\s+KEY`)
+	fakeCreateIndex = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?` + fakeIdent + `\s+ON\s+(?:ONLY\s+)?` + fakeIdent)
+	fakeDropIndex   = regexp.MustCompile(`(?is)^DROP\s+INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?` + fakeIdent)
+)
+
+// exec applies the DDL statement query to the registry. Statements it does
+// not understand are only recorded.
+func (f *Fake) exec(query string) {
+	query = strings.TrimSpace(query)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, query)
+	switch {
+	case fakeCreateTable.MatchString(query):
+		m := fakeCreateTable.FindStringSubmatch(query)
+		t := f.table(unquoteIdent(m[1]))
+		for _, def := range splitTopLevel(m[2]) {
+			name := strings.Fields(def)
+			if len(name) == 0 {
+				continue
+			}
+			switch strings.ToUpper(name[0]) {
+			case "PRIMARY", "CONSTRAINT", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE", "LIKE":
+				continue
+			}
+			if col := unquoteIdent(name[0]); !containsString(t.columns, col) {
+				t.columns = append(t.columns, col)
+			}
+		}
+	case fakeDropTable.MatchString(query):
+		delete(f.tables, unquoteIdent(fakeDropTable.FindStringSubmatch(query)[1]))
+	case fakeForeignKey.MatchString(query):
+		m := fakeForeignKey.FindStringSubmatch(query)
+		f.table(unquoteIdent(m[1])).foreignKeys[unquoteIdent(m[2])] = true
+	case fakeAddColumn.MatchString(query):
+		m := fakeAddColumn.FindStringSubmatch(query)
+		t := f.table(unquoteIdent(m[1]))
+		if col := unquoteIdent(m[2]); !strings.EqualFold(col, "CONSTRAINT") && !containsString(t.columns, col) {
+			t.columns = append(t.columns, col)
+		}
+	case fakeDropColumn.MatchString(query):
+		m := fakeDropColumn.FindStringSubmatch(query)
+		if t, ok := f.tables[unquoteIdent(m[1])]; ok {
+			col := unquoteIdent(m[2])
+			for i, c := range t.columns {
+				if c == col {
+					t.columns = append(t.columns[:i], t.columns[i+1:]...)
+					break
+				}
+			}
+		}
+	case fakeCreateIndex.MatchString(query):
+		m := fakeCreateIndex.FindStringSubmatch(query)
+		f.table(unquoteIdent(m[2])).indexes[unquoteIdent(m[1])] = true
+	case fakeDropIndex.MatchString(query):
+		name := unquoteIdent(fakeDropIndex.FindStringSubmatch(query)[1])
+		for _, t := range f.tables {
+			delete(t.indexes, name)
+		}
+	}
+}
+
+// unquoteIdent returns the name of a possibly quoted identifier, without its
+// schema.
+func unquoteIdent(ident string) string {
+	if strings.HasPrefix(ident, `"`) {
+		return strings.Replace(ident[1:len(ident)-1], `""`, `"`, -1)
+	}
+	if i := strings.LastIndex(ident, "."); i >= 0 {
+		ident = ident[i+1:]
+	}
+	return ident
+}
+
+// splitTopLevel splits a column definition list on the commas that are not
+// nested in parentheses or quotes.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// fakeDB is the connection of a Fake.
+type fakeDB struct {
+	f *Fake
+}
+
+func (db fakeDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	db.f.exec(query)
+	return driver.RowsAffected(0), nil
+}
+
+func (db fakeDB) Prepare(query string) (*sql.Stmt, error) {
+	return nil, ErrFake
+}
+
+func (db fakeDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, ErrFake
+}
+
+// QueryRow returns a row whose Scan fails with ErrFake.
+func (db fakeDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return failingDB.QueryRow(query, args...)
+}
+
+// failingDB builds *sql.Row values carrying ErrFake.
+var failingDB = &failingPool{err: ErrFake}
+
+// failingPool is a pool whose connections can not be established, used to
+// build *sql.Row values carrying err. It is opened on first use.
+type failingPool struct {
+	err  error
+	once sync.Once
+	db   *sql.DB
+}
+
+// QueryRow returns a row whose Scan fails with p.err.
+func (p *failingPool) QueryRow(query string, args ...interface{}) *sql.Row {
+	p.once.Do(func() {
+		p.db = sql.OpenDB(failingConnector{p.err})
+	})
+	return p.db.QueryRow(query, args...)
+}
+
+// failingConnector fails to connect with err.
+type failingConnector struct {
+	err error
+}
+
+func (c failingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c failingConnector) Driver() driver.Driver {
+	return failingDriver{c.err}
+}
+
+type failingDriver struct {
+	err error
+}
+
+func (d failingDriver) Open(name string) (driver.Conn, error) {
+	return nil, d.err
+}
@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// FDWExtension is the foreign data wrapper for other PostgreSQL databases.
+const FDWExtension = "postgres_fdw"
+
+// ForeignServer describes a postgres_fdw server, a remote database whose
+// tables can be declared as foreign tables and joined with local ones.
+type ForeignServer struct {
+	Name string
+
+	// Host, Port and DBName locate the remote database.
+	Host   string
+	Port   int
+	DBName string
+
+	// Options are further server options, e.g. "fetch_size" or
+	// "use_remote_estimate".
+	Options map[string]string
+}
+
+// UserMapping maps a local role to the credentials used on a foreign
+// server.
+type UserMapping struct {
+	// Role is the local role, CURRENT_USER or PUBLIC. Defaults to
+	// CURRENT_USER.
+	Role string
+
+	User     string
+	Password string
+}
+
+// ForeignTable declares a local table backed by a table of a foreign server.
+type ForeignTable struct {
+	Name   string
+	Server string
+
+	// Columns are the column definitions, e.g. "id bigint NOT NULL".
+	Columns []string
+
+	// RemoteSchema and RemoteTable name the remote table. They default to
+	// public and Name.
+	RemoteSchema string
+	RemoteTable  string
+}
+
+// ForeignTableModel is implemented by models backed by a foreign table, so
+// migrations declare them with CreateForeignTableModel, or through
+// Table.ForeignServer, instead of creating a local table.
+type ForeignTableModel interface {
+	ForeignServer() string
+}
+
+// fdwOptions renders options as an OPTIONS clause, sorted by name.
+func fdwOptions(options map[string]string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + " " + quoteLiteral(options[name])
+	}
+	return " OPTIONS (" + strings.Join(pairs, ", ") + ")"
+}
+
+// CreateServerSQL returns the statement creating the foreign server srv.
+func (s Postgres) CreateServerSQL(srv ForeignServer) (string, error) {
+	if srv.Name == "" {
+		return "", errors.New("foreign server name is required")
+	}
+	options := make(map[string]string, len(srv.Options)+3)
+	for k, v := range srv.Options {
+		options[k] = v
+	}
+	if srv.Host != "" {
+		options["host"] = srv.Host
+	}
+	if srv.Port != 0 {
+		options["port"] = fmt.Sprint(srv.Port)
+	}
+	if srv.DBName != "" {
+		options["dbname"] = srv.DBName
+	}
+	return fmt.Sprintf("CREATE SERVER IF NOT EXISTS %v FOREIGN DATA WRAPPER %v%v",
+		s.Quote(srv.Name), FDWExtension, fdwOptions(options)), nil
+}
+
+// CreateUserMappingSQL returns the statement mapping m.Role to the remote
+// credentials of m on server.
+func (s Postgres) CreateUserMappingSQL(server string, m UserMapping) string {
+	role := m.Role
+	if role == "" {
+		role = "CURRENT_USER"
+	}
+	options := map[string]string{"user": m.User}
+	if m.Password != "" {
+		options["password"] = m.Password
+	}
+	return fmt.Sprintf("CREATE USER MAPPING IF NOT EXISTS FOR %v SERVER %v%v",
+		s.quoteRole(role), s.Quote(server), fdwOptions(options))
+}
+
+// CreateForeignTableSQL returns the statement declaring t.
+func (s Postgres) CreateForeignTableSQL(t ForeignTable) (string, error) {
+	if t.Name == "" || t.Server == "" {
+		return "", errors.New("foreign table name and server are required")
+	}
+	if len(t.Columns) == 0 {
+		return "", fmt.Errorf("foreign table %s has no columns", t.Name)
+	}
+	schema, table := t.RemoteSchema, t.RemoteTable
+	if schema == "" {
+		schema = "public"
+	}
+	if table == "" {
+		table = t.Name
+	}
+	return fmt.Sprintf("CREATE FOREIGN TABLE IF NOT EXISTS %v (%v) SERVER %v%v",
+		s.Quote(t.Name), strings.Join(t.Columns, ", "), s.Quote(t.Server),
+		fdwOptions(map[string]string{"schema_name": schema, "table_name": table})), nil
+}
+
+// CreateForeignServer installs postgres_fdw if needed, then creates srv
+// and the user mappings of its users, in a single transaction.
+func (s Postgres) CreateForeignServer(srv ForeignServer, users ...UserMapping) error {
+	create, err := s.CreateServerSQL(srv)
+	if err != nil {
+		return err
+	}
+	if err := s.EnsureExtension(FDWExtension); err != nil {
+		return err
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		if _, err := db.Exec(create); err != nil {
+			return err
+		}
+		for _, m := range users {
+			if _, err := db.Exec(s.CreateUserMappingSQL(srv.Name, m)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DropForeignServer drops the foreign server name along with its user
+// mappings and foreign tables.
+func (s Postgres) DropForeignServer(name string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("DROP SERVER IF EXISTS %v CASCADE", s.Quote(name)))
+	return err
+}
+
+// CreateForeignTable declares t.
+func (s Postgres) CreateForeignTable(t ForeignTable) error {
+	query, err := s.CreateForeignTableSQL(t)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
+
+// CreateForeignTableModel declares the model table t as a foreign table on
+// the server named by value, which must implement ForeignTableModel. The
+// remote table has the same name and columns.
+func (s Postgres) CreateForeignTableModel(t Table, value interface{}) error {
+	m, ok := value.(ForeignTableModel)
+	if !ok || m.ForeignServer() == "" {
+		return errors.New("model does not declare a foreign server")
+	}
+	ft, err := s.foreignTableOf(t, m.ForeignServer())
+	if err != nil {
+		return err
+	}
+	return s.CreateForeignTable(ft)
+}
+
+// foreignTableOf returns the foreign table on server with the columns of
+// the model table t.
+func (s Postgres) foreignTableOf(t Table, server string) (ForeignTable, error) {
+	ft := ForeignTable{Name: t.Name, Server: server}
+	for _, field := range columnFields(t.Fields) {
+		sqlType, additionalType, err := s.sqlTypeOf(field)
+		if err != nil {
+			return ForeignTable{}, err
+		}
+		// Serial types imply a local sequence, which foreign tables
+		// do not have.
+		def := fmt.Sprintf("%v %v", s.Quote(field.DBName), alterableType(sqlType))
+		if !declaredNullable(field, additionalType) {
+			def += " NOT NULL"
+		}
+		ft.Columns = append(ft.Columns, def)
+	}
+	return ft, nil
+}
+
+// ForeignTableExists reports whether a foreign table named tableName exists
+// in the current schema.
+func (s Postgres) ForeignTableExists(tableName string) (bool, error) {
+	query := `
+SELECT Count(*)
+FROM   information_schema.tables
+WHERE  table_schema = current_schema()
+       AND table_name = $1
+       AND table_type = 'FOREIGN'
+	`
+	return s.exists(query, tableName)
+}
+
+// ImportForeignSchema declares local foreign tables in localSchema for the
+// tables of remoteSchema on server, or only for tables when given.
+func (s Postgres) ImportForeignSchema(server, remoteSchema, localSchema string, tables ...string) error {
+	var limit string
+	if len(tables) > 0 {
+		limit = fmt.Sprintf(" LIMIT TO (%v)", s.quoteColumns(tables))
+	}
+	_, err := s.DB.Exec(fmt.Sprintf("IMPORT FOREIGN SCHEMA %v%v FROM SERVER %v INTO %v",
+		s.Quote(remoteSchema), limit, s.Quote(server), s.Quote(localSchema)))
+	return err
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// Filtered returns the aggregate call restricted to the rows matching where,
+// named alias: call FILTER (WHERE where) AS alias. Several filtered
+// aggregates compute conditional totals in a single pass over the table:
+//
+//	paid := s.Filtered(postgres.Raw("count(*)"), postgres.Raw("status = ?", "paid"), "paid")
+//	open := s.Filtered(postgres.Raw("sum(total)"), postgres.Raw("status = ?", "open"), "open_total")
+//	sel := postgres.Join(", ", paid, open)
+//	db.Select(sel.SQL, sel.Args...)
+func (s Postgres) Filtered(call Expr, where Expr, alias string) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("%v FILTER (WHERE %v) AS %v", call.SQL, where.SQL, s.Quote(alias)),
+		Args: append(append([]interface{}{}, call.Args...), where.Args...),
+	}
+}
+
+// CountIf returns count(*) FILTER (WHERE where) AS alias.
+func (s Postgres) CountIf(where Expr, alias string) Expr {
+	return s.Filtered(Expr{SQL: "count(*)"}, where, alias)
+}
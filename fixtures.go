@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// LoadFixtures replaces the content of tables with fixture files, in a
+// single transaction: every target table is truncated, the files are loaded
+// with COPY in the order given, and the sequences of serial columns are moved
+// past the loaded values. Each file fills the table named after it, e.g.
+// testdata/users.csv fills users; .csv files are read as by SeedCSV and
+// .json files as by SeedJSON. Tables referenced by foreign keys must come
+// before the tables referencing them.
+//
+// The tables are truncated with CASCADE: tables referencing them through
+// foreign keys are emptied as well, even those without a fixture file, so
+// fixtures should cover every table a test relies on.
+func (s Postgres) LoadFixtures(files ...string) error {
+	seeds := make([]Seed, len(files))
+	for i, name := range files {
+		seed, err := readFixture(name)
+		if err != nil {
+			return err
+		}
+		seeds[i] = seed
+	}
+	return s.LoadFixtureSets(seeds...)
+}
+
+// LoadFixtureSets is like LoadFixtures for fixtures already in memory. The
+// names of the seed sets are only used in errors.
+func (s Postgres) LoadFixtureSets(fixtures ...Seed) error {
+	if len(fixtures) == 0 {
+		return nil
+	}
+	var tables []string
+	seen := make(map[string]bool)
+	for _, f := range fixtures {
+		if !seen[f.Table] {
+			seen[f.Table] = true
+			tables = append(tables, f.Table)
+		}
+	}
+	truncate, err := s.TruncateSQL(TruncateOptions{RestartIdentity: true, Cascade: true}, tables...)
+	if err != nil {
+		return err
+	}
+	return s.withCopyTx(func(db model.SQLCommon) error {
+		if _, err := db.Exec(truncate); err != nil {
+			return err
+		}
+		for _, f := range fixtures {
+			i := 0
+			_, err := copyInto(db, f.Table, f.Columns, func() ([]interface{}, bool) {
+				if i == len(f.Rows) {
+					return nil, false
+				}
+				i++
+				return f.Rows[i-1], true
+			})
+			if err != nil {
+				return fmt.Errorf("fixture %s: %v", f.Name, err)
+			}
+		}
+		for _, table := range tables {
+			if err := s.resetSequences(db, table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func readFixture(name string) (Seed, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Seed{}, err
+	}
+	defer f.Close()
+	ext := filepath.Ext(name)
+	table := strings.TrimSuffix(filepath.Base(name), ext)
+	switch strings.ToLower(ext) {
+	case ".csv":
+		return SeedCSV(name, table, f)
+	case ".json":
+		return SeedJSON(name, table, f)
+	}
+	return Seed{}, fmt.Errorf("fixture %s: unknown format %s", name, ext)
+}
+
+// resetSequences sets the sequences owned by the columns of tableName to the
+// largest value of their column, so rows inserted after explicit values were
+// loaded do not collide with them.
+func (s Postgres) resetSequences(db model.SQLCommon, tableName string) error {
+	rows, err := db.Query(`
+SELECT a.attname, pg_get_serial_sequence($1, a.attname)
+FROM   pg_attribute a
+WHERE  a.attrelid = $1::regclass
+       AND a.attnum > 0
+       AND NOT a.attisdropped
+       AND pg_get_serial_sequence($1, a.attname) IS NOT NULL`, s.Quote(tableName))
+	if err != nil {
+		return err
+	}
+	type serial struct{ column, sequence string }
+	var serials []serial
+	for rows.Next() {
+		var x serial
+		if err := rows.Scan(&x.column, &x.sequence); err != nil {
+			rows.Close()
+			return err
+		}
+		serials = append(serials, x)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, x := range serials {
+		query := fmt.Sprintf("SELECT setval($1, coalesce(max(%v), 0) + 1, false) FROM %v",
+			s.Quote(x.column), s.Quote(tableName))
+		if _, err := db.Exec(query, x.sequence); err != nil {
+			return err
+		}
+	}
+	return nil
+}
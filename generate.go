@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GenerateModels writes a Go source file declaring package pkg with one struct
+// per table. Column types, primary keys, indexes and not null constraints are
+// carried over as struct tags; foreign keys are recorded as comments on the
+// referencing field.
+func GenerateModels(w io.Writer, pkg string, tables []*TableInfo) error {
+	var buf bytes.Buffer
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+	for _, t := range tables {
+		writeModel(&body, t, imports)
+	}
+	fmt.Fprintf(&buf, "// Code generated from the %s database schema. DO NOT EDIT.\n\n", tablesSchema(tables))
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if imports["time"] {
+		buf.WriteString("import \"time\"\n\n")
+	}
+	buf.Write(body.Bytes())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// GenerateModels inspects every table in the schema of the connection and
+// writes the matching Go models to w.
+func (s Postgres) GenerateModels(w io.Writer, pkg string) error {
+	tables, err := s.Inspector().All()
+	if err != nil {
+		return err
+	}
+	return GenerateModels(w, pkg, tables)
+}
+
+func tablesSchema(tables []*TableInfo) string {
+	if len(tables) > 0 {
+		return tables[0].Schema
+	}
+	return "public"
+}
+
+func writeModel(w io.Writer, t *TableInfo, imports map[string]bool) {
+	name := goName(t.Name)
+	primary := make(map[string]bool)
+	foreign := make(map[string]ConstraintInfo)
+	for _, c := range t.Constraints {
+		switch c.Type {
+		case PrimaryKeyConstraint:
+			for _, col := range c.Columns {
+				primary[col] = true
+			}
+		case ForeignKeyConstraint:
+			if len(c.Columns) == 1 {
+				foreign[c.Columns[0]] = c
+			}
+		}
+	}
+	indexes := make(map[string][]string)
+	for _, idx := range t.Indexes {
+		if idx.Primary || idx.Where != "" {
+			continue
+		}
+		setting := "index:" + idx.Name
+		if idx.Unique {
+			setting = "unique_index:" + idx.Name
+		}
+		for _, col := range idx.Columns {
+			indexes[col] = append(indexes[col], setting)
+		}
+	}
+
+	fmt.Fprintf(w, "// %s maps to the %s table.\n", name, t.Name)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, col := range t.Columns {
+		goType, natural := goTypeOf(col.DataType)
+		if goType == "time.Time" {
+			imports["time"] = true
+		}
+		if col.Nullable && !primary[col.Name] && goType != "[]byte" {
+			goType = "*" + goType
+		}
+		settings := []string{"column:" + col.Name}
+		if !natural {
+			settings = append(settings, "type:"+col.DataType)
+		}
+		if primary[col.Name] {
+			settings = append(settings, "primary_key")
+		} else if !col.Nullable {
+			settings = append(settings, "not null")
+		}
+		settings = append(settings, indexes[col.Name]...)
+		if fk, ok := foreign[col.Name]; ok {
+			fmt.Fprintf(w, "// %s references %s(%s)\n", fk.Name, fk.RefTable,
+				strings.Join(fk.RefColumns, ", "))
+		}
+		fmt.Fprintf(w, "%s %s `gorm:\"%s\"`\n", goName(col.Name), goType,
+			strings.Join(settings, ";"))
+	}
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "// TableName returns the name of the table %s is stored in.\n", name)
+	fmt.Fprintf(w, "func (%s) TableName() string {\nreturn %q\n}\n\n", name, t.Name)
+}
+
+// goTypeOf returns the Go type for a column type, and whether DataTypeOf maps
+// that Go type back to the same column type without a type tag.
+func goTypeOf(dataType string) (string, bool) {
+	base := strings.TrimSpace(typeModifier.ReplaceAllString(dataType, ""))
+	switch base {
+	case "smallint":
+		return "int16", false
+	case "integer":
+		return "int", true
+	case "bigint":
+		return "int64", true
+	case "boolean":
+		return "bool", true
+	case "real":
+		return "float32", false
+	case "double precision":
+		return "float64", false
+	case "numeric":
+		return "float64", dataType == "numeric"
+	case "text":
+		return "string", true
+	case "character varying", "character":
+		return "string", false
+	case "timestamp with time zone":
+		return "time.Time", true
+	case "timestamp without time zone", "date":
+		return "time.Time", false
+	case "bytea":
+		return "[]byte", true
+	}
+	return "string", false
+}
+
+var commonInitialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true,
+	"SQL": true, "SSH": true, "TLS": true, "TTL": true, "UI": true,
+	"UID": true, "UUID": true, "URI": true, "URL": true, "UTF8": true,
+	"VM": true, "XML": true,
+}
+
+// goName converts a snake_case identifier into an exported Go name.
+func goName(name string) string {
+	var buf strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '-' || r == ' ' || r == '.'
+	}) {
+		if upper := strings.ToUpper(part); commonInitialisms[upper] {
+			buf.WriteString(upper)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(part)
+		buf.WriteRune(unicode.ToUpper(r))
+		buf.WriteString(part[size:])
+	}
+	out := buf.String()
+	if out == "" || (out[0] >= '0' && out[0] <= '9') {
+		out = "X" + out
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Object kinds privileges can be granted on.
+const (
+	OnTable              = "TABLE"
+	OnSequence           = "SEQUENCE"
+	OnSchema             = "SCHEMA"
+	OnAllTablesInSchema  = "ALL TABLES IN SCHEMA"
+	OnAllSequencesSchema = "ALL SEQUENCES IN SCHEMA"
+)
+
+var validPrivileges = map[string][]string{
+	OnTable:              {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER", "ALL"},
+	OnSequence:           {"USAGE", "SELECT", "UPDATE", "ALL"},
+	OnSchema:             {"CREATE", "USAGE", "ALL"},
+	OnAllTablesInSchema:  {"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER", "ALL"},
+	OnAllSequencesSchema: {"USAGE", "SELECT", "UPDATE", "ALL"},
+}
+
+// Grant describes privileges held by roles on a set of objects.
+type Grant struct {
+	// Privileges such as SELECT, INSERT or USAGE.
+	Privileges []string
+
+	// On is the kind of the objects, e.g. OnTable.
+	On string
+
+	// Objects are the names of the tables, sequences or schemas.
+	Objects []string
+
+	Roles []string
+
+	// WithGrantOption allows the roles to grant the privileges to others.
+	// It is ignored when revoking.
+	WithGrantOption bool
+}
+
+func (s Postgres) grantParts(g Grant) (privs, objects, roles string, err error) {
+	allowed, ok := validPrivileges[g.On]
+	if !ok {
+		return "", "", "", fmt.Errorf("can not grant privileges on %s", g.On)
+	}
+	if len(g.Privileges) == 0 || len(g.Objects) == 0 || len(g.Roles) == 0 {
+		return "", "", "", errors.New("grant needs privileges, objects and roles")
+	}
+	p := make([]string, len(g.Privileges))
+	for i, priv := range g.Privileges {
+		priv = strings.ToUpper(priv)
+		if !containsString(allowed, priv) {
+			return "", "", "", fmt.Errorf("invalid privilege %s on %s", priv, g.On)
+		}
+		p[i] = priv
+	}
+	o := make([]string, len(g.Objects))
+	for i, obj := range g.Objects {
+		o[i] = s.Quote(obj)
+	}
+	r := make([]string, len(g.Roles))
+	for i, role := range g.Roles {
+		r[i] = s.quoteRole(role)
+	}
+	return strings.Join(p, ", "), strings.Join(o, ", "), strings.Join(r, ", "), nil
+}
+
+// GrantSQL returns the GRANT statement for g.
+func (s Postgres) GrantSQL(g Grant) (string, error) {
+	privs, objects, roles, err := s.grantParts(g)
+	if err != nil {
+		return "", err
+	}
+	query := fmt.Sprintf("GRANT %v ON %v %v TO %v", privs, g.On, objects, roles)
+	if g.WithGrantOption {
+		query += " WITH GRANT OPTION"
+	}
+	return query, nil
+}
+
+// RevokeSQL returns the REVOKE statement undoing g.
+func (s Postgres) RevokeSQL(g Grant) (string, error) {
+	privs, objects, roles, err := s.grantParts(g)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("REVOKE %v ON %v %v FROM %v", privs, g.On, objects, roles), nil
+}
+
+// Grant gives the privileges described by g.
+func (s Postgres) Grant(g Grant) error {
+	query, err := s.GrantSQL(g)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
+
+// Revoke takes away the privileges described by g.
+func (s Postgres) Revoke(g Grant) error {
+	query, err := s.RevokeSQL(g)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
+
+// HasTablePrivilege reports whether role holds privilege on tableName.
+func (s Postgres) HasTablePrivilege(role, tableName, privilege string) bool {
+	ok, _ := s.TablePrivilegeExists(role, tableName, privilege)
+	return ok
+}
+
+// TablePrivilegeExists is like HasTablePrivilege but reports the errors of
+// the lookup, such as an unknown role or table.
+func (s Postgres) TablePrivilegeExists(role, tableName, privilege string) (bool, error) {
+	var ok bool
+	err := s.DB.QueryRow("SELECT has_table_privilege($1, $2, $3)",
+		role, tableName, privilege).Scan(&ok)
+	return ok, err
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rollup returns ROLLUP (columns), grouping by every prefix of columns: with
+// region and product it yields rows per product, subtotals per region and a
+// grand total. Use it as the GROUP BY clause:
+//
+//	db.Select("region, product, sum(amount)").Group(s.Rollup("region", "product"))
+func (s Postgres) Rollup(columns ...string) string {
+	return fmt.Sprintf("ROLLUP (%v)", s.quoteColumns(columns))
+}
+
+// Cube returns CUBE (columns), grouping by every subset of columns.
+func (s Postgres) Cube(columns ...string) string {
+	return fmt.Sprintf("CUBE (%v)", s.quoteColumns(columns))
+}
+
+// GroupingSets returns GROUPING SETS with one grouping per element of sets;
+// an empty set stands for the grand total.
+func (s Postgres) GroupingSets(sets ...[]string) string {
+	groups := make([]string, len(sets))
+	for i, set := range sets {
+		groups[i] = "(" + s.quoteColumns(set) + ")"
+	}
+	return fmt.Sprintf("GROUPING SETS (%v)", strings.Join(groups, ", "))
+}
+
+// Grouping returns GROUPING(columns) named alias, a bit mask telling which of
+// columns are aggregated away in a row: subtotal rows have NULL in those
+// columns, which GROUPING distinguishes from NULL data.
+func (s Postgres) Grouping(alias string, columns ...string) string {
+	return fmt.Sprintf("GROUPING(%v) AS %v", s.quoteColumns(columns), s.Quote(alias))
+}
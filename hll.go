@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// HLLExtension is the postgresql-hll extension, providing HyperLogLog
+// cardinality estimation.
+const HLLExtension = "hll"
+
+// approxCountDistinctSQL defines approx_count_distinct, a HyperLogLog
+// aggregate written in plain SQL and PL/pgSQL for servers without
+// postgresql-hll. It keeps 1024 registers fed by hashtext, for an error of
+// about 3%; being a 32 bit hash, it is not meant for billions of distinct
+// values.
+var approxCountDistinctSQL = []string{`
+CREATE OR REPLACE FUNCTION approx_count_distinct_add(registers smallint[], value anyelement)
+RETURNS smallint[] LANGUAGE plpgsql IMMUTABLE PARALLEL SAFE AS $$
+DECLARE
+	h int;
+	j int;
+	rho smallint;
+BEGIN
+	IF value IS NULL THEN
+		RETURN registers;
+	END IF;
+	IF registers IS NULL THEN
+		registers := array_fill(0::smallint, ARRAY[1024]);
+	END IF;
+	h := hashtext(value::text);
+	j := (h & 1023) + 1;
+	rho := 23 - length(ltrim((h >> 10)::bit(22)::text, '0'));
+	IF registers[j] < rho THEN
+		registers[j] := rho;
+	END IF;
+	RETURN registers;
+END
+$$`, `
+CREATE OR REPLACE FUNCTION approx_count_distinct_merge(a smallint[], b smallint[])
+RETURNS smallint[] LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+	SELECT CASE
+		WHEN a IS NULL THEN b
+		WHEN b IS NULL THEN a
+		ELSE (SELECT array_agg(greatest(x, y) ORDER BY i) FROM unnest(a, b) WITH ORDINALITY AS u(x, y, i))
+	END
+$$`, `
+CREATE OR REPLACE FUNCTION approx_count_distinct_final(registers smallint[])
+RETURNS bigint LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+	SELECT CASE
+		WHEN registers IS NULL THEN 0
+		WHEN e <= 2.5 * 1024 AND zeros > 0 THEN round(1024 * ln(1024.0 / zeros))::bigint
+		ELSE round(e)::bigint
+	END
+	FROM (SELECT 0.7213 / (1 + 1.079 / 1024) * 1024 * 1024 / sum(power(2.0, -r)) AS e,
+	             count(*) FILTER (WHERE r = 0) AS zeros
+	      FROM unnest(registers) AS r) AS t
+$$`, `
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'approx_count_distinct' AND pronamespace = current_schema()::regnamespace) THEN
+		CREATE AGGREGATE approx_count_distinct(anyelement) (
+			SFUNC = approx_count_distinct_add,
+			STYPE = smallint[],
+			COMBINEFUNC = approx_count_distinct_merge,
+			FINALFUNC = approx_count_distinct_final,
+			PARALLEL = SAFE
+		);
+	END IF;
+END
+$$`,
+}
+
+// EnsureApproxCountDistinct prepares the current database for
+// ApproxCountDistinct: it installs postgresql-hll when the server has it
+// available and reports true, and otherwise creates the approx_count_distinct
+// aggregate in the current schema.
+func (s Postgres) EnsureApproxCountDistinct() (hll bool, err error) {
+	var available bool
+	err = s.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = $1)", HLLExtension).Scan(&available)
+	if err != nil {
+		return false, err
+	}
+	if available {
+		return true, s.EnsureExtension(HLLExtension)
+	}
+	return false, s.withTx(func(db model.SQLCommon) error {
+		for _, stmt := range approxCountDistinctSQL {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ApproxCountDistinct returns an aggregate estimating count(DISTINCT column),
+// named alias, for cardinality dashboards on tables too large to count
+// exactly:
+//
+//	hll, err := s.EnsureApproxCountDistinct()
+//	...
+//	db.Select("day, " + s.ApproxCountDistinct("user_id", "users", hll)).Group("day")
+//
+// With hll it uses postgresql-hll, otherwise the approx_count_distinct
+// aggregate installed by EnsureApproxCountDistinct. Both run in a single pass
+// with constant memory per group.
+func (s Postgres) ApproxCountDistinct(column, alias string, hll bool) string {
+	if hll {
+		return fmt.Sprintf("hll_cardinality(hll_add_agg(hll_hash_any(%v)))::bigint AS %v", s.Quote(column), s.Quote(alias))
+	}
+	return fmt.Sprintf("approx_count_distinct(%v) AS %v", s.Quote(column), s.Quote(alias))
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// HstoreGet returns column -> key, the value stored under key.
+func (s Postgres) HstoreGet(column, key string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v -> ?", s.Quote(column)), Args: []interface{}{key}}
+}
+
+// HstoreHasKey returns the predicate column ? key. Like JSONHasKey the
+// operator is escaped as ??, so the Expr must go through Build.
+func (s Postgres) HstoreHasKey(column, key string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v ?? ?", s.Quote(column)), Args: []interface{}{key}}
+}
+
+// HstoreContains returns the predicate column @> pairs, true when column
+// holds every key of pairs with the same value.
+func (s Postgres) HstoreContains(column string, pairs map[string]string) Expr {
+	keys, values := hstorePairs(pairs)
+	return Expr{
+		SQL:  fmt.Sprintf("%v @> hstore(?::text[], ?::text[])", s.Quote(column)),
+		Args: []interface{}{pq.Array(keys), pq.Array(values)},
+	}
+}
+
+// HstoreSet returns an Assignment setting key to value inside column server
+// side, leaving the other keys untouched.
+func (s Postgres) HstoreSet(column, key, value string) Assignment {
+	return Assignment{
+		Column: column,
+		Value: Expr{
+			SQL:  fmt.Sprintf("COALESCE(%v, ''::hstore) || hstore(?::text, ?::text)", s.Quote(column)),
+			Args: []interface{}{key, value},
+		},
+	}
+}
+
+// HstoreMerge returns an Assignment adding pairs to column, overwriting
+// existing keys.
+func (s Postgres) HstoreMerge(column string, pairs map[string]string) Assignment {
+	keys, values := hstorePairs(pairs)
+	return Assignment{
+		Column: column,
+		Value: Expr{
+			SQL:  fmt.Sprintf("COALESCE(%v, ''::hstore) || hstore(?::text[], ?::text[])", s.Quote(column)),
+			Args: []interface{}{pq.Array(keys), pq.Array(values)},
+		},
+	}
+}
+
+// HstoreDelete returns an Assignment removing keys from column.
+func (s Postgres) HstoreDelete(column string, keys ...string) Assignment {
+	return Assignment{
+		Column: column,
+		Value: Expr{
+			SQL:  fmt.Sprintf("delete(%v, ?::text[])", s.Quote(column)),
+			Args: []interface{}{pq.Array(keys)},
+		},
+	}
+}
+
+// hstorePairs splits pairs into parallel key and value slices in key order.
+func hstorePairs(pairs map[string]string) ([]string, []string) {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = pairs[k]
+	}
+	return keys, values
+}
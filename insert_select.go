@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// InsertSelect describes an INSERT INTO ... SELECT statement.
+type InsertSelect struct {
+	Table string
+
+	// Columns are the target columns filled by the query, in order.
+	Columns []string
+
+	// Query is the SELECT producing the rows.
+	Query Expr
+
+	// OnConflict optionally resolves conflicts with existing rows.
+	OnConflict *OnConflict
+}
+
+// ColumnMapping maps a column of the source table to a column of the target
+// table. Source defaults to Target.
+type ColumnMapping struct {
+	Target string
+	Source string
+}
+
+// InsertSelectSQL returns the statement described by i.
+func (s Postgres) InsertSelectSQL(i InsertSelect) (string, []interface{}, error) {
+	if i.Query.SQL == "" {
+		return "", nil, errors.New("insert select needs a query")
+	}
+	e := Expr{SQL: "INSERT INTO " + s.Quote(i.Table)}
+	if len(i.Columns) > 0 {
+		e.SQL += fmt.Sprintf(" (%v)", s.quoteColumns(i.Columns))
+	}
+	e = Join(" ", e, i.Query)
+	if i.OnConflict != nil {
+		clause, err := s.OnConflictClause(i.Table, *i.OnConflict)
+		if err != nil {
+			return "", nil, err
+		}
+		e = Join(" ", e, clause)
+	}
+	query, args := s.Build(e)
+	return query, args, nil
+}
+
+// InsertSelect runs the statement described by i in one round trip and
+// returns the number of rows inserted.
+func (s Postgres) InsertSelect(i InsertSelect) (int64, error) {
+	query, args, err := s.InsertSelectSQL(i)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.DB.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// CopyTable inserts the rows of source matching where into target, mapping
+// columns as given. It is meant for archive and copy jobs between tables of
+// compatible models; conflicts are resolved by onConflict when not nil.
+func (s Postgres) CopyTable(target, source string, columns []ColumnMapping, where Expr, onConflict *OnConflict) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("copy table needs a column mapping")
+	}
+	targets := make([]string, len(columns))
+	sources := make([]string, len(columns))
+	for i, c := range columns {
+		targets[i] = c.Target
+		sources[i] = c.Source
+		if sources[i] == "" {
+			sources[i] = c.Target
+		}
+	}
+	query := Expr{SQL: fmt.Sprintf("SELECT %v FROM %v", s.quoteColumns(sources), s.Quote(source))}
+	if where.SQL != "" {
+		query = Join(" WHERE ", query, where)
+	}
+	return s.InsertSelect(InsertSelect{
+		Table:      target,
+		Columns:    targets,
+		Query:      query,
+		OnConflict: onConflict,
+	})
+}
@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/ngorm/ngorm/model"
+)
+
+// Constraint types as reported by ConstraintInfo.Type.
+const (
+	PrimaryKeyConstraint = "PRIMARY KEY"
+	ForeignKeyConstraint = "FOREIGN KEY"
+	UniqueConstraint     = "UNIQUE"
+	CheckConstraint      = "CHECK"
+	ExclusionConstraint  = "EXCLUDE"
+)
+
+// TableInfo describes a table found in the database.
+type TableInfo struct {
+	Schema      string
+	Name        string
+	Columns     []ColumnInfo
+	Indexes     []IndexInfo
+	Constraints []ConstraintInfo
+}
+
+// Column returns the column named name, or nil if the table has no such
+// column.
+func (t *TableInfo) Column(name string) *ColumnInfo {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Index returns the index named name, or nil if the table has no such index.
+func (t *TableInfo) Index(name string) *IndexInfo {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// ColumnInfo describes a table column. DataType is the type as rendered by
+// format_type, e.g. "character varying(255)" or "timestamp with time zone".
+type ColumnInfo struct {
+	Name     string
+	Position int
+	DataType string
+	Nullable bool
+	Default  sql.NullString
+}
+
+// IndexInfo describes an index on a table.
+type IndexInfo struct {
+	Name       string
+	Columns    []string
+	Unique     bool
+	Primary    bool
+	Where      string
+	Definition string
+}
+
+// ConstraintInfo describes a table constraint. RefTable and RefColumns are
+// only set for foreign keys.
+type ConstraintInfo struct {
+	Name       string
+	Type       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	Definition string
+}
+
+// Inspector reads structured schema information from the system catalogs.
+type Inspector struct {
+	db model.SQLCommon
+
+	// Schema is the schema to inspect. When empty the current schema of the
+	// connection is used.
+	Schema string
+}
+
+// Inspector returns an Inspector using the dialect's connection.
+func (s Postgres) Inspector() *Inspector {
+	return &Inspector{db: s.DB}
+}
+
+func (i *Inspector) schema() (string, error) {
+	if i.Schema != "" {
+		return i.Schema, nil
+	}
+	var name string
+	err := i.db.QueryRow("SELECT current_schema()").Scan(&name)
+	return name, err
+}
+
+// Tables returns the names of all base tables in the schema.
+func (i *Inspector) Tables() ([]string, error) {
+	schema, err := i.schema()
+	if err != nil {
+		return nil, err
+	}
+	query := `
+SELECT table_name
+FROM   information_schema.tables
+WHERE  table_schema = $1
+       AND table_type = 'BASE TABLE'
+ORDER  BY table_name
+	`
+	rows, err := i.db.Query(query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Table returns the full description of tableName.
+func (i *Inspector) Table(tableName string) (*TableInfo, error) {
+	schema, err := i.schema()
+	if err != nil {
+		return nil, err
+	}
+	t := &TableInfo{Schema: schema, Name: tableName}
+	if t.Columns, err = i.columns(schema, tableName); err != nil {
+		return nil, err
+	}
+	if len(t.Columns) == 0 {
+		return nil, fmt.Errorf("table %s.%s does not exist", schema, tableName)
+	}
+	if t.Indexes, err = i.indexes(schema, tableName); err != nil {
+		return nil, err
+	}
+	if t.Constraints, err = i.constraints(schema, tableName); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// All returns the description of every base table in the schema.
+func (i *Inspector) All() ([]*TableInfo, error) {
+	names, err := i.Tables()
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]*TableInfo, 0, len(names))
+	for _, name := range names {
+		t, err := i.Table(name)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+// Columns returns the columns of tableName ordered by position.
+func (i *Inspector) Columns(tableName string) ([]ColumnInfo, error) {
+	schema, err := i.schema()
+	if err != nil {
+		return nil, err
+	}
+	return i.columns(schema, tableName)
+}
+
+// Indexes returns the indexes defined on tableName.
+func (i *Inspector) Indexes(tableName string) ([]IndexInfo, error) {
+	schema, err := i.schema()
+	if err != nil {
+		return nil, err
+	}
+	return i.indexes(schema, tableName)
+}
+
+// Constraints returns the constraints defined on tableName.
+func (i *Inspector) Constraints(tableName string) ([]ConstraintInfo, error) {
+	schema, err := i.schema()
+	if err != nil {
+		return nil, err
+	}
+	return i.constraints(schema, tableName)
+}
+
+func (i *Inspector) columns(schema, tableName string) ([]ColumnInfo, error) {
+	query := `
+SELECT a.attname,
+       a.attnum,
+       format_type(a.atttypid, a.atttypmod),
+       NOT a.attnotnull,
+       pg_get_expr(d.adbin, d.adrelid)
+FROM   pg_attribute a
+       JOIN pg_class c ON c.oid = a.attrelid
+       JOIN pg_namespace n ON n.oid = c.relnamespace
+       LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE  n.nspname = $1
+       AND c.relname = $2
+       AND a.attnum > 0
+       AND NOT a.attisdropped
+ORDER  BY a.attnum
+	`
+	rows, err := i.db.Query(query, schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cols []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Position, &c.DataType, &c.Nullable, &c.Default); err != nil {
+			return nil, err
+		}
+		cols = append(cols, c)
+	}
+	return cols, rows.Err()
+}
+
+func (i *Inspector) indexes(schema, tableName string) ([]IndexInfo, error) {
+	query := `
+SELECT ic.relname,
+       ARRAY(SELECT a.attname
+             FROM   unnest(ix.indkey) WITH ORDINALITY k(attnum, ord)
+                    JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum
+             ORDER  BY k.ord),
+       ix.indisunique,
+       ix.indisprimary,
+       COALESCE(pg_get_expr(ix.indpred, ix.indrelid), ''),
+       pg_get_indexdef(ix.indexrelid)
+FROM   pg_index ix
+       JOIN pg_class t ON t.oid = ix.indrelid
+       JOIN pg_class ic ON ic.oid = ix.indexrelid
+       JOIN pg_namespace n ON n.oid = t.relnamespace
+WHERE  n.nspname = $1
+       AND t.relname = $2
+ORDER  BY ic.relname
+	`
+	rows, err := i.db.Query(query, schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var idx []IndexInfo
+	for rows.Next() {
+		var x IndexInfo
+		err := rows.Scan(&x.Name, pq.Array(&x.Columns), &x.Unique,
+			&x.Primary, &x.Where, &x.Definition)
+		if err != nil {
+			return nil, err
+		}
+		idx = append(idx, x)
+	}
+	return idx, rows.Err()
+}
+
+func (i *Inspector) constraints(schema, tableName string) ([]ConstraintInfo, error) {
+	query := `
+SELECT con.conname,
+       con.contype,
+       ARRAY(SELECT a.attname
+             FROM   unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+                    JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+             ORDER  BY k.ord),
+       COALESCE(ft.relname, ''),
+       ARRAY(SELECT a.attname
+             FROM   unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+                    JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+             ORDER  BY k.ord),
+       pg_get_constraintdef(con.oid)
+FROM   pg_constraint con
+       JOIN pg_class t ON t.oid = con.conrelid
+       JOIN pg_namespace n ON n.oid = t.relnamespace
+       LEFT JOIN pg_class ft ON ft.oid = con.confrelid
+WHERE  n.nspname = $1
+       AND t.relname = $2
+ORDER  BY con.conname
+	`
+	rows, err := i.db.Query(query, schema, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var cons []ConstraintInfo
+	for rows.Next() {
+		var c ConstraintInfo
+		var kind string
+		err := rows.Scan(&c.Name, &kind, pq.Array(&c.Columns), &c.RefTable,
+			pq.Array(&c.RefColumns), &c.Definition)
+		if err != nil {
+			return nil, err
+		}
+		c.Type = constraintType(kind)
+		cons = append(cons, c)
+	}
+	return cons, rows.Err()
+}
+
+func constraintType(contype string) string {
+	switch contype {
+	case "p":
+		return PrimaryKeyConstraint
+	case "f":
+		return ForeignKeyConstraint
+	case "u":
+		return UniqueConstraint
+	case "c":
+		return CheckConstraint
+	case "x":
+		return ExclusionConstraint
+	}
+	return contype
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Interval is a PostgreSQL interval, bound as a parameter instead of being
+// formatted into the query. Months and days are kept apart from the time
+// part, as their length depends on the date they are added to: adding one
+// month to January 31 yields the end of February.
+type Interval struct {
+	Months int
+	Days   int
+	Time   time.Duration
+}
+
+// Months returns an interval of n months.
+func Months(n int) Interval {
+	return Interval{Months: n}
+}
+
+// Days returns an interval of n days.
+func Days(n int) Interval {
+	return Interval{Days: n}
+}
+
+// Duration returns an interval of d.
+func Duration(d time.Duration) Interval {
+	return Interval{Time: d}
+}
+
+// String returns the interval in the input syntax of PostgreSQL, e.g.
+// "1 mons 2 days 03:04:05.000006".
+func (i Interval) String() string {
+	var parts []string
+	if i.Months != 0 {
+		parts = append(parts, fmt.Sprintf("%d mons", i.Months))
+	}
+	if i.Days != 0 {
+		parts = append(parts, fmt.Sprintf("%d days", i.Days))
+	}
+	if i.Time != 0 || len(parts) == 0 {
+		d, sign := i.Time, ""
+		if d < 0 {
+			d, sign = -d, "-"
+		}
+		us := d / time.Microsecond
+		parts = append(parts, fmt.Sprintf("%s%02d:%02d:%02d.%06d",
+			sign, us/3600e6, us/60e6%60, us/1e6%60, us%1e6))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Value implements driver.Valuer.
+func (i Interval) Value() (driver.Value, error) {
+	return i.String(), nil
+}
+
+// Ago returns now() - i, the start of a window ending now. now() is the
+// start of the transaction, so every statement of a transaction sees the
+// same window.
+func (s Postgres) Ago(i Interval) Expr {
+	return Expr{SQL: "now() - ?::interval", Args: []interface{}{i}}
+}
+
+// Within returns the predicate column >= now() - i, true for the rows of the
+// last i:
+//
+//	e := s.Within("created_at", postgres.Days(30))
+//	db.Where(e.SQL, e.Args...).Find(&orders)
+func (s Postgres) Within(column string, i Interval) Expr {
+	e := s.Ago(i)
+	return Expr{SQL: fmt.Sprintf("%v >= %v", s.Quote(column), e.SQL), Args: e.Args}
+}
+
+// AddInterval returns column + i.
+func (s Postgres) AddInterval(column string, i Interval) Expr {
+	return Expr{SQL: fmt.Sprintf("%v + ?::interval", s.Quote(column)), Args: []interface{}{i}}
+}
+
+// SubInterval returns column - i.
+func (s Postgres) SubInterval(column string, i Interval) Expr {
+	return Expr{SQL: fmt.Sprintf("%v - ?::interval", s.Quote(column)), Args: []interface{}{i}}
+}
+
+// TimeRange returns the predicate from <= column < to, the half open range
+// used so that consecutive ranges never count a row twice.
+func (s Postgres) TimeRange(column string, from, to time.Time) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("%v >= ? AND %v < ?", s.Quote(column), s.Quote(column)),
+		Args: []interface{}{from, to},
+	}
+}
+
+// DateTrunc returns date_trunc(unit, column), column truncated to the start
+// of its unit, e.g. "hour", "day", "week" or "month". The unit is written as
+// a literal rather than bound, so the same expression can be used in both
+// the select list and GROUP BY:
+//
+//	day := s.DateTrunc("day", "created_at")
+//	db.Select(day + " AS day, count(*)").Group(day)
+func (s Postgres) DateTrunc(unit, column string) string {
+	return fmt.Sprintf("date_trunc(%v, %v)", quoteLiteral(unit), s.Quote(column))
+}
+
+// TimeBuckets returns a set returning expression of the buckets of width
+// step from from to to, named alias, to left join aggregated rows on so that
+// empty buckets are reported too.
+func (s Postgres) TimeBuckets(from, to time.Time, step Interval, alias string) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("generate_series(?::timestamptz, ?::timestamptz, ?::interval) AS %v", s.Quote(alias)),
+		Args: []interface{}{from, to, step},
+	}
+}
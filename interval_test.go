@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalString(t *testing.T) {
+	for _, tc := range []struct {
+		in   Interval
+		want string
+	}{
+		{in: Interval{}, want: "00:00:00.000000"},
+		{in: Months(14), want: "14 mons"},
+		{in: Days(-3), want: "-3 days"},
+		{in: Duration(90 * time.Minute), want: "01:30:00.000000"},
+		{in: Duration(-90 * time.Minute), want: "-01:30:00.000000"},
+		{in: Duration(25 * time.Hour), want: "25:00:00.000000"},
+		{in: Duration(1500 * time.Nanosecond), want: "00:00:00.000001"},
+		{in: Duration(time.Second / 2), want: "00:00:00.500000"},
+		{
+			in:   Interval{Months: 1, Days: 2, Time: 3*time.Hour + 4*time.Minute + 5*time.Second + 6*time.Microsecond},
+			want: "1 mons 2 days 03:04:05.000006",
+		},
+		{in: Interval{Months: -1, Days: 15}, want: "-1 mons 15 days"},
+		{in: Interval{Days: 1, Time: -time.Hour}, want: "1 days -01:00:00.000000"},
+	} {
+		if got := tc.in.String(); got != tc.want {
+			t.Errorf("%#v.String() = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
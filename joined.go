@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeleteUsingSQL returns a DELETE FROM tableName USING using WHERE where
+// statement. using is the raw list of joined tables, which may carry
+// aliases, e.g. "orders o, customers c"; where joins them to tableName and
+// is required, as a missing join condition would delete every row.
+func (s Postgres) DeleteUsingSQL(tableName, using string, where Expr) (string, []interface{}, error) {
+	if using == "" || where.SQL == "" {
+		return "", nil, errors.New("delete using needs joined tables and a condition")
+	}
+	e := Join(" WHERE ", Expr{SQL: fmt.Sprintf("DELETE FROM %v USING %v", s.Quote(tableName), using)}, where)
+	query, args := s.Build(e)
+	return query, args, nil
+}
+
+// DeleteUsing deletes the rows of tableName matched through a join with
+// using and returns the number of rows deleted.
+func (s Postgres) DeleteUsing(tableName, using string, where Expr) (int64, error) {
+	query, args, err := s.DeleteUsingSQL(tableName, using, where)
+	if err != nil {
+		return 0, err
+	}
+	return s.execAffected(query, args...)
+}
+
+// UpdateFromSQL returns an UPDATE tableName SET ... FROM from WHERE where
+// statement. Assignment values may refer to the joined tables through Expr
+// values, e.g. Raw("c.tier"); from and where behave as in DeleteUsingSQL.
+func (s Postgres) UpdateFromSQL(tableName string, set []Assignment, from string, where Expr) (string, []interface{}, error) {
+	if from == "" || where.SQL == "" {
+		return "", nil, errors.New("update from needs joined tables and a condition")
+	}
+	e, err := s.updateExpr(tableName, set, Expr{})
+	if err != nil {
+		return "", nil, err
+	}
+	e.SQL += " FROM " + from
+	query, args := s.Build(Join(" WHERE ", e, where))
+	return query, args, nil
+}
+
+// UpdateFrom updates the rows of tableName matched through a join with from
+// and returns the number of rows updated.
+func (s Postgres) UpdateFrom(tableName string, set []Assignment, from string, where Expr) (int64, error) {
+	query, args, err := s.UpdateFromSQL(tableName, set, from, where)
+	if err != nil {
+		return 0, err
+	}
+	return s.execAffected(query, args...)
+}
+
+func (s Postgres) execAffected(query string, args ...interface{}) (int64, error) {
+	res, err := s.DB.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// JSONField returns column -> key, the jsonb value stored under key.
+func (s Postgres) JSONField(column, key string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v -> ?", s.Quote(column)), Args: []interface{}{key}}
+}
+
+// JSONText returns column ->> key, the value stored under key as text.
+func (s Postgres) JSONText(column, key string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v ->> ?", s.Quote(column)), Args: []interface{}{key}}
+}
+
+// JSONPath returns column #> path, the jsonb value found at path.
+func (s Postgres) JSONPath(column string, path ...string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v #> ?::text[]", s.Quote(column)), Args: []interface{}{pq.Array(path)}}
+}
+
+// JSONPathText returns column #>> path, the value found at path as text.
+func (s Postgres) JSONPathText(column string, path ...string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v #>> ?::text[]", s.Quote(column)), Args: []interface{}{pq.Array(path)}}
+}
+
+// JSONContains returns the predicate column @> value, where value is encoded
+// as JSON. It can use a GIN index on column.
+func (s Postgres) JSONContains(column string, value interface{}) (Expr, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return Expr{}, err
+	}
+	return Expr{SQL: fmt.Sprintf("%v @> ?::jsonb", s.Quote(column)), Args: []interface{}{string(b)}}, nil
+}
+
+// JSONHasKey returns the predicate column ? key, true when key is a top level
+// key of column.
+//
+// The jsonb ? operators collide with ? placeholders, so they are written
+// escaped as ?? and the Expr must go through Build before execution.
+func (s Postgres) JSONHasKey(column, key string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v ?? ?", s.Quote(column)), Args: []interface{}{key}}
+}
+
+// JSONHasAnyKey returns the predicate column ?| keys. See JSONHasKey about
+// escaping.
+func (s Postgres) JSONHasAnyKey(column string, keys ...string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v ??| ?::text[]", s.Quote(column)), Args: []interface{}{pq.Array(keys)}}
+}
+
+// JSONHasAllKeys returns the predicate column ?& keys. See JSONHasKey about
+// escaping.
+func (s Postgres) JSONHasAllKeys(column string, keys ...string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v ??& ?::text[]", s.Quote(column)), Args: []interface{}{pq.Array(keys)}}
+}
+
+// JSONSet returns an Assignment replacing the value at path inside column
+// with value, encoded as JSON, using jsonb_set. Missing keys are created.
+// It updates a single key server side instead of rewriting the document:
+//
+//	set, _ := s.JSONSet("settings", []string{"theme"}, "dark")
+//	s.UpdateReturning(&u, "users", []postgres.Assignment{set}, where)
+func (s Postgres) JSONSet(column string, path []string, value interface{}) (Assignment, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return Assignment{}, err
+	}
+	return Assignment{
+		Column: column,
+		Value: Expr{
+			SQL:  fmt.Sprintf("jsonb_set(COALESCE(%v, '{}'::jsonb), ?::text[], ?::jsonb, true)", s.Quote(column)),
+			Args: []interface{}{pq.Array(path), string(b)},
+		},
+	}, nil
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Keyset describes a page of a keyset (seek) paginated query. Instead of
+// skipping rows with OFFSET, the next page starts right after the sort key of
+// the last row seen, which stays fast however deep the page is.
+type Keyset struct {
+	// Columns is the sort key. It must be unique over the table, typically
+	// by ending in the primary key: []string{"created_at", "id"}.
+	Columns []string
+
+	// After holds the sort key values of the last row of the previous page,
+	// one per column. It is empty for the first page.
+	After []interface{}
+
+	// Desc sorts in descending order.
+	Desc bool
+
+	// Limit is the page size. There is no default: zero means no LIMIT.
+	Limit int
+}
+
+// KeysetClauses returns the WHERE predicate and ORDER BY list of k, for use
+// with the query builder's Where and Order; k.Limit goes to its Limit. The
+// predicate is empty for the first page.
+func (s Postgres) KeysetClauses(k Keyset) (Expr, string, error) {
+	if len(k.Columns) == 0 {
+		return Expr{}, "", errors.New("keyset needs at least one column")
+	}
+	if len(k.After) != 0 && len(k.After) != len(k.Columns) {
+		return Expr{}, "", fmt.Errorf("keyset has %d values for %d columns", len(k.After), len(k.Columns))
+	}
+	op, dir := ">", "ASC"
+	if k.Desc {
+		op, dir = "<", "DESC"
+	}
+	order := ""
+	for i, c := range k.Columns {
+		if i > 0 {
+			order += ", "
+		}
+		order += s.Quote(c) + " " + dir
+	}
+	var where Expr
+	if len(k.After) > 0 {
+		where = Expr{
+			SQL:  fmt.Sprintf("(%v) %v (%v)", s.quoteColumns(k.Columns), op, placeholders(len(k.After))),
+			Args: append([]interface{}{}, k.After...),
+		}
+	}
+	return where, order, nil
+}
+
+// KeysetQuery returns the query selecting the page k of the rows of
+// tableName matching filter.
+func (s Postgres) KeysetQuery(tableName string, filter Expr, k Keyset) (Expr, error) {
+	seek, order, err := s.KeysetClauses(k)
+	if err != nil {
+		return Expr{}, err
+	}
+	e := Expr{SQL: "SELECT * FROM " + s.Quote(tableName)}
+	if where := And(filter, seek); where.SQL != "" {
+		e = Join(" WHERE ", e, where)
+	}
+	e.SQL += " ORDER BY " + order
+	if k.Limit > 0 {
+		e.SQL += fmt.Sprintf(" LIMIT %d", k.Limit)
+	}
+	return e, nil
+}
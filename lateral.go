@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// CrossJoinLateral returns a CROSS JOIN LATERAL clause over query, which may
+// refer to columns of the tables preceding it. Rows without a match in query
+// are dropped. The result is meant for the query builder's Joins:
+//
+//	j := s.CrossJoinLateral(postgres.Raw(
+//		`SELECT * FROM orders o WHERE o.user_id = users.id ORDER BY o.created_at DESC LIMIT ?`, 3),
+//		"recent")
+//	db.Joins(j.SQL, j.Args...)
+func (s Postgres) CrossJoinLateral(query Expr, alias string, columns ...string) Expr {
+	return s.lateral("CROSS JOIN LATERAL", query, alias, columns, "")
+}
+
+// LeftJoinLateral is like CrossJoinLateral but keeps rows without a match in
+// query, filling the lateral columns with NULL.
+func (s Postgres) LeftJoinLateral(query Expr, alias string, columns ...string) Expr {
+	return s.lateral("LEFT JOIN LATERAL", query, alias, columns, " ON true")
+}
+
+func (s Postgres) lateral(kind string, query Expr, alias string, columns []string, on string) Expr {
+	as := s.Quote(alias)
+	if len(columns) > 0 {
+		as += fmt.Sprintf("(%v)", s.quoteColumns(columns))
+	}
+	return Expr{
+		SQL:  fmt.Sprintf("%v (%v) AS %v%v", kind, query.SQL, as, on),
+		Args: query.Args,
+	}
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Row lock strengths, strongest first.
+const (
+	ForUpdate      = "UPDATE"
+	ForNoKeyUpdate = "NO KEY UPDATE"
+	ForShare       = "SHARE"
+	ForKeyShare    = "KEY SHARE"
+)
+
+// Lock describes the locking clause of a SELECT.
+type Lock struct {
+	// Strength is one of ForUpdate, ForNoKeyUpdate, ForShare or ForKeyShare.
+	Strength string
+
+	// Of restricts locking to the named tables of the query.
+	Of []string
+
+	// SkipLocked skips rows locked by other transactions instead of waiting.
+	SkipLocked bool
+
+	// NoWait fails immediately instead of waiting for locked rows.
+	NoWait bool
+}
+
+// LockingClause returns the FOR ... clause for l, to be appended to a SELECT
+// (through the query builder's query option setting, for instance).
+func (s Postgres) LockingClause(l Lock) (string, error) {
+	switch l.Strength {
+	case ForUpdate, ForNoKeyUpdate, ForShare, ForKeyShare:
+	default:
+		return "", fmt.Errorf("invalid lock strength %q", l.Strength)
+	}
+	if l.SkipLocked && l.NoWait {
+		return "", errors.New("lock can not both skip locked rows and not wait")
+	}
+	var buf strings.Builder
+	buf.WriteString("FOR " + l.Strength)
+	if len(l.Of) > 0 {
+		buf.WriteString(" OF " + s.quoteColumns(l.Of))
+	}
+	if l.SkipLocked {
+		buf.WriteString(" SKIP LOCKED")
+	}
+	if l.NoWait {
+		buf.WriteString(" NOWAIT")
+	}
+	return buf.String(), nil
+}
+
+// ClaimQuery returns a SELECT locking up to limit rows of tableName matching
+// where, in order, with FOR UPDATE SKIP LOCKED. It is the building block of
+// job queues where concurrent workers must never pick the same row.
+// The caller is expected to run it, and update the claimed rows, inside a
+// transaction.
+func (s Postgres) ClaimQuery(tableName string, where Expr, order string, limit int) Expr {
+	e := Expr{SQL: "SELECT * FROM " + s.Quote(tableName)}
+	if where.SQL != "" {
+		e = Join(" WHERE ", e, where)
+	}
+	if order != "" {
+		e.SQL += " ORDER BY " + order
+	}
+	if limit > 0 {
+		e.SQL += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	e.SQL += " FOR UPDATE SKIP LOCKED"
+	return e
+}
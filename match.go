@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the LIKE wildcards in s, so user input can be embedded
+// in a pattern: "%" + EscapeLike(input) + "%".
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// ILike returns the case insensitive predicate column ILIKE pattern.
+func (s Postgres) ILike(column, pattern string) Expr {
+	return s.matchOp(column, "ILIKE", pattern)
+}
+
+// NotILike returns the predicate column NOT ILIKE pattern.
+func (s Postgres) NotILike(column, pattern string) Expr {
+	return s.matchOp(column, "NOT ILIKE", pattern)
+}
+
+// IContains returns a case insensitive substring match of text in column,
+// escaping the wildcards of text.
+func (s Postgres) IContains(column, text string) Expr {
+	return s.ILike(column, "%"+EscapeLike(text)+"%")
+}
+
+// IHasPrefix returns a case insensitive prefix match of prefix in column,
+// escaping the wildcards of prefix.
+func (s Postgres) IHasPrefix(column, prefix string) Expr {
+	return s.ILike(column, EscapeLike(prefix)+"%")
+}
+
+// Regexp returns the case sensitive POSIX regular expression match
+// column ~ pattern.
+func (s Postgres) Regexp(column, pattern string) Expr {
+	return s.matchOp(column, "~", pattern)
+}
+
+// IRegexp returns the case insensitive regular expression match
+// column ~* pattern.
+func (s Postgres) IRegexp(column, pattern string) Expr {
+	return s.matchOp(column, "~*", pattern)
+}
+
+// NotIRegexp returns the predicate column !~* pattern.
+func (s Postgres) NotIRegexp(column, pattern string) Expr {
+	return s.matchOp(column, "!~*", pattern)
+}
+
+func (s Postgres) matchOp(column, op, pattern string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v %v ?", s.Quote(column), op), Args: []interface{}{pattern}}
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// HasMaterializedView reports whether a materialized view named viewName
+// exists.
+func (s Postgres) HasMaterializedView(viewName string) bool {
+	ok, _ := s.MaterializedViewExists(viewName)
+	return ok
+}
+
+// MaterializedViewExists is like HasMaterializedView but reports the errors
+// of the lookup.
+func (s Postgres) MaterializedViewExists(viewName string) (bool, error) {
+	return s.exists("SELECT count(*) FROM pg_matviews WHERE schemaname = current_schema() AND matviewname = $1", viewName)
+}
+
+// CreateMaterializedView creates the materialized view viewName defined by
+// query. With withData false the view is created unpopulated.
+func (s Postgres) CreateMaterializedView(viewName, query string, withData bool) error {
+	data := "WITH DATA"
+	if !withData {
+		data = "WITH NO DATA"
+	}
+	_, err := s.DB.Exec(fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %v AS %v %v",
+		s.Quote(viewName), query, data))
+	return err
+}
+
+// RefreshMaterializedView replaces the contents of viewName.
+//
+// A concurrent refresh does not lock out readers, but requires the view to be
+// populated and to have a unique index without a WHERE clause; both are
+// checked up front so the caller gets a clear error instead of a failed
+// refresh.
+func (s Postgres) RefreshMaterializedView(viewName string, concurrently bool) error {
+	if concurrently {
+		if err := s.checkConcurrentRefresh(viewName); err != nil {
+			return err
+		}
+	}
+	mode := ""
+	if concurrently {
+		mode = "CONCURRENTLY "
+	}
+	_, err := s.DB.Exec(fmt.Sprintf("REFRESH MATERIALIZED VIEW %v%v", mode, s.Quote(viewName)))
+	return err
+}
+
+func (s Postgres) checkConcurrentRefresh(viewName string) error {
+	var populated bool
+	var uniqueIndexes int
+	query := `
+SELECT c.relispopulated,
+       (SELECT Count(*)
+        FROM   pg_index ix
+        WHERE  ix.indrelid = c.oid
+               AND ix.indisunique
+               AND ix.indisvalid
+               AND ix.indpred IS NULL
+               AND ix.indexprs IS NULL)
+FROM   pg_class c
+WHERE  c.relname = $1
+       AND c.relkind = 'm'
+	`
+	err := s.DB.QueryRow(query, viewName).Scan(&populated, &uniqueIndexes)
+	if err != nil {
+		return fmt.Errorf("materialized view %s: %w", viewName, NotFound(err))
+	}
+	if !populated {
+		return fmt.Errorf("materialized view %s is not populated; refresh it without CONCURRENTLY first", viewName)
+	}
+	if uniqueIndexes == 0 {
+		return fmt.Errorf("materialized view %s needs a unique index on plain columns to be refreshed concurrently", viewName)
+	}
+	return nil
+}
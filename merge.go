@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Merge describes a MERGE of a source relation into a table.
+type Merge struct {
+	Table string
+
+	// Source is a table name or a parenthesized query producing the rows to
+	// merge, referred to as Alias (default "src").
+	Source Expr
+	Alias  string
+
+	// On lists the key columns that match a source row to a target row. On
+	// servers without MERGE they must be covered by a unique index.
+	On []string
+
+	// Update lists the columns copied from the source when a row matches.
+	Update []string
+
+	// Insert lists the columns filled from the source when no row matches.
+	Insert []string
+
+	// DeleteWhen, when set, deletes matched rows for which this condition
+	// holds instead of updating them. The target table is aliased t in the
+	// condition. It requires MERGE.
+	DeleteWhen string
+}
+
+func (m Merge) alias() string {
+	if m.Alias == "" {
+		return "src"
+	}
+	return m.Alias
+}
+
+// MergeSQL returns a MERGE statement for m. It requires Postgres 15.
+func (s Postgres) MergeSQL(m Merge) (string, []interface{}, error) {
+	if len(m.On) == 0 || (len(m.Update) == 0 && len(m.Insert) == 0 && m.DeleteWhen == "") {
+		return "", nil, errors.New("merge needs key columns and at least one action")
+	}
+	src := s.Quote(m.alias())
+	on := make([]string, len(m.On))
+	for i, c := range m.On {
+		on[i] = fmt.Sprintf("t.%v = %v.%v", s.Quote(c), src, s.Quote(c))
+	}
+	e := Join(" ",
+		Expr{SQL: fmt.Sprintf("MERGE INTO %v AS t USING", s.Quote(m.Table))},
+		m.Source,
+		Expr{SQL: fmt.Sprintf("AS %v ON %v", src, strings.Join(on, " AND "))},
+	)
+	if m.DeleteWhen != "" {
+		e.SQL += fmt.Sprintf(" WHEN MATCHED AND %v THEN DELETE", m.DeleteWhen)
+	}
+	if len(m.Update) > 0 {
+		e.SQL += " WHEN MATCHED THEN UPDATE SET " + s.sourceAssignments(m.Update, src)
+	}
+	if len(m.Insert) > 0 {
+		e.SQL += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%v) VALUES (%v)",
+			s.quoteColumns(m.Insert), s.sourceColumns(m.Insert, src))
+	}
+	query, args := s.Build(e)
+	return query, args, nil
+}
+
+// MergeFallbackSQL returns the INSERT ... ON CONFLICT statement equivalent to
+// m, for servers without MERGE. Every updated column must also be inserted,
+// and DeleteWhen is not supported.
+func (s Postgres) MergeFallbackSQL(m Merge) (string, []interface{}, error) {
+	if m.DeleteWhen != "" {
+		return "", nil, errors.New("merge with delete requires Postgres 15")
+	}
+	if len(m.On) == 0 || len(m.Insert) == 0 {
+		return "", nil, errors.New("merge fallback needs key and insert columns")
+	}
+	for _, c := range m.Update {
+		if !containsString(m.Insert, c) {
+			return "", nil, fmt.Errorf("merge fallback can not update column %s which is not inserted", c)
+		}
+	}
+	c := OnConflict{Columns: m.On, Update: m.Update, DoNothing: len(m.Update) == 0}
+	src := s.Quote(m.alias())
+	return s.InsertSelectSQL(InsertSelect{
+		Table:   m.Table,
+		Columns: m.Insert,
+		Query: Join(" ",
+			Expr{SQL: fmt.Sprintf("SELECT %v FROM", s.sourceColumns(m.Insert, src))},
+			m.Source,
+			Expr{SQL: "AS " + src}),
+		OnConflict: &c,
+	})
+}
+
+// Merge applies m with MERGE when the server supports it, and with
+// INSERT ... ON CONFLICT otherwise. It returns the number of rows affected.
+func (s Postgres) Merge(m Merge) (int64, error) {
+	caps, err := s.Capabilities()
+	if err != nil {
+		return 0, err
+	}
+	build := s.MergeFallbackSQL
+	if caps.Merge {
+		build = s.MergeSQL
+	}
+	query, args, err := build(m)
+	if err != nil {
+		return 0, err
+	}
+	return s.execAffected(query, args...)
+}
+
+func (s Postgres) sourceColumns(columns []string, src string) string {
+	out := make([]string, len(columns))
+	for i, c := range columns {
+		out[i] = src + "." + s.Quote(c)
+	}
+	return strings.Join(out, ", ")
+}
+
+func (s Postgres) sourceAssignments(columns []string, src string) string {
+	out := make([]string, len(columns))
+	for i, c := range columns {
+		out[i] = fmt.Sprintf("%v = %v.%v", s.Quote(c), src, s.Quote(c))
+	}
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics collects Prometheus metrics about the statements reported to it
+// as a QueryLogger, typically by a LoggedDB, and about the connection pools
+// it watches.
+type Metrics struct {
+	queries *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+
+	mu    sync.Mutex
+	pools map[string]*sql.DB
+
+	inUse, idle, open, waitCount, waitDuration *prometheus.Desc
+}
+
+// NewMetrics returns Metrics whose names are prefixed with namespace.
+func NewMetrics(namespace string) *Metrics {
+	poolDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "postgres_pool", name), help, []string{"pool"}, nil)
+	}
+	return &Metrics{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "postgres",
+			Name:      "queries_total",
+			Help:      "Statements run, by operation.",
+		}, []string{"operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "postgres",
+			Name:      "errors_total",
+			Help:      "Failed statements, by operation and SQLSTATE.",
+		}, []string{"operation", "sqlstate"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "postgres",
+			Name:      "query_duration_seconds",
+			Help:      "Statement latency, by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		pools:        map[string]*sql.DB{},
+		inUse:        poolDesc("in_use_connections", "Connections currently in use."),
+		idle:         poolDesc("idle_connections", "Idle connections."),
+		open:         poolDesc("open_connections", "Established connections."),
+		waitCount:    poolDesc("wait_total", "Connections waited for."),
+		waitDuration: poolDesc("wait_seconds_total", "Time spent waiting for a connection."),
+	}
+}
+
+// Register registers m with reg, prometheus.DefaultRegisterer when nil.
+func (m *Metrics) Register(reg prometheus.Registerer) error {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	return reg.Register(m)
+}
+
+// WatchPool reports the statistics of db labelled with name.
+func (m *Metrics) WatchPool(name string, db *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools[name] = db
+}
+
+// LogQuery implements QueryLogger.
+func (m *Metrics) LogQuery(e QueryEvent) {
+	op := operation(e.Query)
+	m.queries.WithLabelValues(op).Inc()
+	m.latency.WithLabelValues(op).Observe(e.Duration.Seconds())
+	if e.Err != nil {
+		code := sqlState(e.Err)
+		if code == "" {
+			code = "client"
+		}
+		m.errors.WithLabelValues(op, code).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.queries.Describe(ch)
+	m.errors.Describe(ch)
+	m.latency.Describe(ch)
+	for _, d := range []*prometheus.Desc{m.inUse, m.idle, m.open, m.waitCount, m.waitDuration} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.queries.Collect(ch)
+	m.errors.Collect(ch)
+	m.latency.Collect(ch)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, db := range m.pools {
+		st := db.Stats()
+		ch <- prometheus.MustNewConstMetric(m.inUse, prometheus.GaugeValue, float64(st.InUse), name)
+		ch <- prometheus.MustNewConstMetric(m.idle, prometheus.GaugeValue, float64(st.Idle), name)
+		ch <- prometheus.MustNewConstMetric(m.open, prometheus.GaugeValue, float64(st.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(m.waitCount, prometheus.CounterValue, float64(st.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(m.waitDuration, prometheus.CounterValue, st.WaitDuration.Seconds(), name)
+	}
+}
+
+// operation returns the lower cased command of query, such as select or
+// insert, keeping the label cardinality bounded.
+func operation(query string) string {
+	fields := strings.Fields(strings.TrimLeft(query, "("))
+	if len(fields) == 0 {
+		return "other"
+	}
+	op := strings.ToLower(fields[0])
+	switch op {
+	case "select", "insert", "update", "delete", "merge", "with", "copy",
+		"create", "alter", "drop", "truncate", "begin", "commit", "rollback",
+		"savepoint", "release", "set", "show", "explain", "vacuum", "analyze",
+		"lock", "notify", "listen", "values", "table":
+		return op
+	}
+	return "other"
+}
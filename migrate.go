@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// MigrationLock configures the advisory lock taken by WithMigrationLock.
+type MigrationLock struct {
+	// Key identifies the lock. Applications sharing a database but not
+	// their schema should use different keys. Defaults to
+	// DefaultMigrationLockKey.
+	Key *LockKey
+
+	// Timeout bounds the time spent waiting for another instance to finish
+	// migrating. There is no limit but the context's when zero.
+	Timeout time.Duration
+
+	// PollInterval is the time between two attempts to take the lock.
+	// Defaults to one second.
+	PollInterval time.Duration
+}
+
+// DefaultMigrationLockKey is the key of the migration lock unless
+// MigrationLock.Key is set.
+var DefaultMigrationLockKey = KeyFor("ngorm/postgres migrate")
+
+// ErrMigrationLockTimeout is returned when the migration lock could not be
+// taken within MigrationLock.Timeout.
+var ErrMigrationLockTimeout = errors.New("timed out waiting for the migration lock")
+
+// WithMigrationLock runs fn, typically automigrate, while holding the
+// session level advisory lock described by l, so application instances
+// deploying at the same time migrate one after the other instead of racing on
+// CREATE TABLE and CREATE INDEX. Instances that waited find the schema up to
+// date. The lock is taken on a connection of its own, which is released
+// along with the lock when fn returns.
+func (s Postgres) WithMigrationLock(ctx context.Context, l MigrationLock, fn func() error) error {
+	key := DefaultMigrationLockKey
+	if l.Key != nil {
+		key = *l.Key
+	}
+	interval := l.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	var deadline <-chan time.Time
+	if l.Timeout > 0 {
+		timer := time.NewTimer(l.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		lock, err := s.TryAdvisoryLock(ctx, key)
+		if err != nil {
+			return err
+		}
+		if lock != nil {
+			err := fn()
+			if uerr := lock.Unlock(); err == nil {
+				err = uerr
+			}
+			return err
+		}
+		select {
+		case <-time.After(interval):
+		case <-deadline:
+			return ErrMigrationLockTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"log"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/lib/pq"
+	"github.com/ngorm/ngorm/model"
+)
+
+// Notice is a message sent by the server outside of an error, such as those
+// raised by RAISE NOTICE or the "identifier will be truncated" warning of DDL.
+type Notice struct {
+	// Severity is NOTICE, WARNING, INFO, LOG or DEBUG.
+	Severity string
+
+	// Code is the SQLSTATE of the notice, e.g. "00000" or "42622".
+	Code    string
+	Message string
+	Detail  string
+	Hint    string
+
+	// Where is the call stack of the function that raised the notice.
+	Where string
+}
+
+func (n Notice) String() string {
+	s := n.Severity + ": " + n.Message
+	if n.Detail != "" {
+		s += " (" + n.Detail + ")"
+	}
+	return s
+}
+
+// LogNotice writes n to the standard logger. It can be used as
+// Connector.OnNotice.
+func LogNotice(n Notice) {
+	log.Printf("postgres %v", n)
+}
+
+// noticeSinks holds the functions capturing the notices of connections
+// while captureNotices runs, keyed by the lib/pq driver connection or the
+// pgx *pgconn.PgConn.
+var noticeSinks sync.Map
+
+// notice delivers n, received on the connection key, to its sink or to
+// c.OnNotice.
+func (c *Connector) notice(key interface{}, n Notice) {
+	if sink, ok := noticeSinks.Load(key); ok {
+		sink.(func(Notice))(n)
+		return
+	}
+	if c.OnNotice != nil {
+		c.OnNotice(n)
+	}
+}
+
+// noticeConn installs the notice handler of c on conn when it was made by
+// lib/pq. pgx connections get theirs from their configuration instead, see
+// pgxNotices.
+func (c *Connector) noticeConn(base driver.Connector, conn driver.Conn) {
+	if _, ok := base.(*pq.Connector); !ok {
+		return
+	}
+	pq.SetNoticeHandler(conn, func(e *pq.Error) {
+		c.notice(conn, Notice{
+			Severity: e.Severity,
+			Code:     string(e.Code),
+			Message:  e.Message,
+			Detail:   e.Detail,
+			Hint:     e.Hint,
+			Where:    e.Where,
+		})
+	})
+}
+
+// pgxNotices returns a pgx option forwarding the notices of the connection
+// to c.OnNotice, which may be set after the Connector was created.
+func pgxNotices(c *Connector) func(*pgx.ConnConfig) error {
+	return func(config *pgx.ConnConfig) error {
+		config.OnNotice = func(pc *pgconn.PgConn, n *pgconn.Notice) {
+			c.notice(pc, Notice{
+				Severity: n.Severity,
+				Code:     n.Code,
+				Message:  n.Message,
+				Detail:   n.Detail,
+				Hint:     n.Hint,
+				Where:    n.Where,
+			})
+		}
+		return nil
+	}
+}
+
+// captureNotices runs fn on a dedicated connection and returns the notices
+// the server sent meanwhile, such as the output of VACUUM VERBOSE. Notices
+// are only captured on connections made by a Connector, and not when the
+// dialect's connection is a transaction.
+func (s Postgres) captureNotices(ctx context.Context, fn func(db model.SQLCommon) error) ([]Notice, error) {
+	db, release, err := s.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	pinned, ok := db.(pinnedConn)
+	if !ok {
+		return nil, fn(db)
+	}
+	var mu sync.Mutex
+	var notices []Notice
+	var key interface{}
+	err = pinned.conn.Raw(func(dc interface{}) error {
+		key = dc
+		if c, ok := dc.(*stdlib.Conn); ok {
+			key = c.Conn().PgConn()
+		}
+		noticeSinks.Store(key, func(n Notice) {
+			mu.Lock()
+			notices = append(notices, n)
+			mu.Unlock()
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer noticeSinks.Delete(key)
+	err = fn(db)
+	mu.Lock()
+	defer mu.Unlock()
+	return notices, err
+}
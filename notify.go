@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification is a payload delivered by NOTIFY.
+type Notification struct {
+	Channel string
+	Payload string
+
+	// PID is the process id of the backend that sent the notification.
+	PID int
+}
+
+// ListenerConfig configures a Notifications subscriber.
+type ListenerConfig struct {
+	// MinReconnect and MaxReconnect bound the wait between reconnection
+	// attempts. They default to 10 seconds and 1 minute.
+	MinReconnect time.Duration
+	MaxReconnect time.Duration
+
+	// PingInterval is how often an idle connection is checked. Defaults to
+	// 90 seconds.
+	PingInterval time.Duration
+
+	// Buffer is the capacity of the notification channel.
+	Buffer int
+
+	// OnReconnect is called after the connection was lost and
+	// re-established. Notifications sent in between are lost, so callers
+	// relying on them should resynchronize, e.g. drop their caches.
+	OnReconnect func()
+
+	// OnError is called with connection errors.
+	OnError func(error)
+}
+
+// Notifications subscribes to NOTIFY channels on a dedicated connection and
+// delivers their payloads on C. The connection is re-established
+// automatically and the subscriptions restored when it is lost.
+type Notifications struct {
+	// C receives the notifications. It is closed by Close.
+	C <-chan Notification
+
+	listener *pq.Listener
+	out      chan Notification
+	config   ListenerConfig
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// Listen opens a connection using the lib/pq connection string dsn and
+// subscribes it to channels.
+func Listen(dsn string, config ListenerConfig, channels ...string) (*Notifications, error) {
+	if config.MinReconnect <= 0 {
+		config.MinReconnect = 10 * time.Second
+	}
+	if config.MaxReconnect <= 0 {
+		config.MaxReconnect = time.Minute
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = 90 * time.Second
+	}
+	n := &Notifications{
+		out:    make(chan Notification, config.Buffer),
+		config: config,
+		done:   make(chan struct{}),
+	}
+	n.C = n.out
+	n.listener = pq.NewListener(dsn, config.MinReconnect, config.MaxReconnect, n.event)
+	for _, ch := range channels {
+		if err := n.listener.Listen(ch); err != nil {
+			n.listener.Close()
+			return nil, err
+		}
+	}
+	n.wg.Add(1)
+	go n.run()
+	return n, nil
+}
+
+func (n *Notifications) event(ev pq.ListenerEventType, err error) {
+	if err != nil && n.config.OnError != nil {
+		n.config.OnError(err)
+	}
+}
+
+func (n *Notifications) run() {
+	defer n.wg.Done()
+	defer close(n.out)
+	ticker := time.NewTicker(n.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.done:
+			return
+		case msg, ok := <-n.listener.Notify:
+			if !ok {
+				return
+			}
+			if msg == nil {
+				// The listener reconnected.
+				if n.config.OnReconnect != nil {
+					n.config.OnReconnect()
+				}
+				continue
+			}
+			select {
+			case n.out <- Notification{Channel: msg.Channel, Payload: msg.Extra, PID: msg.BePid}:
+			case <-n.done:
+				return
+			}
+		case <-ticker.C:
+			go n.listener.Ping()
+		}
+	}
+}
+
+// Listen subscribes to channel.
+func (n *Notifications) Listen(channel string) error {
+	return n.listener.Listen(channel)
+}
+
+// Unlisten unsubscribes from channel.
+func (n *Notifications) Unlisten(channel string) error {
+	return n.listener.Unlisten(channel)
+}
+
+// Close unsubscribes from every channel, closes the connection and then C.
+func (n *Notifications) Close() error {
+	close(n.done)
+	err := n.listener.Close()
+	n.wg.Wait()
+	return err
+}
+
+// Notify sends payload on channel. Inside a transaction the notification is
+// only delivered once the transaction commits.
+func (s Postgres) Notify(channel, payload string) error {
+	_, err := s.DB.Exec("SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
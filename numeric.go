@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Fields of type big.Rat and big.Float, or pointers to them, are numeric
+// columns scanned without going through float64, so amounts keep every
+// digit. Other decimal types can be registered the same way with
+// RegisterType.
+func init() {
+	RegisterType(big.Rat{}, TypeMapper{SQLType: "numeric", Parse: parseRat, Format: formatRat})
+	RegisterType(big.Float{}, TypeMapper{SQLType: "numeric", Parse: parseFloat, Format: formatFloat})
+}
+
+func parseRat(text string) (interface{}, error) {
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return nil, fmt.Errorf("invalid numeric %q", text)
+	}
+	return *r, nil
+}
+
+// formatRat writes the big.Rat v in full. Fractions without a finite
+// decimal form, such as 1/3, are refused rather than rounded.
+func formatRat(v interface{}) (string, error) {
+	r := v.(big.Rat)
+	if r.IsInt() {
+		return r.Num().String(), nil
+	}
+	d := new(big.Int).Set(r.Denom())
+	var twos, fives int
+	two, five, rem := big.NewInt(2), big.NewInt(5), new(big.Int)
+	for {
+		if q, m := new(big.Int).QuoRem(d, two, rem); m.Sign() == 0 {
+			d, twos = q, twos+1
+			continue
+		}
+		if q, m := new(big.Int).QuoRem(d, five, rem); m.Sign() == 0 {
+			d, fives = q, fives+1
+			continue
+		}
+		break
+	}
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return "", fmt.Errorf("%v has no finite decimal form", r.String())
+	}
+	digits := twos
+	if fives > digits {
+		digits = fives
+	}
+	return r.FloatString(digits), nil
+}
+
+func parseFloat(text string) (interface{}, error) {
+	// About 3.3 bits per decimal digit keep every digit of text.
+	prec := uint(len(text)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	f, _, err := big.ParseFloat(strings.TrimSpace(text), 10, prec, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid numeric %q: %v", text, err)
+	}
+	return *f, nil
+}
+
+func formatFloat(v interface{}) (string, error) {
+	f := v.(big.Float)
+	if f.IsInf() {
+		return "", fmt.Errorf("can not write %v as numeric", f.String())
+	}
+	return f.Text('f', -1), nil
+}
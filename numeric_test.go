@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestFormatRat(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "5", want: "5"},
+		{in: "-42", want: "-42"},
+		{in: "0", want: "0"},
+		{in: "3/4", want: "0.75"},
+		{in: "-1/8", want: "-0.125"},
+		{in: "1/10", want: "0.1"},
+		{in: "1/20", want: "0.05"},
+		{in: "12345678901234567890123456789/1000", want: "12345678901234567890123456.789"},
+		{in: "1/3", err: true},
+		{in: "7/6", err: true},
+	} {
+		r, ok := new(big.Rat).SetString(tc.in)
+		if !ok {
+			t.Fatalf("invalid rational %q", tc.in)
+		}
+		got, err := formatRat(*r)
+		if tc.err {
+			if err == nil {
+				t.Errorf("formatRat(%v): expected an error, got %q", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("formatRat(%v): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("formatRat(%v) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseRat(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "1.25", want: "5/4"},
+		{in: "-0.001", want: "-1/1000"},
+		{in: "12345678901234567890.5", want: "24691357802469135781/2"},
+		{in: "NaN", err: true},
+		{in: "abc", err: true},
+	} {
+		v, err := parseRat(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseRat(%q): expected an error, got %v", tc.in, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseRat(%q): %v", tc.in, err)
+			continue
+		}
+		r := v.(big.Rat)
+		if got := r.String(); got != tc.want {
+			t.Errorf("parseRat(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseFloat(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "1.5", want: "1.5"},
+		{in: " 2.25 ", want: "2.25"},
+		{in: "-0.1", want: "-0.1"},
+		{in: "100000000000000000000", want: "100000000000000000000"},
+		{in: "3.14159265358979323846264338327950288", want: "3.14159265358979323846264338327950288"},
+		{in: "12345678901234567890.123456789", want: "12345678901234567890.123456789"},
+		{in: "1.5.2", err: true},
+		{in: "", err: true},
+	} {
+		v, err := parseFloat(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("parseFloat(%q): expected an error, got %v", tc.in, v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseFloat(%q): %v", tc.in, err)
+			continue
+		}
+		got, err := formatFloat(v)
+		if err != nil {
+			t.Errorf("formatFloat(parseFloat(%q)): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("formatFloat(parseFloat(%q)) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	for _, tc := range []struct {
+		in   *big.Float
+		want string
+		err  bool
+	}{
+		{in: big.NewFloat(0), want: "0"},
+		{in: big.NewFloat(0.5), want: "0.5"},
+		{in: big.NewFloat(-1e20), want: "-100000000000000000000"},
+		{in: big.NewFloat(math.Inf(1)), err: true},
+		{in: big.NewFloat(math.Inf(-1)), err: true},
+	} {
+		got, err := formatFloat(*tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("formatFloat(%v): expected an error, got %q", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("formatFloat(%v): %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("formatFloat(%v) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
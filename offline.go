@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrOffline is returned by the queries run by an offline dialect, which
+// records them instead.
+var ErrOffline = errors.New("offline postgres dialect can not run queries")
+
+// Statement is a statement built by the dialect: its final text, with
+// numbered bind variables, and the arguments bound to them.
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// String returns the statement with its arguments inlined, for review only.
+// See QueryEvent.Interpolated.
+func (st Statement) String() string {
+	return QueryEvent{Query: st.SQL, Args: st.Args}.Interpolated()
+}
+
+// OfflineDB is a connection that records every statement, queries included,
+// without a server. Unlike DryRunDB it needs no connection to read from:
+// queries are recorded and fail with ErrOffline, so operations that depend
+// on what the database contains can not be built offline.
+type OfflineDB struct {
+	recorder
+}
+
+// offlineDB builds *sql.Row values carrying ErrOffline.
+var offlineDB = &failingPool{err: ErrOffline}
+
+// recorder keeps the statements of OfflineDB and DryRunDB.
+type recorder struct {
+	mu         sync.Mutex
+	statements []Statement
+}
+
+func (r *recorder) record(query string, args []interface{}) {
+	r.mu.Lock()
+	r.statements = append(r.statements, Statement{SQL: strings.TrimSpace(query), Args: args})
+	r.mu.Unlock()
+}
+
+// Statements returns the statements recorded so far.
+func (r *recorder) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Statement(nil), r.statements...)
+}
+
+// Exec implements model.SQLCommon. It records query and reports no affected
+// rows.
+func (d *OfflineDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	d.record(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+// Prepare implements model.SQLCommon. It fails with ErrOffline.
+func (d *OfflineDB) Prepare(query string) (*sql.Stmt, error) {
+	return nil, ErrOffline
+}
+
+// Query implements model.SQLCommon. It records query and fails with
+// ErrOffline.
+func (d *OfflineDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	d.record(query, args)
+	return nil, ErrOffline
+}
+
+// QueryRow implements model.SQLCommon. It records query and returns a row
+// whose Scan fails with ErrOffline.
+func (d *OfflineDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	d.record(query, args)
+	return offlineDB.QueryRow(query, args...)
+}
+
+// Offline returns a copy of the dialect recording its statements in the
+// returned OfflineDB instead of running them, so the SQL of queries and DDL
+// can be unit tested, reviewed or handed to other tools. Generated SQL
+// targets the latest server unless Target is set.
+func (s Postgres) Offline() (Postgres, *OfflineDB) {
+	d := &OfflineDB{}
+	s.DB = d
+	if s.Target == nil {
+		caps := allCapabilities
+		s.Target = &caps
+	}
+	return s, d
+}
+
+// Record calls fn with an offline copy of the dialect and returns the
+// statements it built. The error of fn is returned along with them, as
+// operations reading from the database fail with ErrOffline after
+// recording their query:
+//
+//	stmts, err := s.Record(func(s postgres.Postgres) error {
+//		return s.Truncate(postgres.TruncateOptions{}, "users")
+//	})
+func (s Postgres) Record(fn func(s Postgres) error) ([]Statement, error) {
+	s, d := s.Offline()
+	err := fn(s)
+	return d.Statements(), err
+}
@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// OnlineLockTimeout bounds the time the schema changes of this file wait for
+// their ACCESS EXCLUSIVE lock. A statement queued behind a long running
+// transaction blocks every query on the table, so it is better to fail and
+// try again later.
+var OnlineLockTimeout = 5 * time.Second
+
+// alter runs the schema change query with lock_timeout set to
+// OnlineLockTimeout.
+func (s Postgres) alter(query string) error {
+	return s.withTx(func(db model.SQLCommon) error {
+		if OnlineLockTimeout > 0 {
+			if err := setLocal(db, "lock_timeout", durationSetting(OnlineLockTimeout)); err != nil {
+				return err
+			}
+		}
+		_, err := db.Exec(query)
+		return err
+	})
+}
+
+// AddConstraintNotValid adds the constraint name, such as
+// "CHECK (price >= 0)" or "FOREIGN KEY (user_id) REFERENCES users (id)", to
+// tableName without checking the existing rows, so the table is only locked
+// briefly. New rows are checked right away; ValidateConstraint checks the
+// others.
+func (s Postgres) AddConstraintNotValid(tableName, name, definition string) error {
+	return s.alter(fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v %v NOT VALID",
+		s.Quote(tableName), s.Quote(name), definition))
+}
+
+// ValidateConstraint checks the existing rows of tableName against the
+// constraint name added with AddConstraintNotValid. It only takes a SHARE
+// UPDATE EXCLUSIVE lock, so reads and writes go on during the scan.
+func (s Postgres) ValidateConstraint(tableName, name string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("ALTER TABLE %v VALIDATE CONSTRAINT %v",
+		s.Quote(tableName), s.Quote(name)))
+	return err
+}
+
+// AddConstraintOnline adds and validates a constraint in two steps, see
+// AddConstraintNotValid. The two statements must not run in the same
+// transaction, so the dialect's connection must not be one.
+func (s Postgres) AddConstraintOnline(tableName, name, definition string) error {
+	if _, ok := s.DB.(txBeginner); !ok {
+		return errors.New("online schema changes can not run inside a transaction")
+	}
+	if err := s.AddConstraintNotValid(tableName, name, definition); err != nil {
+		return err
+	}
+	return s.ValidateConstraint(tableName, name)
+}
+
+// SetNotNullOnline makes columnName of tableName NOT NULL without holding an
+// exclusive lock while the rows are checked: a NOT VALID check constraint is
+// added and validated first, which lets SET NOT NULL skip the scan on
+// PostgreSQL 12 and later, and is then dropped.
+func (s Postgres) SetNotNullOnline(tableName, columnName string) error {
+	name := fmt.Sprintf("%v_%v_not_null", tableName, columnName)
+	if err := s.AddConstraintOnline(tableName, name, fmt.Sprintf("CHECK (%v IS NOT NULL)", s.Quote(columnName))); err != nil {
+		return err
+	}
+	if err := s.alter(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET NOT NULL",
+		s.Quote(tableName), s.Quote(columnName))); err != nil {
+		return err
+	}
+	return s.alter(fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", s.Quote(tableName), s.Quote(name)))
+}
+
+// AddColumnOnline adds columnName of type sqlType to tableName with the
+// default value def without rewriting the table under an exclusive lock: the
+// column is added as nullable, def becomes its default for new rows, the
+// existing rows are backfilled in batches as described by b, and the column
+// is made NOT NULL when notNull is set. b.Table and b.Set are filled in.
+func (s Postgres) AddColumnOnline(ctx context.Context, tableName, columnName, sqlType string, def Expr, notNull bool, b Backfill) error {
+	if def.SQL == "" {
+		return errors.New("online column addition needs a default")
+	}
+	exists, err := s.ColumnExists(tableName, columnName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := s.alter(fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v",
+			s.Quote(tableName), s.Quote(columnName), sqlType)); err != nil {
+			return err
+		}
+	}
+	// The default is an expression of the DDL, where parameters can not be
+	// bound: its arguments are inlined as literals.
+	defSQL, err := inlineArgs(def.SQL, def.Args)
+	if err != nil {
+		return err
+	}
+	if err := s.alter(fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DEFAULT %v",
+		s.Quote(tableName), s.Quote(columnName), defSQL)); err != nil {
+		return err
+	}
+	b.Table = tableName
+	b.Set = []Assignment{{Column: columnName, Value: def}}
+	b.Where = And(Raw(s.Quote(columnName)+" IS NULL"), b.Where)
+	if _, err := s.Backfill(ctx, b); err != nil {
+		return err
+	}
+	if notNull {
+		return s.SetNotNullOnline(tableName, columnName)
+	}
+	return nil
+}
+
+// Backfill describes a batched update of a large table.
+type Backfill struct {
+	Table string
+
+	// Set lists the assignments made to every row.
+	Set []Assignment
+
+	// Where restricts the rows that are updated.
+	Where Expr
+
+	// Key is the unique column the table is walked in order of. Defaults to
+	// id.
+	Key string
+
+	// BatchSize is the number of rows updated per statement. Defaults to
+	// 1000.
+	BatchSize int
+
+	// Pause is waited between batches, giving replicas and autovacuum time
+	// to keep up.
+	Pause time.Duration
+
+	// Progress is called after every batch.
+	Progress func(p BackfillProgress)
+}
+
+// BackfillProgress reports the progress of a Backfill.
+type BackfillProgress struct {
+	Batches int
+	Rows    int64
+	Elapsed time.Duration
+}
+
+// Backfill runs the batched update b, each batch in a statement of its own
+// so locks are held briefly and the work done survives an interruption. It
+// returns the number of rows updated. The dialect's connection should not be
+// a transaction, which would hold the locks of every batch until its end.
+func (s Postgres) Backfill(ctx context.Context, b Backfill) (int64, error) {
+	if len(b.Set) == 0 {
+		return 0, errors.New("backfill has nothing to update")
+	}
+	key := b.Key
+	if key == "" {
+		key = "id"
+	}
+	size := b.BatchSize
+	if size <= 0 {
+		size = 1000
+	}
+	sets := make([]Expr, len(b.Set))
+	for i, a := range b.Set {
+		sets[i] = s.assignment(a)
+	}
+	set := Join(", ", sets...)
+	table, qkey := s.Quote(b.Table), s.Quote(key)
+
+	var progress BackfillProgress
+	start := time.Now()
+	var last sql.NullString
+	for {
+		where := b.Where
+		if last.Valid {
+			where = And(where, Raw(qkey+" > ?", last.String))
+		}
+		if where.SQL == "" {
+			where = Raw("true")
+		}
+		e := Expr{
+			SQL: fmt.Sprintf(`
+WITH batch AS (
+	SELECT %[2]v FROM %[1]v WHERE %[3]v ORDER BY %[2]v LIMIT %[4]d
+), updated AS (
+	UPDATE %[1]v SET %[5]v FROM batch WHERE %[1]v.%[2]v = batch.%[2]v RETURNING %[1]v.%[2]v
+)
+SELECT count(*), max(%[2]v)::text FROM updated`, table, qkey, where.SQL, size, set.SQL),
+			Args: append(append([]interface{}{}, where.Args...), set.Args...),
+		}
+		query, args := s.Build(e)
+		var n int64
+		if err := s.DB.QueryRow(query, args...).Scan(&n, &last); err != nil {
+			return progress.Rows, err
+		}
+		if n == 0 {
+			return progress.Rows, nil
+		}
+		progress.Batches++
+		progress.Rows += n
+		progress.Elapsed = time.Since(start)
+		if b.Progress != nil {
+			b.Progress(progress)
+		}
+		if b.Pause > 0 {
+			select {
+			case <-time.After(b.Pause):
+			case <-ctx.Done():
+				return progress.Rows, ctx.Err()
+			}
+		} else if err := ctx.Err(); err != nil {
+			return progress.Rows, err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"strings"
+)
+
+// Placement of NULL values in an ordering. By default Postgres sorts NULL
+// after every other value ascending, and before them descending.
+const (
+	NullsDefault = ""
+	NullsFirst   = "NULLS FIRST"
+	NullsLast    = "NULLS LAST"
+)
+
+// OrderTerm is a sort key of an ORDER BY clause.
+type OrderTerm struct {
+	Column string
+	Desc   bool
+
+	// Nulls is one of NullsDefault, NullsFirst or NullsLast.
+	Nulls string
+}
+
+// Asc returns an ascending sort on column.
+func Asc(column string) OrderTerm {
+	return OrderTerm{Column: column}
+}
+
+// Desc returns a descending sort on column.
+func Desc(column string) OrderTerm {
+	return OrderTerm{Column: column, Desc: true}
+}
+
+// NullsFirst returns t with NULL values sorted first.
+func (t OrderTerm) NullsFirst() OrderTerm {
+	t.Nulls = NullsFirst
+	return t
+}
+
+// NullsLast returns t with NULL values sorted last.
+func (t OrderTerm) NullsLast() OrderTerm {
+	t.Nulls = NullsLast
+	return t
+}
+
+// OrderBy returns the ORDER BY list for terms, for the query builder's Order:
+//
+//	db.Order(s.OrderBy(postgres.Desc("last_login").NullsLast(), postgres.Asc("id")))
+func (s Postgres) OrderBy(terms ...OrderTerm) string {
+	parts := make([]string, len(terms))
+	for i, t := range terms {
+		part := s.Quote(t.Column)
+		if t.Desc {
+			part += " DESC"
+		} else {
+			part += " ASC"
+		}
+		if t.Nulls != NullsDefault {
+			part += " " + t.Nulls
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ", ")
+}
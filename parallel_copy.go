@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParallelCopy describes a load split across several connections.
+type ParallelCopy struct {
+	Table   string
+	Columns []string
+
+	// Workers is the number of connections copying at once. Defaults to 4.
+	Workers int
+
+	// ChunkSize is the number of rows per COPY. Defaults to 10000.
+	ChunkSize int
+
+	// Partition, when set, returns the table receiving a row, such as the
+	// partition of Table it belongs to. Copying into partitions directly
+	// spares the server the routing and lets workers avoid each other.
+	Partition func(row []interface{}) string
+}
+
+// CopyStats summarizes a parallel load.
+type CopyStats struct {
+	Rows     int64
+	Chunks   int
+	Failed   int
+	Duration time.Duration
+}
+
+// RowsPerSecond returns the throughput of the load.
+func (st CopyStats) RowsPerSecond() float64 {
+	if st.Duration <= 0 {
+		return 0
+	}
+	return float64(st.Rows) / st.Duration.Seconds()
+}
+
+// ChunkError is the failure of a chunk of a parallel load. Chunks are
+// numbered from 0 in input order.
+type ChunkError struct {
+	Chunk int
+	Table string
+	Err   error
+}
+
+func (e ChunkError) Error() string {
+	return fmt.Sprintf("chunk %d into %s: %v", e.Chunk, e.Table, e.Err)
+}
+
+// CopyErrors lists the failed chunks of a parallel load in input order.
+type CopyErrors []ChunkError
+
+func (e CopyErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, c := range e {
+		msgs[i] = c.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type copyChunk struct {
+	index int
+	table string
+	rows  [][]interface{}
+}
+
+// ParallelCopy loads the rows received from rows, until it is closed, with
+// COPY on several connections at once. Every chunk is copied in its own
+// transaction: a failed chunk does not stop the others, and is reported in
+// the returned CopyErrors. The dialect's connection must be a pool.
+func (s Postgres) ParallelCopy(p ParallelCopy, rows <-chan []interface{}) (CopyStats, error) {
+	if _, ok := s.DB.(txBeginner); !ok {
+		return CopyStats{}, errors.New("parallel copy needs a connection pool")
+	}
+	workers, size := p.Workers, p.ChunkSize
+	if workers <= 0 {
+		workers = 4
+	}
+	if size <= 0 {
+		size = 10000
+	}
+	start := time.Now()
+	var (
+		mu    sync.Mutex
+		stats CopyStats
+		errs  CopyErrors
+		wg    sync.WaitGroup
+	)
+	work := make(chan copyChunk)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				n, err := s.CopyFrom(c.table, p.Columns, c.rows)
+				mu.Lock()
+				if err != nil {
+					stats.Failed++
+					errs = append(errs, ChunkError{Chunk: c.index, Table: c.table, Err: err})
+				} else {
+					stats.Rows += n
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	pending := map[string][][]interface{}{}
+	var order []string
+	send := func(table string) {
+		work <- copyChunk{index: stats.Chunks, table: table, rows: pending[table]}
+		stats.Chunks++
+		pending[table] = nil
+	}
+	for row := range rows {
+		table := p.Table
+		if p.Partition != nil {
+			table = p.Partition(row)
+		}
+		if _, ok := pending[table]; !ok {
+			order = append(order, table)
+		}
+		pending[table] = append(pending[table], row)
+		if len(pending[table]) >= size {
+			send(table)
+		}
+	}
+	for _, table := range order {
+		if len(pending[table]) > 0 {
+			send(table)
+		}
+	}
+	close(work)
+	wg.Wait()
+	stats.Duration = time.Since(start)
+	if len(errs) == 0 {
+		return stats, nil
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Chunk < errs[j].Chunk })
+	return stats, errs
+}
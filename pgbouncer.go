@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrPgBouncer is returned by session level features in PgBouncer mode.
+var ErrPgBouncer = errors.New("session level feature unavailable behind pgbouncer")
+
+// pgxPgBouncer makes pgx use unnamed statements only, as named prepared
+// statements do not survive the switch to another server session.
+func pgxPgBouncer(config *pgx.ConnConfig) error {
+	config.DefaultQueryExecMode = pgx.QueryExecModeExec
+	config.StatementCacheCapacity = 0
+	config.DescriptionCacheCapacity = 0
+	return nil
+}
@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Drivers a Connector can be built on.
+const (
+	// DriverPQ is lib/pq, the default.
+	DriverPQ = "postgres"
+
+	// DriverPgx is jackc/pgx through its database/sql adapter. Besides being
+	// faster, it lets the dialect use native protocol features such as
+	// COPY TO and COPY FROM.
+	DriverPgx = "pgx"
+)
+
+// NewPgxConnector returns a Connector using the pgx driver for the
+// connection string dsn, which may be a URL or key=value pairs.
+func NewPgxConnector(dsn string) (*Connector, error) {
+	return pgxConnector(dsn)
+}
+
+// pgxConnector returns a pgx Connector for dsn, with the parsed
+// configuration adjusted by opts.
+func pgxConnector(dsn string, opts ...func(*pgx.ConnConfig) error) (*Connector, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	c := &Connector{}
+	for _, opt := range append(opts, pgxNotices(c)) {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+	c.base = stdlib.GetConnector(*config)
+	return c, nil
+}
+
+// NewConnectorDriver returns a Connector for dsn using driverName, one of
+// DriverPQ or DriverPgx.
+func NewConnectorDriver(driverName, dsn string) (*Connector, error) {
+	switch driverName {
+	case DriverPQ, "":
+		return NewConnector(dsn)
+	case DriverPgx:
+		return NewPgxConnector(dsn)
+	}
+	return nil, fmt.Errorf("unknown postgres driver %q", driverName)
+}
+
+// Open returns a connection pool for dsn using driverName, one of DriverPQ
+// or DriverPgx. The pool is ready to be handed to the ORM with the postgres
+// dialect whichever driver is used, and is sized with DefaultPool. Open
+// waits for the server to accept connections as scheduled by
+// DefaultOpenBackoff.
+func Open(driverName, dsn string) (*sql.DB, error) {
+	c, err := NewConnectorDriver(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	db := c.DB()
+	DefaultPool.Apply(db)
+	if err := WaitReady(context.Background(), db, DefaultOpenBackoff); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// withPgx runs fn on the native pgx connection behind the dialect's
+// connection. It reports false without calling fn when the connection is not
+// backed by pgx.
+func (s Postgres) withPgx(ctx context.Context, fn func(conn *pgx.Conn) error) (bool, error) {
+	var conn *sql.Conn
+	switch db := s.DB.(type) {
+	case pinnedConn:
+		conn = db.conn
+	case conner:
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return false, err
+		}
+		defer c.Close()
+		conn = c
+	default:
+		return false, nil
+	}
+	ok := false
+	err := conn.Raw(func(dc interface{}) error {
+		c, isPgx := dc.(*stdlib.Conn)
+		if !isPgx {
+			return nil
+		}
+		ok = true
+		return fn(c.Conn())
+	})
+	return ok, err
+}
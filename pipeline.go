@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/ngorm/ngorm/model"
+)
+
+// Batch queues statements to be sent together by SendBatch.
+type Batch struct {
+	items []*BatchResult
+}
+
+// BatchResult is the outcome of a statement of a Batch, set by SendBatch.
+type BatchResult struct {
+	query string
+	args  []interface{}
+	dest  interface{}
+
+	// RowsAffected is the number of rows affected by a statement queued with
+	// Exec.
+	RowsAffected int64
+}
+
+// Exec queues the statement query.
+func (b *Batch) Exec(query string, args ...interface{}) *BatchResult {
+	r := &BatchResult{query: query, args: args}
+	b.items = append(b.items, r)
+	return r
+}
+
+// Query queues query, whose rows are scanned into dest like ScanRows. A
+// struct dest is left untouched when there are no rows.
+func (b *Batch) Query(dest interface{}, query string, args ...interface{}) *BatchResult {
+	r := &BatchResult{query: query, args: args, dest: dest}
+	b.items = append(b.items, r)
+	return r
+}
+
+// Len returns the number of queued statements.
+func (b *Batch) Len() int {
+	return len(b.items)
+}
+
+// SendBatch runs the statements of b as a single transaction: either all of
+// them succeed or none takes effect, and the first error is returned. On
+// connections using the pgx driver they are pipelined in one network round
+// trip, which saves the latency of all but one statement; otherwise they run
+// one after the other.
+func (s Postgres) SendBatch(ctx context.Context, b *Batch) error {
+	if len(b.items) == 0 {
+		return nil
+	}
+	ok, err := s.withPgx(ctx, func(conn *pgx.Conn) error {
+		pb := &pgx.Batch{}
+		for _, r := range b.items {
+			pb.Queue(r.query, r.args...)
+		}
+		results := conn.SendBatch(ctx, pb)
+		for _, r := range b.items {
+			if err := r.readPgx(results); err != nil {
+				results.Close()
+				return err
+			}
+		}
+		return results.Close()
+	})
+	if ok || err != nil {
+		return err
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		for _, r := range b.items {
+			if err := r.run(db); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *BatchResult) readPgx(results pgx.BatchResults) error {
+	if r.dest == nil {
+		tag, err := results.Exec()
+		r.RowsAffected = tag.RowsAffected()
+		return err
+	}
+	rows, err := results.Query()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	fields := rows.FieldDescriptions()
+	cols := make([]string, len(fields))
+	for i, f := range fields {
+		cols[i] = f.Name
+	}
+	if err := scanInto(rows, cols, r.dest); err != nil && !IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *BatchResult) run(db model.SQLCommon) error {
+	if r.dest == nil {
+		res, err := db.Exec(r.query, r.args...)
+		if err != nil {
+			return err
+		}
+		r.RowsAffected, err = res.RowsAffected()
+		return err
+	}
+	rows, err := db.Query(r.query, r.args...)
+	if err != nil {
+		return err
+	}
+	if err := ScanRows(rows, r.dest); err != nil && !IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,467 @@
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// Kinds of schema changes produced by PlanMigration.
+const (
+	CreateTable     = "create_table"
+	AddColumn       = "add_column"
+	BackfillColumn  = "backfill_column"
+	AlterColumnType = "alter_column_type"
+	AlterStorage    = "alter_storage"
+	DropIndex       = "drop_index"
+	CreateIndex     = "create_index"
+)
+
+// Table is the desired definition of a model's table, as derived from its
+// struct fields.
+type Table struct {
+	Name   string
+	Fields []*model.StructField
+
+	// ForeignServer declares the table as a foreign table on that server,
+	// for models implementing ForeignTableModel. Foreign tables are created
+	// with CREATE FOREIGN TABLE and are otherwise left alone by migrations.
+	ForeignServer string
+}
+
+// Change is a single DDL statement of a migration plan.
+type Change struct {
+	Kind   string
+	Table  string
+	Object string
+	SQL    string
+
+	// column is the column added by a BackfillColumn change.
+	column *backfillColumn
+}
+
+// backfillColumn is a column added with AddColumnOnline.
+type backfillColumn struct {
+	sqlType string
+	def     string
+	notNull bool
+}
+
+// Plan is an ordered list of changes that brings the database in line with a
+// set of model tables.
+type Plan []Change
+
+// SQL returns the plan as a script with one statement per line.
+func (p Plan) SQL() string {
+	var buf strings.Builder
+	for _, c := range p {
+		buf.WriteString(c.SQL)
+		buf.WriteString(";\n")
+	}
+	return buf.String()
+}
+
+// PlanMigration compares tables against the live database and returns the
+// changes needed to create missing tables, columns and indexes, and to change
+// the type of columns whose declared type has drifted. Nothing is executed;
+// use ApplyPlan after reviewing the result.
+//
+// Before PostgreSQL 11, adding a column with a default rewrote the whole
+// table under an exclusive lock. On such servers these columns are planned
+// as BackfillColumn changes, applied with AddColumnOnline.
+//
+// Tables are created first, followed by column additions, type and storage
+// changes and finally indexes, so that every statement only depends on
+// earlier ones. The STORAGE tag setting of new columns is applied with
+// ALTER COLUMN ... SET STORAGE, which every server version supports.
+//
+// Unique indexes of tables with a SoftDeleteColumn are partial, see
+// modelIndexes. Existing unique indexes of the same name covering every row
+// are dropped and created again, which leaves the columns without a unique
+// index until the new one is built.
+func (s Postgres) PlanMigration(tables ...Table) (Plan, error) {
+	var creates, adds, alters, indexes Plan
+	inspector := s.Inspector()
+	for _, t := range tables {
+		if t.ForeignServer != "" {
+			ok, err := s.ForeignTableExists(t.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				c, err := s.createTableChange(t)
+				if err != nil {
+					return nil, err
+				}
+				creates = append(creates, c)
+			}
+			continue
+		}
+		exists, err := s.TableExists(t.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			c, err := s.createTableChange(t)
+			if err != nil {
+				return nil, err
+			}
+			creates = append(creates, c)
+			for _, field := range columnFields(t.Fields) {
+				if alters, err = s.appendStorageChange(alters, t.Name, field); err != nil {
+					return nil, err
+				}
+			}
+			for _, idx := range modelIndexes(t) {
+				c, err := s.createIndexChange(t.Name, idx)
+				if err != nil {
+					return nil, err
+				}
+				indexes = append(indexes, c)
+			}
+			continue
+		}
+		live, err := inspector.Table(t.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, field := range columnFields(t.Fields) {
+			col := live.Column(field.DBName)
+			if col == nil {
+				if alters, err = s.appendStorageChange(alters, t.Name, field); err != nil {
+					return nil, err
+				}
+				if c, ok, err := s.backfillChange(t.Name, field); err != nil {
+					return nil, err
+				} else if ok {
+					adds = append(adds, c)
+					continue
+				}
+				def, err := s.DataTypeOf(field)
+				if err != nil {
+					return nil, err
+				}
+				adds = append(adds, Change{
+					Kind:   AddColumn,
+					Table:  t.Name,
+					Object: field.DBName,
+					SQL: fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v",
+						s.Quote(t.Name), s.Quote(field.DBName), def),
+				})
+				continue
+			}
+			sqlType, _, err := s.sqlTypeOf(field)
+			if err != nil {
+				return nil, err
+			}
+			if !sameType(sqlType, col.DataType) {
+				alters = append(alters, Change{
+					Kind:   AlterColumnType,
+					Table:  t.Name,
+					Object: field.DBName,
+					SQL: fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v TYPE %v",
+						s.Quote(t.Name), s.Quote(field.DBName), alterableType(sqlType)),
+				})
+			}
+		}
+		for _, idx := range modelIndexes(t) {
+			if old := live.Index(idx.name); old != nil {
+				if !idx.unique || idx.where == "" || old.Where != "" {
+					continue
+				}
+				// A unique index created before the table had soft
+				// deletes covers deleted rows: it is rebuilt partial.
+				indexes = append(indexes, Change{
+					Kind:   DropIndex,
+					Table:  t.Name,
+					Object: idx.name,
+					SQL:    fmt.Sprintf("DROP INDEX %v", s.Quote(idx.name)),
+				})
+			}
+			c, err := s.createIndexChange(t.Name, idx)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, c)
+		}
+	}
+	plan := append(creates, adds...)
+	plan = append(plan, alters...)
+	return append(plan, indexes...), nil
+}
+
+// appendStorageChange appends to plan the AlterStorage change applying the
+// STORAGE tag setting of field, if any.
+func (s Postgres) appendStorageChange(plan Plan, tableName string, field *model.StructField) (Plan, error) {
+	query, err := s.ColumnStorageSQL(tableName, field)
+	if err != nil || query == "" {
+		return plan, err
+	}
+	return append(plan, Change{
+		Kind:   AlterStorage,
+		Table:  tableName,
+		Object: field.DBName,
+		SQL:    query,
+	}), nil
+}
+
+// ApplyPlan executes the statements of plan in order, stopping at the first
+// error. See ApplyPlanWith to build indexes concurrently.
+func (s Postgres) ApplyPlan(plan Plan) error {
+	return s.ApplyPlanWith(plan, ApplyOptions{})
+}
+
+func (s Postgres) createTableChange(t Table) (Change, error) {
+	if t.ForeignServer != "" {
+		ft, err := s.foreignTableOf(t, t.ForeignServer)
+		if err != nil {
+			return Change{}, err
+		}
+		query, err := s.CreateForeignTableSQL(ft)
+		if err != nil {
+			return Change{}, err
+		}
+		return Change{Kind: CreateTable, Table: t.Name, Object: t.Name, SQL: query}, nil
+	}
+	var defs, keys []string
+	for _, field := range columnFields(t.Fields) {
+		def, err := s.DataTypeOf(field)
+		if err != nil {
+			return Change{}, err
+		}
+		defs = append(defs, fmt.Sprintf("%v %v", s.Quote(field.DBName), def))
+		if field.IsPrimaryKey {
+			keys = append(keys, s.Quote(field.DBName))
+		}
+	}
+	if len(keys) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%v)", strings.Join(keys, ",")))
+	}
+	return Change{
+		Kind:   CreateTable,
+		Table:  t.Name,
+		Object: t.Name,
+		SQL: fmt.Sprintf("CREATE TABLE %v (%v)",
+			s.Quote(t.Name), strings.Join(defs, ",")),
+	}, nil
+}
+
+// backfillChange returns the BackfillColumn change adding field to
+// tableName, when the server would rewrite the table to add it.
+func (s Postgres) backfillChange(tableName string, field *model.StructField) (Change, bool, error) {
+	def, ok := field.TagSettings["DEFAULT"]
+	if !ok || s.caps().FastColumnDefaults {
+		return Change{}, false, nil
+	}
+	if _, unique := field.TagSettings["UNIQUE"]; unique {
+		return Change{}, false, nil
+	}
+	sqlType, additionalType, err := s.sqlTypeOf(field)
+	if err != nil {
+		return Change{}, false, err
+	}
+	notNull := !declaredNullable(field, additionalType)
+	// The statements are listed for review; ApplyPlan runs the backfill
+	// between them in batches.
+	stmts := []string{
+		fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v", s.Quote(tableName), s.Quote(field.DBName), sqlType),
+		fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DEFAULT %v", s.Quote(tableName), s.Quote(field.DBName), def),
+		fmt.Sprintf("UPDATE %v SET %v = DEFAULT WHERE %v IS NULL", s.Quote(tableName), s.Quote(field.DBName), s.Quote(field.DBName)),
+	}
+	if notNull {
+		stmts = append(stmts, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET NOT NULL", s.Quote(tableName), s.Quote(field.DBName)))
+	}
+	return Change{
+		Kind:   BackfillColumn,
+		Table:  tableName,
+		Object: field.DBName,
+		SQL:    strings.Join(stmts, ";\n"),
+		column: &backfillColumn{sqlType: sqlType, def: def, notNull: notNull},
+	}, true, nil
+}
+
+func (s Postgres) createIndexChange(tableName string, idx indexDef) (Change, error) {
+	kind := "INDEX"
+	if idx.unique {
+		kind = "UNIQUE INDEX"
+	}
+	var nulls string
+	if idx.nullsNotDistinct {
+		if !s.caps().NullsNotDistinct {
+			return Change{}, fmt.Errorf("index %s: NULLS NOT DISTINCT requires PostgreSQL 15", idx.name)
+		}
+		nulls = " NULLS NOT DISTINCT"
+	}
+	var using string
+	if idx.method != "" {
+		using = " USING " + idx.method
+	}
+	cols := make([]string, len(idx.columns))
+	for i, c := range idx.columns {
+		cols[i] = s.Quote(c)
+		if idx.opclass != "" {
+			cols[i] += " " + idx.opclass
+		}
+	}
+	var where string
+	if idx.where != "" {
+		where = " WHERE " + idx.where
+	}
+	return Change{
+		Kind:   CreateIndex,
+		Table:  tableName,
+		Object: idx.name,
+		SQL: fmt.Sprintf("CREATE %v %v ON %v%v(%v)%v%v", kind, s.Quote(idx.name),
+			s.Quote(tableName), using, strings.Join(cols, ", "), nulls, where),
+	}, nil
+}
+
+// columnFields returns the fields of a model that are stored as columns.
+func columnFields(fields []*model.StructField) []*model.StructField {
+	var cols []*model.StructField
+	for _, field := range fields {
+		if field.IsNormal && !field.IsIgnored {
+			cols = append(cols, field)
+		}
+	}
+	return cols
+}
+
+type indexDef struct {
+	name    string
+	columns []string
+	unique  bool
+
+	// method and opclass are set for indexes using an access method other
+	// than btree, such as trigram indexes.
+	method  string
+	opclass string
+
+	// where makes the index partial.
+	where string
+
+	// nullsNotDistinct makes a unique index treat NULLs as equal.
+	nullsNotDistinct bool
+}
+
+// SoftDeleteColumn is the column marking soft deleted rows, set by models
+// embedding a DeletedAt field.
+const SoftDeleteColumn = "deleted_at"
+
+// modelIndexes collects the INDEX, UNIQUE_INDEX and TRGM_INDEX tag settings
+// of t. Fields sharing an index name make up a composite index.
+//
+// When t has a SoftDeleteColumn, unique indexes only cover the rows that are
+// not deleted, so a deleted row does not block reusing its values. A
+// NULLS_NOT_DISTINCT setting on one of its fields makes a unique index refuse
+// duplicate NULLs, which needs PostgreSQL 15.
+func modelIndexes(t Table) []indexDef {
+	var defs []indexDef
+	var where string
+	seen := make(map[string]int)
+	add := func(name string, unique bool, column string) {
+		if i, ok := seen[name]; ok {
+			defs[i].columns = append(defs[i].columns, column)
+			return
+		}
+		seen[name] = len(defs)
+		defs = append(defs, indexDef{name: name, columns: []string{column}, unique: unique})
+	}
+	fields := columnFields(t.Fields)
+	for _, field := range fields {
+		if field.DBName == SoftDeleteColumn {
+			where = Postgres{}.Quote(SoftDeleteColumn) + " IS NULL"
+		}
+	}
+	for _, field := range fields {
+		if name, ok := field.TagSettings["INDEX"]; ok {
+			for _, n := range strings.Split(name, ",") {
+				if n == "" || n == "INDEX" {
+					n = fmt.Sprintf("idx_%v_%v", t.Name, field.DBName)
+				}
+				add(n, false, field.DBName)
+			}
+		}
+		if name, ok := field.TagSettings["UNIQUE_INDEX"]; ok {
+			for _, n := range strings.Split(name, ",") {
+				if n == "" || n == "UNIQUE_INDEX" {
+					n = fmt.Sprintf("uix_%v_%v", t.Name, field.DBName)
+				}
+				add(n, true, field.DBName)
+				if _, ok := field.TagSettings["NULLS_NOT_DISTINCT"]; ok {
+					defs[seen[n]].nullsNotDistinct = true
+				}
+			}
+		}
+		if name, ok := field.TagSettings["TRGM_INDEX"]; ok {
+			if name == "" || name == "TRGM_INDEX" {
+				name = fmt.Sprintf("trgm_%v_%v", t.Name, field.DBName)
+			}
+			defs = append(defs, indexDef{
+				name:    name,
+				columns: []string{field.DBName},
+				method:  "gin",
+				opclass: "gin_trgm_ops",
+			})
+		}
+	}
+	for i := range defs {
+		if defs[i].unique {
+			defs[i].where = where
+		}
+	}
+	return defs
+}
+
+var typeModifier = regexp.MustCompile(`\s*\(.*\)`)
+
+var typeAliases = map[string]string{
+	"serial":      "integer",
+	"serial4":     "integer",
+	"bigserial":   "bigint",
+	"serial8":     "bigint",
+	"smallserial": "smallint",
+	"serial2":     "smallint",
+	"int":         "integer",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"int2":        "smallint",
+	"bool":        "boolean",
+	"float8":      "double precision",
+	"float4":      "real",
+	"decimal":     "numeric",
+	"varchar":     "character varying",
+	"char":        "character",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+}
+
+// normalizeType maps a declared type to the spelling used by format_type so
+// that the two can be compared.
+func normalizeType(typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	mod := typeModifier.FindString(typ)
+	base := strings.TrimSpace(typeModifier.ReplaceAllString(typ, ""))
+	if alias, ok := typeAliases[base]; ok {
+		base = alias
+	}
+	return base + strings.Replace(mod, " ", "", -1)
+}
+
+func sameType(declared, live string) bool {
+	return normalizeType(declared) == normalizeType(live)
+}
+
+// alterableType returns the type to use in ALTER COLUMN ... TYPE, where the
+// serial pseudo types are not allowed.
+func alterableType(typ string) string {
+	switch strings.ToLower(typ) {
+	case "serial", "serial4", "serial8", "bigserial", "smallserial", "serial2":
+		return normalizeType(typ)
+	}
+	return typ
+}
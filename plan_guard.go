@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PlanGuard catches query plan regressions in tests, such as a dropped
+// index turning an index scan into a sequential scan. The plans of named
+// queries are compared with a baseline kept in a JSON file next to the tests
+// and committed along with them.
+type PlanGuard struct {
+	// File holds the baseline, e.g. testdata/plans.json.
+	File string
+
+	// CostFactor is the growth of the estimated cost over the baseline
+	// that fails a check. Defaults to 2; negative disables the check.
+	CostFactor float64
+
+	// Update records the current plans as the baseline instead of
+	// checking them, e.g. when set from a -update test flag. Queries
+	// missing from the baseline are always recorded.
+	Update bool
+
+	s        Postgres
+	mu       sync.Mutex
+	baseline map[string]PlanSummary
+}
+
+// PlanSummary is the part of a plan that is compared with the baseline.
+type PlanSummary struct {
+	Cost float64 `json:"cost"`
+
+	// Scans maps the tables read by the plan to their scan node types,
+	// e.g. "Index Scan".
+	Scans map[string]string `json:"scans"`
+}
+
+// NewPlanGuard returns a PlanGuard checking plans obtained on the dialect's
+// connection against the baseline in file.
+func (s Postgres) NewPlanGuard(file string) *PlanGuard {
+	return &PlanGuard{File: file, s: s}
+}
+
+// summarize returns the summary of plan.
+func summarize(plan *QueryPlan) PlanSummary {
+	sum := PlanSummary{Cost: plan.TotalCost(), Scans: make(map[string]string)}
+	plan.Plan.Walk(func(n *PlanNode) {
+		if n.RelationName == "" || !strings.HasSuffix(n.NodeType, "Scan") {
+			return
+		}
+		// A table read in several ways keeps its worst scan.
+		if prev, ok := sum.Scans[n.RelationName]; !ok || prev != "Seq Scan" {
+			sum.Scans[n.RelationName] = n.NodeType
+		}
+	})
+	return sum
+}
+
+// Check explains query and fails the test when its plan regressed from the
+// baseline recorded under name: a table read with an index is now read
+// sequentially, or the estimated cost grew beyond CostFactor. Estimates
+// depend on the data and its statistics, so tests should load a
+// representative data set and run ANALYZE first.
+func (g *PlanGuard) Check(t TB, name string, query Expr) {
+	t.Helper()
+	plan, err := g.s.Explain(query, ExplainOptions{})
+	if err != nil {
+		t.Fatalf("explain %s: %v", name, err)
+	}
+	if problems, err := g.check(name, summarize(plan)); err != nil {
+		t.Fatalf("plan baseline %s: %v", g.File, err)
+	} else if len(problems) > 0 {
+		t.Fatalf("plan of %s regressed:\n\t%s", name, strings.Join(problems, "\n\t"))
+	}
+}
+
+func (g *PlanGuard) check(name string, sum PlanSummary) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+	base, ok := g.baseline[name]
+	if !ok || g.Update {
+		g.baseline[name] = sum
+		return nil, g.save()
+	}
+	var problems []string
+	var tables []string
+	for table := range base.Scans {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		if now := sum.Scans[table]; now == "Seq Scan" && base.Scans[table] != "Seq Scan" {
+			problems = append(problems, fmt.Sprintf("%s is read with a Seq Scan instead of a %s", table, base.Scans[table]))
+		}
+	}
+	factor := g.CostFactor
+	if factor == 0 {
+		factor = 2
+	}
+	if factor > 0 && base.Cost > 0 && sum.Cost > base.Cost*factor {
+		problems = append(problems, fmt.Sprintf("estimated cost grew from %.2f to %.2f", base.Cost, sum.Cost))
+	}
+	return problems, nil
+}
+
+func (g *PlanGuard) load() error {
+	if g.baseline != nil {
+		return nil
+	}
+	g.baseline = make(map[string]PlanSummary)
+	data, err := ioutil.ReadFile(g.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &g.baseline)
+}
+
+func (g *PlanGuard) save() error {
+	data, err := json.MarshalIndent(g.baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(g.File, append(data, '\n'), 0644)
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Policy describes a row level security policy.
+type Policy struct {
+	Name string
+
+	// Command is the command the policy applies to: ALL, SELECT, INSERT,
+	// UPDATE or DELETE. Defaults to ALL.
+	Command string
+
+	// Restrictive creates an AS RESTRICTIVE policy, which must pass in
+	// addition to the permissive ones.
+	Restrictive bool
+
+	// Roles the policy applies to. Defaults to PUBLIC.
+	Roles []string
+
+	// Using is the expression existing rows are checked against.
+	Using string
+
+	// WithCheck is the expression new rows are checked against.
+	WithCheck string
+}
+
+// EnableRLS enables row level security on tableName. When force is true the
+// policies also apply to the table owner.
+func (s Postgres) EnableRLS(tableName string, force bool) error {
+	_, err := s.DB.Exec(fmt.Sprintf("ALTER TABLE %v ENABLE ROW LEVEL SECURITY", s.Quote(tableName)))
+	if err != nil || !force {
+		return err
+	}
+	_, err = s.DB.Exec(fmt.Sprintf("ALTER TABLE %v FORCE ROW LEVEL SECURITY", s.Quote(tableName)))
+	return err
+}
+
+// DisableRLS disables row level security on tableName.
+func (s Postgres) DisableRLS(tableName string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("ALTER TABLE %v DISABLE ROW LEVEL SECURITY", s.Quote(tableName)))
+	return err
+}
+
+// CreatePolicySQL returns the CREATE POLICY statement for p on tableName.
+func (s Postgres) CreatePolicySQL(tableName string, p Policy) (string, error) {
+	if p.Name == "" {
+		return "", errors.New("policy name is required")
+	}
+	if p.Using == "" && p.WithCheck == "" {
+		return "", fmt.Errorf("policy %s needs a USING or WITH CHECK expression", p.Name)
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "CREATE POLICY %v ON %v", s.Quote(p.Name), s.Quote(tableName))
+	if p.Restrictive {
+		buf.WriteString(" AS RESTRICTIVE")
+	}
+	if p.Command != "" {
+		cmd := strings.ToUpper(p.Command)
+		switch cmd {
+		case "ALL", "SELECT", "INSERT", "UPDATE", "DELETE":
+		default:
+			return "", fmt.Errorf("invalid policy command %s", p.Command)
+		}
+		fmt.Fprintf(&buf, " FOR %v", cmd)
+	}
+	if len(p.Roles) > 0 {
+		roles := make([]string, len(p.Roles))
+		for i, r := range p.Roles {
+			roles[i] = s.quoteRole(r)
+		}
+		fmt.Fprintf(&buf, " TO %v", strings.Join(roles, ", "))
+	}
+	if p.Using != "" {
+		fmt.Fprintf(&buf, " USING (%v)", p.Using)
+	}
+	if p.WithCheck != "" {
+		fmt.Fprintf(&buf, " WITH CHECK (%v)", p.WithCheck)
+	}
+	return buf.String(), nil
+}
+
+// CreatePolicy creates the policy p on tableName.
+func (s Postgres) CreatePolicy(tableName string, p Policy) error {
+	query, err := s.CreatePolicySQL(tableName, p)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
+
+// DropPolicy removes the policy policyName from tableName if it exists.
+func (s Postgres) DropPolicy(tableName, policyName string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("DROP POLICY IF EXISTS %v ON %v",
+		s.Quote(policyName), s.Quote(tableName)))
+	return err
+}
+
+// HasPolicy reports whether tableName has a policy named policyName.
+func (s Postgres) HasPolicy(tableName string, policyName string) bool {
+	ok, _ := s.PolicyExists(tableName, policyName)
+	return ok
+}
+
+// PolicyExists is like HasPolicy but reports the errors of the lookup.
+func (s Postgres) PolicyExists(tableName string, policyName string) (bool, error) {
+	query := `
+SELECT Count(*)
+FROM   pg_policies
+WHERE  schemaname = current_schema()
+       AND tablename = $1
+       AND policyname = $2
+	`
+	return s.exists(query, tableName, policyName)
+}
+
+// quoteRole quotes a role name, leaving the PUBLIC pseudo role and the
+// CURRENT_USER/SESSION_USER keywords untouched.
+func (s Postgres) quoteRole(role string) string {
+	switch strings.ToUpper(role) {
+	case "PUBLIC", "CURRENT_USER", "SESSION_USER", "CURRENT_ROLE":
+		return strings.ToUpper(role)
+	}
+	return s.Quote(role)
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PoolConfig sizes a connection pool. Zero fields take the value of
+// DefaultPool.
+type PoolConfig struct {
+	// MaxOpenConns bounds the connections opened to the server. Every
+	// connection is a backend process, so it should stay well below the
+	// server's max_connections divided by the number of clients.
+	MaxOpenConns int
+
+	// MaxIdleConns bounds the connections kept open while idle.
+	MaxIdleConns int
+
+	// ConnMaxLifetime closes connections after this long, so they are
+	// rebalanced after a failover and backend memory does not grow forever.
+	ConnMaxLifetime time.Duration
+
+	// ConnMaxIdleTime closes connections that have been idle this long.
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPool is the pool configuration used by Open. database/sql defaults
+// to an unbounded pool whose connections live forever, which can exhaust
+// max_connections under load.
+var DefaultPool = PoolConfig{
+	MaxOpenConns:    20,
+	MaxIdleConns:    10,
+	ConnMaxLifetime: 30 * time.Minute,
+	ConnMaxIdleTime: 5 * time.Minute,
+}
+
+// Apply configures db with p.
+func (p PoolConfig) Apply(db *sql.DB) {
+	if p.MaxOpenConns == 0 {
+		p.MaxOpenConns = DefaultPool.MaxOpenConns
+	}
+	if p.MaxIdleConns == 0 {
+		p.MaxIdleConns = DefaultPool.MaxIdleConns
+	}
+	if p.ConnMaxLifetime == 0 {
+		p.ConnMaxLifetime = DefaultPool.ConnMaxLifetime
+	}
+	if p.ConnMaxIdleTime == 0 {
+		p.ConnMaxIdleTime = DefaultPool.ConnMaxIdleTime
+	}
+	if p.MaxIdleConns > p.MaxOpenConns {
+		p.MaxIdleConns = p.MaxOpenConns
+	}
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// applySet is like Apply but only changes the settings of db that p sets.
+func (p PoolConfig) applySet(db *sql.DB) {
+	if p.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(p.MaxOpenConns)
+	}
+	if p.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	if p.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	}
+	if p.ConnMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+	}
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// PreparedStatement is a statement prepared on the server, giving the caller
+// control over its lifetime, e.g. to prepare the statements of a latency
+// critical path once at startup. Unlike the statements of database/sql it is
+// not re-prepared behind the scenes: it stays on the connection it was
+// prepared on. Arguments are bound with the extended protocol, never written
+// into the statement text.
+type PreparedStatement struct {
+	// Name identifies the statement to the caller. The server side name is
+	// chosen by the driver.
+	Name string
+
+	// SQL is the prepared statement, with numbered bind variables.
+	SQL string
+
+	stmt    *sql.Stmt
+	release func()
+}
+
+// PrepareNamed prepares the statement e, built as by Build, under name. Only
+// the placeholders of e are used; their values are passed to Exec and Query.
+// Prepared statements belong to a server session, so on a pool the statement
+// holds a dedicated connection until Deallocate is called.
+func (s Postgres) PrepareNamed(ctx context.Context, name string, e Expr) (*PreparedStatement, error) {
+	if s.PgBouncer {
+		return nil, ErrPgBouncer
+	}
+	query, _ := s.Build(e)
+	db, release, err := s.pin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &PreparedStatement{Name: name, SQL: query, stmt: stmt, release: release}, nil
+}
+
+func (p *PreparedStatement) check() error {
+	if p.release == nil {
+		return fmt.Errorf("prepared statement %s has been deallocated", p.Name)
+	}
+	return nil
+}
+
+// Exec runs the statement with args.
+func (p *PreparedStatement) Exec(args ...interface{}) (sql.Result, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return p.stmt.Exec(args...)
+}
+
+// Query runs the statement with args and returns its rows.
+func (p *PreparedStatement) Query(args ...interface{}) (*sql.Rows, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return p.stmt.Query(args...)
+}
+
+// QueryInto runs the statement with args and scans its rows into dest as
+// ScanRows does.
+func (p *PreparedStatement) QueryInto(dest interface{}, args ...interface{}) error {
+	rows, err := p.Query(args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scanInto(rows, cols, dest)
+}
+
+// Deallocate removes the statement from the server and releases its
+// connection.
+func (p *PreparedStatement) Deallocate() error {
+	if p.release == nil {
+		return nil
+	}
+	defer func() {
+		p.release()
+		p.release = nil
+	}()
+	return p.stmt.Close()
+}
+
+// PreparedStatements returns the names of the statements prepared in the
+// session behind the dialect's connection, including those of database/sql.
+func (s Postgres) PreparedStatements() ([]string, error) {
+	rows, err := s.DB.Query("SELECT name FROM pg_prepared_statements ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
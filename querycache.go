@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CacheChannel is the NOTIFY channel on which the cache invalidation
+// triggers announce modified tables.
+const CacheChannel = "ngorm_cache_invalidate"
+
+// CacheInvalidateFunction is the name of the trigger function notifying
+// CacheChannel.
+const CacheInvalidateFunction = "notify_cache_invalidate"
+
+const cacheInvalidateFunctionSQL = `
+CREATE OR REPLACE FUNCTION notify_cache_invalidate() RETURNS trigger AS $$
+BEGIN
+	PERFORM pg_notify('` + CacheChannel + `', TG_TABLE_NAME);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql
+`
+
+// CacheInvalidateTrigger returns the statement level trigger announcing
+// every modification of tableName on CacheChannel.
+func CacheInvalidateTrigger(tableName string) Trigger {
+	return Trigger{
+		Name:     tableName + "_notify_cache_invalidate",
+		Timing:   After,
+		Events:   []string{OnInsert, OnUpdate, OnDelete, OnTruncate},
+		Function: CacheInvalidateFunction,
+	}
+}
+
+// EnsureCacheInvalidation installs the notify_cache_invalidate() function
+// and attaches the CacheInvalidateTrigger to tableName.
+func (s Postgres) EnsureCacheInvalidation(tableName string) error {
+	if _, err := s.DB.Exec(cacheInvalidateFunctionSQL); err != nil {
+		return err
+	}
+	t := CacheInvalidateTrigger(tableName)
+	ok, err := s.TriggerExists(tableName, t.Name)
+	if err != nil || ok {
+		return err
+	}
+	return s.CreateTrigger(tableName, t)
+}
+
+// QueryCache keeps the results of queries tagged with the tables they read,
+// and drops them as soon as one of those tables is modified, as announced by
+// the triggers installed with EnsureCacheInvalidation. It suits small, mostly
+// static tables such as settings or reference data.
+//
+// Modifications made between a query and the arrival of the notification
+// may be missed by a concurrent read, and all entries are dropped when the
+// listening connection is re-established.
+type QueryCache struct {
+	n *Notifications
+
+	mu      sync.Mutex
+	entries map[string]reflect.Value
+	tables  map[string]map[string]bool
+	done    chan struct{}
+
+	// gens counts the invalidations of every table and epoch the flushes,
+	// so results read while their tables were invalidated are not stored.
+	gens  map[string]uint64
+	epoch uint64
+}
+
+// NewQueryCache returns a QueryCache listening for invalidations on a
+// dedicated connection to dsn.
+func NewQueryCache(dsn string, config ListenerConfig) (*QueryCache, error) {
+	c := &QueryCache{
+		entries: map[string]reflect.Value{},
+		tables:  map[string]map[string]bool{},
+		done:    make(chan struct{}),
+		gens:    map[string]uint64{},
+	}
+	onReconnect := config.OnReconnect
+	config.OnReconnect = func() {
+		c.Flush()
+		if onReconnect != nil {
+			onReconnect()
+		}
+	}
+	n, err := Listen(dsn, config, CacheChannel)
+	if err != nil {
+		return nil, err
+	}
+	c.n = n
+	go c.run()
+	return c, nil
+}
+
+func (c *QueryCache) run() {
+	defer close(c.done)
+	for msg := range c.n.C {
+		c.Invalidate(msg.Payload)
+	}
+}
+
+// Query scans the result of query into dest like ScanRows, serving it from
+// the cache when possible. tables lists the tables query reads; each of
+// them must have the cache invalidation trigger. dest receives a copy of the
+// cached value, so cached slices are never shared with callers, but the
+// values they point to are.
+func (c *QueryCache) Query(s Postgres, tables []string, dest interface{}, query string, args ...interface{}) error {
+	if len(tables) == 0 {
+		return errors.New("cached queries must be tagged with the tables they read")
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("scan destination must be a non nil pointer")
+	}
+	key := fmt.Sprintf("%T\x00%s\x00%#v", dest, query, args)
+	c.mu.Lock()
+	cached, ok := c.entries[key]
+	gens, epoch := c.generations(tables), c.epoch
+	c.mu.Unlock()
+	if ok {
+		v.Elem().Set(copyValue(cached))
+		return nil
+	}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	if err := ScanRows(rows, dest); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if epoch != c.epoch || !reflect.DeepEqual(gens, c.generations(tables)) {
+		// A table was modified while the query ran: the result may be
+		// stale and is returned without being cached.
+		return nil
+	}
+	c.entries[key] = copyValue(v.Elem())
+	for _, t := range tables {
+		if c.tables[t] == nil {
+			c.tables[t] = map[string]bool{}
+		}
+		c.tables[t][key] = true
+	}
+	return nil
+}
+
+// generations returns the invalidation counts of tables. c.mu must be
+// held.
+func (c *QueryCache) generations(tables []string) []uint64 {
+	gens := make([]uint64, len(tables))
+	for i, t := range tables {
+		gens[i] = c.gens[t]
+	}
+	return gens
+}
+
+// copyValue returns a copy of v that does not share its storage, or a
+// slice's backing array, with v.
+func copyValue(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		return c
+	}
+	c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(c, v)
+	return c
+}
+
+// Invalidate drops the entries reading tableName.
+func (c *QueryCache) Invalidate(tableName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.tables[tableName] {
+		delete(c.entries, key)
+	}
+	delete(c.tables, tableName)
+	c.gens[tableName]++
+}
+
+// Flush drops every entry.
+func (c *QueryCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]reflect.Value{}
+	c.tables = map[string]map[string]bool{}
+	c.epoch++
+}
+
+// Len returns the number of cached results.
+func (c *QueryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Close stops listening for invalidations.
+func (c *QueryCache) Close() error {
+	err := c.n.Close()
+	<-c.done
+	return err
+}
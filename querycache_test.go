@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestQueryCacheCopiesResults(t *testing.T) {
+	db := sql.OpenDB(stubConnector{})
+	defer db.Close()
+	var s Postgres
+	s.DB = db
+	c := &QueryCache{
+		entries: map[string]reflect.Value{},
+		tables:  map[string]map[string]bool{},
+		gens:    map[string]uint64{},
+	}
+	type row struct{ Name string }
+	for _, tc := range []struct {
+		name string
+		dest func() (interface{}, func() string, func())
+	}{
+		{
+			name: "struct",
+			dest: func() (interface{}, func() string, func()) {
+				var r row
+				return &r, func() string { return r.Name }, func() { r.Name = "mutated" }
+			},
+		},
+		{
+			name: "slice",
+			dest: func() (interface{}, func() string, func()) {
+				var r []row
+				return &r, func() string { return r[0].Name }, func() { r[0].Name = "mutated" }
+			},
+		},
+	} {
+		query := "SELECT name FROM " + tc.name
+		for i := 0; i < 3; i++ {
+			dest, get, mutate := tc.dest()
+			if err := c.Query(s, []string{tc.name}, dest, query); err != nil {
+				t.Fatalf("%s: Query: %v", tc.name, err)
+			}
+			if got := get(); got != "a" {
+				t.Errorf("%s: query %d returned %q, want %q", tc.name, i, got, "a")
+			}
+			mutate()
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// QueryEvent describes a statement run through a LoggedDB.
+type QueryEvent struct {
+	Query    string
+	Args     []interface{}
+	Duration time.Duration
+
+	// Rows is the number of rows affected by Exec, or -1 for queries whose
+	// rows are streamed to the caller.
+	Rows int64
+
+	Err error
+}
+
+// Interpolated returns Query with its bind variables replaced by the
+// literal form of Args. It is meant for reading logs only: the result is not
+// guaranteed to be a valid statement and must never be executed.
+func (e QueryEvent) Interpolated() string {
+	var buf strings.Builder
+	var quote byte
+	q := e.Query
+	for i := 0; i < len(q); i++ {
+		c := q[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$' && i+1 < len(q) && q[i+1] >= '0' && q[i+1] <= '9':
+			j := i + 1
+			for j < len(q) && q[j] >= '0' && q[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(q[i+1 : j])
+			if n >= 1 && n <= len(e.Args) {
+				buf.WriteString(logLiteral(e.Args[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+func logLiteral(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return quoteLiteral(x)
+	case []byte:
+		return `'\x` + hex.EncodeToString(x) + `'`
+	case bool:
+		if x {
+			return "TRUE"
+		}
+		return "FALSE"
+	case time.Time:
+		return quoteLiteral(x.Format(time.RFC3339Nano))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(x)
+	}
+	return quoteLiteral(fmt.Sprint(v))
+}
+
+// QueryLogger receives the statements run through a LoggedDB.
+type QueryLogger interface {
+	LogQuery(e QueryEvent)
+}
+
+// QueryLoggerFunc adapts a function to QueryLogger.
+type QueryLoggerFunc func(e QueryEvent)
+
+// LogQuery calls f(e).
+func (f QueryLoggerFunc) LogQuery(e QueryEvent) {
+	f(e)
+}
+
+// Redacted replaces the logged value of redacted columns.
+const Redacted = "[REDACTED]"
+
+// LoggedDB is a connection reporting every statement it runs to a
+// QueryLogger. It can be used wherever a connection is expected. Statements
+// run in transactions started with Begin are not logged.
+type LoggedDB struct {
+	db     model.SQLCommon
+	logger QueryLogger
+
+	// Redact lists columns, such as password or token, whose bound values
+	// are replaced by Redacted in the logged arguments. Values are matched
+	// to columns in comparisons (col = $1) and insert column lists.
+	Redact []string
+
+	// SlowThreshold, when positive, reports statements running at least
+	// this long to OnSlow.
+	SlowThreshold time.Duration
+
+	// OnSlow receives slow statements. When nil, their text is written to
+	// the standard logger, without their arguments.
+	OnSlow func(q SlowQuery)
+
+	// ExplainSlow captures the plan of slow statements with EXPLAIN, which
+	// plans the statement again without running it.
+	ExplainSlow bool
+}
+
+// NewLoggedDB returns a LoggedDB reporting the statements run on db to
+// logger. The logger can be nil when only slow statements are of interest.
+func NewLoggedDB(db model.SQLCommon, logger QueryLogger, redact ...string) *LoggedDB {
+	return &LoggedDB{db: db, logger: logger, Redact: redact}
+}
+
+func (l *LoggedDB) log(query string, args []interface{}, start time.Time, rows int64, err error) {
+	e := QueryEvent{
+		Query:    query,
+		Args:     l.redact(query, args),
+		Duration: time.Since(start),
+		Rows:     rows,
+		Err:      err,
+	}
+	if l.logger != nil {
+		l.logger.LogQuery(e)
+	}
+	if l.SlowThreshold > 0 && e.Duration >= l.SlowThreshold {
+		l.slow(e, args)
+	}
+}
+
+// Exec implements model.SQLCommon.
+func (l *LoggedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := l.db.Exec(query, args...)
+	rows := int64(-1)
+	if err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			rows = n
+		}
+	}
+	l.log(query, args, start, rows, err)
+	return res, err
+}
+
+// Prepare implements model.SQLCommon. Executions of the statement are not
+// logged.
+func (l *LoggedDB) Prepare(query string) (*sql.Stmt, error) {
+	return l.db.Prepare(query)
+}
+
+// Query implements model.SQLCommon. The duration only covers the time to
+// the first row.
+func (l *LoggedDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := l.db.Query(query, args...)
+	l.log(query, args, start, -1, err)
+	return rows, err
+}
+
+// QueryRow implements model.SQLCommon. Errors are reported by Scan and are
+// not logged.
+func (l *LoggedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := l.db.QueryRow(query, args...)
+	l.log(query, args, start, -1, nil)
+	return row
+}
+
+// Begin starts a transaction on the underlying connection.
+func (l *LoggedDB) Begin() (*sql.Tx, error) {
+	b, ok := l.db.(txBeginner)
+	if !ok {
+		return nil, fmt.Errorf("can not begin a transaction on %T", l.db)
+	}
+	return b.Begin()
+}
+
+// Conn returns a dedicated connection from the underlying pool.
+func (l *LoggedDB) Conn(ctx context.Context) (*sql.Conn, error) {
+	c, ok := l.db.(conner)
+	if !ok {
+		return nil, fmt.Errorf("can not get a connection from %T", l.db)
+	}
+	return c.Conn(ctx)
+}
+
+var (
+	comparedParam = regexp.MustCompile(`(?i)"?(\w+)"?\s*(?:=|<>|!=|<=|>=|<|>|\bLIKE\b|\bILIKE\b)\s*\$(\d+)`)
+	insertColumns = regexp.MustCompile(`(?is)\(([^()]*)\)\s*VALUES\s*((?:\([^()]*\)\s*,?\s*)+)`)
+	valuesRow     = regexp.MustCompile(`\(([^()]*)\)`)
+)
+
+// redact returns args with the values bound to redacted columns replaced.
+func (l *LoggedDB) redact(query string, args []interface{}) []interface{} {
+	if len(l.Redact) == 0 || len(args) == 0 {
+		return args
+	}
+	hidden := map[int]bool{}
+	redacted := func(col string) bool {
+		col = strings.Trim(strings.TrimSpace(col), `"`)
+		for _, r := range l.Redact {
+			if strings.EqualFold(col, r) {
+				return true
+			}
+		}
+		return false
+	}
+	param := func(s string) int {
+		n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(s), "$"))
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+	for _, m := range comparedParam.FindAllStringSubmatch(query, -1) {
+		if redacted(m[1]) {
+			hidden[param(m[2])] = true
+		}
+	}
+	for _, m := range insertColumns.FindAllStringSubmatch(query, -1) {
+		cols := strings.Split(m[1], ",")
+		for _, row := range valuesRow.FindAllStringSubmatch(m[2], -1) {
+			for i, v := range strings.Split(row[1], ",") {
+				if i < len(cols) && redacted(cols[i]) {
+					hidden[param(v)] = true
+				}
+			}
+		}
+	}
+	if len(hidden) == 0 {
+		return args
+	}
+	out := append([]interface{}{}, args...)
+	for n := range hidden {
+		if n >= 1 && n <= len(out) {
+			out[n-1] = Redacted
+		}
+	}
+	return out
+}
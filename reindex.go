@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ReindexTable rebuilds every index of tableName, e.g. to recover from index
+// bloat. A plain REINDEX blocks writes to the table and reads using the
+// indexes; with concurrently the indexes are rebuilt without blocking, which
+// needs PostgreSQL 12 and can not run in a transaction.
+func (s Postgres) ReindexTable(tableName string, concurrently bool) error {
+	return s.reindex("TABLE", tableName, concurrently)
+}
+
+// ReindexIndex rebuilds indexName, see ReindexTable.
+func (s Postgres) ReindexIndex(indexName string, concurrently bool) error {
+	return s.reindex("INDEX", indexName, concurrently)
+}
+
+func (s Postgres) reindex(kind, name string, concurrently bool) error {
+	query := fmt.Sprintf("REINDEX %v %v", kind, s.Quote(name))
+	if concurrently {
+		caps, err := s.Capabilities()
+		if err != nil {
+			return err
+		}
+		if !caps.ReindexConcurrently {
+			return errors.New("REINDEX CONCURRENTLY requires PostgreSQL 12")
+		}
+		if _, ok := s.DB.(txBeginner); !ok {
+			return errors.New("REINDEX CONCURRENTLY can not run inside a transaction")
+		}
+		query = fmt.Sprintf("REINDEX %v CONCURRENTLY %v", kind, s.Quote(name))
+	}
+	_, err := s.DB.Exec(query)
+	return err
+}
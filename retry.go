@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"time"
+)
+
+// DefaultTxBackoff is the retry schedule of RunInTxWithRetry.
+var DefaultTxBackoff = Backoff{Attempts: 5, Initial: 10 * time.Millisecond, Max: time.Second}
+
+// IsRetryable reports whether the operation that failed with err may
+// succeed if run again: transactions aborted by a serialization failure or
+// a deadlock, and connections lost to a reset or a server shutdown. Other
+// errors, such as constraint violations, are permanent.
+//
+// A connection lost while committing leaves the outcome of the transaction
+// unknown, so only idempotent work should be retried on such errors; whole
+// transactions are only retried on the errors of IsTxRetryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch code := sqlState(err); {
+	case code == SerializationFailure, code == DeadlockDetected:
+		return true
+	case code == AdminShutdown, code == CrashShutdown, code == CannotConnectNow:
+		return true
+	case CodeClass(code) == CodeClass(ConnectionException):
+		return true
+	case code != "":
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsTxRetryable reports whether the transaction that failed with err was
+// rolled back by the server and may succeed if run again: serialization
+// failures and deadlocks. Lost connections are not, as the transaction may
+// have committed before the connection dropped.
+func IsTxRetryable(err error) bool {
+	switch sqlState(err) {
+	case SerializationFailure, DeadlockDetected:
+		return true
+	}
+	return false
+}
+
+// RunInTxWithRetry runs fn in a transaction started with opts and commits
+// it. When the transaction fails with an error classified as retryable by
+// IsTxRetryable it is rolled back and run again, with backoff as scheduled by
+// DefaultTxBackoff, so fn must not have side effects outside the database.
+// The dialect's connection must not already be a transaction, as a
+// serialization failure aborts the whole transaction.
+func (s Postgres) RunInTxWithRetry(ctx context.Context, opts TxOptions, fn func(tx *Tx) error) error {
+	return s.RunInTxWithBackoff(ctx, opts, DefaultTxBackoff, fn)
+}
+
+// RunInTxWithBackoff is like RunInTxWithRetry with the retry schedule b.
+func (s Postgres) RunInTxWithBackoff(ctx context.Context, opts TxOptions, b Backoff, fn func(tx *Tx) error) error {
+	if _, ok := s.DB.(txBeginner); !ok {
+		return errors.New("can not retry a transaction nested in another one")
+	}
+	var err error
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if serr := b.sleep(ctx, attempt); serr != nil {
+				return err
+			}
+		}
+		err = s.TransactionTx(opts, fn)
+		if err == nil || !IsTxRetryable(err) || attempt >= b.Attempts {
+			return err
+		}
+	}
+}
+
+// RetryPolicy decides which failed statements are run again and when.
+type RetryPolicy struct {
+	Backoff
+
+	// Codes lists the SQLSTATE codes that are retried. By default the
+	// errors classified as retryable by IsRetryable are retried, along with
+	// lock_not_available raised by lock_timeout.
+	Codes []string
+}
+
+// DefaultRetryPolicy retries deadlocks and lock timeouts a few times.
+var DefaultRetryPolicy = RetryPolicy{
+	Backoff: Backoff{Attempts: 3, Initial: 50 * time.Millisecond, Max: time.Second},
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if len(p.Codes) > 0 {
+		return containsString(p.Codes, sqlState(err))
+	}
+	return IsRetryable(err) || sqlState(err) == LockNotAvailable
+}
+
+// Do runs fn until it succeeds, fails with an error that p does not retry
+// or the attempts are exhausted.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			if serr := p.sleep(ctx, attempt); serr != nil {
+				return err
+			}
+		}
+		err = fn()
+		if err == nil || !p.retryable(err) || attempt >= p.Attempts {
+			return err
+		}
+	}
+}
+
+// ExecRetry runs the idempotent statement query, retrying it as described
+// by p. A failed statement aborts the transaction it runs in, so the
+// dialect's connection must not be a transaction.
+func (s Postgres) ExecRetry(ctx context.Context, p RetryPolicy, query string, args ...interface{}) (sql.Result, error) {
+	if _, ok := s.DB.(txBeginner); !ok {
+		return nil, errors.New("can not retry a statement inside a transaction")
+	}
+	var res sql.Result
+	err := p.Do(ctx, func() error {
+		var err error
+		res, err = s.DB.Exec(query, args...)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// ReturningClause returns a RETURNING clause for columns, or RETURNING * when
+// no columns are given.
+func (s Postgres) ReturningClause(columns ...string) string {
+	if len(columns) == 0 {
+		return "RETURNING *"
+	}
+	return "RETURNING " + s.quoteColumns(columns)
+}
+
+// ExecReturning runs an INSERT, UPDATE or DELETE statement that ends in a
+// RETURNING clause and scans the returned rows into dest, as described by
+// ScanRows. This reflects server side defaults and changes made by triggers
+// without a second query.
+func (s Postgres) ExecReturning(dest interface{}, query string, args ...interface{}) error {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	return ScanRows(rows, dest)
+}
+
+// InsertReturning inserts values into columns of tableName and scans the
+// returning columns of the new row into dest. All columns are returned when
+// returning is empty.
+func (s Postgres) InsertReturning(dest interface{}, tableName string, columns []string, values []interface{}, returning ...string) error {
+	if len(columns) != len(values) {
+		return fmt.Errorf("got %d values for %d columns", len(values), len(columns))
+	}
+	query, args := s.Build(Expr{
+		SQL: fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v) %v", s.Quote(tableName),
+			s.quoteColumns(columns), placeholders(len(values)), s.ReturningClause(returning...)),
+		Args: values,
+	})
+	return s.ExecReturning(dest, query, args...)
+}
+
+// UpdateReturning applies set to the rows of tableName matching where and
+// scans the updated rows into dest.
+func (s Postgres) UpdateReturning(dest interface{}, tableName string, set []Assignment, where Expr, returning ...string) error {
+	e, err := s.updateExpr(tableName, set, where)
+	if err != nil {
+		return err
+	}
+	e.SQL += " " + s.ReturningClause(returning...)
+	query, args := s.Build(e)
+	return s.ExecReturning(dest, query, args...)
+}
+
+// DeleteReturning deletes the rows of tableName matching where and scans the
+// deleted rows into dest.
+func (s Postgres) DeleteReturning(dest interface{}, tableName string, where Expr, returning ...string) error {
+	e := Expr{SQL: "DELETE FROM " + s.Quote(tableName)}
+	if where.SQL != "" {
+		e = Join(" WHERE ", e, where)
+	}
+	e.SQL += " " + s.ReturningClause(returning...)
+	query, args := s.Build(e)
+	return s.ExecReturning(dest, query, args...)
+}
+
+func (s Postgres) updateExpr(tableName string, set []Assignment, where Expr) (Expr, error) {
+	if len(set) == 0 {
+		return Expr{}, fmt.Errorf("update of %s has nothing to set", tableName)
+	}
+	sets := make([]Expr, len(set))
+	for i, a := range set {
+		sets[i] = s.assignment(a)
+	}
+	e := Join(" SET ", Expr{SQL: "UPDATE " + s.Quote(tableName)}, Join(", ", sets...))
+	if where.SQL != "" {
+		e = Join(" WHERE ", e, where)
+	}
+	return e, nil
+}
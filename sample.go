@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Table sampling methods.
+const (
+	// SampleSystem picks whole pages; fast but clustered.
+	SampleSystem = "SYSTEM"
+
+	// SampleBernoulli picks individual rows; slower but uniform.
+	SampleBernoulli = "BERNOULLI"
+)
+
+// Sample describes a TABLESAMPLE clause.
+type Sample struct {
+	// Method is SampleSystem or SampleBernoulli. Defaults to SampleSystem.
+	Method string
+
+	// Percent of the table to sample, between 0 and 100.
+	Percent float64
+
+	// Seed makes the sample repeatable across queries when not nil.
+	Seed *int64
+}
+
+// TableSample returns tableName followed by its TABLESAMPLE clause, to be
+// used in place of the table name:
+//
+//	table, err := s.TableSample("events", postgres.Sample{Percent: 1})
+//	if err != nil {
+//		return err
+//	}
+//	db.Table(table)
+func (s Postgres) TableSample(tableName string, sample Sample) (string, error) {
+	method := sample.Method
+	if method == "" {
+		method = SampleSystem
+	}
+	if method != SampleSystem && method != SampleBernoulli {
+		return "", fmt.Errorf("invalid sampling method %s", method)
+	}
+	if sample.Percent < 0 || sample.Percent > 100 {
+		return "", fmt.Errorf("sample percentage %v is out of range", sample.Percent)
+	}
+	clause := fmt.Sprintf("%v TABLESAMPLE %v (%v)", s.Quote(tableName), method,
+		strconv.FormatFloat(sample.Percent, 'f', -1, 64))
+	if sample.Seed != nil {
+		clause += fmt.Sprintf(" REPEATABLE (%d)", *sample.Seed)
+	}
+	return clause, nil
+}
@@ -0,0 +1,323 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ScanRows scans every row of rows into dest, which must be a pointer to a
+// struct, a pointer to a slice of structs or a pointer to a slice of struct
+// pointers. Columns are matched to fields by their column tag setting or the
+// snake_case form of the field name; columns without a matching field are
+// discarded. When dest is a struct only the first row is scanned and
+// ErrNotFound is returned if there is none.
+func ScanRows(rows *sql.Rows, dest interface{}) error {
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return scanInto(rows, cols, dest)
+}
+
+// rowScanner is the part of *sql.Rows used for scanning, also implemented by
+// the native rows of pgx.
+type rowScanner interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+}
+
+func scanInto(rows rowScanner, cols []string, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("scan destination must be a non nil pointer")
+	}
+	v = v.Elem()
+	switch v.Kind() {
+	case reflect.Struct:
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return ErrNotFound
+		}
+		if err := scanStruct(rows, cols, v); err != nil {
+			return err
+		}
+	case reflect.Slice:
+		elem := v.Type().Elem()
+		isPtr := elem.Kind() == reflect.Ptr
+		if isPtr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			return fmt.Errorf("can not scan into %s", v.Type())
+		}
+		plan := newScanPlan(elem, cols)
+		if !isPtr && plan.flat {
+			// Every row is scanned into the same value, which is then
+			// copied into the slice, so the scan destinations are only
+			// computed once.
+			item := reflect.New(elem).Elem()
+			targets := plan.targets(item)
+			for rows.Next() {
+				if err := rows.Scan(targets...); err != nil {
+					return err
+				}
+				v.Set(reflect.Append(v, item))
+			}
+			break
+		}
+		for rows.Next() {
+			item := reflect.New(elem)
+			if err := rows.Scan(plan.targets(item.Elem())...); err != nil {
+				return err
+			}
+			if isPtr {
+				v.Set(reflect.Append(v, item))
+			} else {
+				v.Set(reflect.Append(v, item.Elem()))
+			}
+		}
+	default:
+		return fmt.Errorf("can not scan into %s", v.Type())
+	}
+	return rows.Err()
+}
+
+func scanStruct(rows rowScanner, cols []string, v reflect.Value) error {
+	return rows.Scan(newScanPlan(v.Type(), cols).targets(v)...)
+}
+
+// scanPlan maps the columns of a result to the fields of a struct type.
+type scanPlan struct {
+	// fields holds the index path of the field of each column, nil for
+	// discarded columns.
+	fields [][]int
+
+	// flat is set when no field is reached through an embedded pointer, so
+	// that scanning never allocates inside the struct.
+	flat bool
+
+	// wrap holds the scanner of the fields that can not be scanned into
+	// directly, see fieldScanner, and nil for the others.
+	wrap []func(field reflect.Value) sql.Scanner
+}
+
+func newScanPlan(t reflect.Type, cols []string) *scanPlan {
+	byName := columnIndex(t)
+	p := &scanPlan{fields: make([][]int, len(cols)), flat: true, wrap: make([]func(reflect.Value) sql.Scanner, len(cols))}
+	for i, col := range cols {
+		index, ok := byName[col]
+		if !ok {
+			continue
+		}
+		p.fields[i] = index
+		ft := t
+		for _, x := range index[:len(index)-1] {
+			ft = ft.Field(x).Type
+			if ft.Kind() == reflect.Ptr {
+				p.flat = false
+				ft = ft.Elem()
+			}
+		}
+		p.wrap[i] = fieldScanner(t.FieldByIndex(index).Type)
+	}
+	return p
+}
+
+// targets returns the scan destinations of the fields of v. Discarded
+// columns share a single destination.
+func (p *scanPlan) targets(v reflect.Value) []interface{} {
+	targets := make([]interface{}, len(p.fields))
+	var discard interface{}
+	for i, index := range p.fields {
+		switch {
+		case index == nil:
+			targets[i] = &discard
+		case p.wrap[i] != nil:
+			targets[i] = p.wrap[i](fieldByIndex(v, index))
+		default:
+			targets[i] = fieldByIndex(v, index).Addr().Interface()
+		}
+	}
+	return targets
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but allocates nil embedded
+// struct pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// structColumn is a column stored by a struct field.
+type structColumn struct {
+	name    string
+	index   []int
+	primary bool
+
+	// system is set for system columns such as xmin, which are read but
+	// never written.
+	system bool
+
+	// hasDefault is set for the columns with a server default, which
+	// InsertModels leaves to it for zero values.
+	hasDefault bool
+
+	// relation is set for the fields holding associated models, such as
+	// a struct or a slice of structs. They can be scanned from nested rows
+	// but are not columns of the table.
+	relation bool
+}
+
+// columnIndexes caches the result of columnIndex by type.
+var columnIndexes sync.Map
+
+// columnIndex maps column names to the index path of the fields of t that
+// store them. The map is shared and must not be modified.
+func columnIndex(t reflect.Type) map[string][]int {
+	if m, ok := columnIndexes.Load(t); ok {
+		return m.(map[string][]int)
+	}
+	cols := structColumns(t)
+	m := make(map[string][]int, len(cols))
+	for _, c := range cols {
+		m[c.name] = c.index
+	}
+	columnIndexes.Store(t, m)
+	return m
+}
+
+// structColumns returns the columns stored by the fields of t in declaration
+// order.
+func structColumns(t reflect.Type) []structColumn {
+	var cols []structColumn
+	seen := make(map[string]bool)
+	addColumns(&cols, seen, t, nil)
+	return cols
+}
+
+func addColumns(cols *[]structColumn, seen map[string]bool, t reflect.Type, parent []int) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		index := append(append([]int{}, parent...), i)
+		if name, ok := systemColumns[f.Type]; ok {
+			// Fields of system column types hold them whatever their
+			// tags, which usually hide them from ngorm.
+			if !seen[name] {
+				seen[name] = true
+				*cols = append(*cols, structColumn{name: name, index: index, system: true})
+			}
+			continue
+		}
+		settings := tagSettings(f.Tag)
+		if _, ok := settings["-"]; ok {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct {
+			if _, ok := settings["EMBEDDED"]; ok || !isScannerType(f.Type) {
+				addColumns(cols, seen, ft, index)
+				continue
+			}
+		}
+		name := settings["COLUMN"]
+		if name == "" {
+			name = ToDBName(f.Name)
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		_, primary := settings["PRIMARY_KEY"]
+		_, hasDefault := settings["DEFAULT"]
+		*cols = append(*cols, structColumn{
+			name:       name,
+			index:      index,
+			primary:    primary || f.Name == "ID",
+			hasDefault: hasDefault,
+			relation:   isNestedType(f.Type),
+		})
+	}
+}
+
+// systemColumns maps the types of struct fields holding system columns to
+// the columns.
+var systemColumns = map[reflect.Type]string{
+	xidType:  XMinColumn,
+	ctidType: CTIDColumn,
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+func isScannerType(t reflect.Type) bool {
+	return t.Implements(scannerType) || reflect.PtrTo(t).Implements(scannerType)
+}
+
+// tagSettings parses the sql and gorm struct tags into upper cased keys, the
+// same way the model package does.
+func tagSettings(tag reflect.StructTag) map[string]string {
+	settings := make(map[string]string)
+	for _, str := range []string{tag.Get("sql"), tag.Get("gorm")} {
+		if str == "-" {
+			settings["-"] = "-"
+			continue
+		}
+		for _, value := range strings.Split(str, ";") {
+			v := strings.Split(value, ":")
+			k := strings.TrimSpace(strings.ToUpper(v[0]))
+			if k == "" {
+				continue
+			}
+			if len(v) >= 2 {
+				settings[k] = strings.Join(v[1:], ":")
+			} else {
+				settings[k] = k
+			}
+		}
+	}
+	return settings
+}
+
+// ToDBName converts a Go field name to its snake_case column name, keeping
+// initialisms together: UserID becomes user_id and HTTPServer http_server.
+func ToDBName(name string) string {
+	runes := []rune(name)
+	var buf strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					buf.WriteByte('_')
+				}
+			}
+			buf.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
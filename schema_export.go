@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Exporter describes the schema written by ExportSchema.
+type Exporter struct {
+	// Extensions are installed first, e.g. pg_trgm or pgcrypto.
+	Extensions []string
+
+	// Tables are the tables of the registered models. Their indexes are
+	// taken from the INDEX, UNIQUE_INDEX and TRGM_INDEX tag settings, and
+	// column comments from the COMMENT tag setting.
+	Tables []Table
+
+	// Constraints are added once every table exists, so foreign keys can
+	// reference any of them.
+	Constraints []Constraint
+
+	// Comments maps table names to their comment.
+	Comments map[string]string
+}
+
+// Constraint is a named table constraint, e.g. a foreign key with the
+// definition "FOREIGN KEY (user_id) REFERENCES users (id)".
+type Constraint struct {
+	Table      string
+	Name       string
+	Definition string
+}
+
+// ExportSchema writes the DDL of e to w as a script that can be applied by
+// tools other than the application, such as a change management pipeline.
+// Statements are ordered so every one only depends on earlier ones:
+// extensions, tables, columns added to tables created by earlier versions,
+// constraints, indexes, column storage and comments. The script is
+// re-runnable: objects that already exist are left alone.
+func (s Postgres) ExportSchema(w io.Writer, e Exporter) error {
+	script, err := s.ExportSchemaSQL(e)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, script)
+	return err
+}
+
+// ExportSchemaSQL returns the script written by ExportSchema.
+func (s Postgres) ExportSchemaSQL(e Exporter) (string, error) {
+	var stmts, columns, constraints, indexes, rest []string
+	for _, ext := range e.Extensions {
+		stmts = append(stmts, fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %v", s.Quote(ext)))
+	}
+	for _, t := range e.Tables {
+		c, err := s.createTableChange(t)
+		if err != nil {
+			return "", err
+		}
+		if t.ForeignServer != "" {
+			stmts = append(stmts, c.SQL)
+			continue
+		}
+		stmts = append(stmts, strings.Replace(c.SQL, "CREATE TABLE ", "CREATE TABLE IF NOT EXISTS ", 1))
+		for _, field := range columnFields(t.Fields) {
+			if field.IsPrimaryKey {
+				continue
+			}
+			def, err := s.DataTypeOf(field)
+			if err != nil {
+				return "", err
+			}
+			columns = append(columns, fmt.Sprintf("ALTER TABLE %v ADD COLUMN IF NOT EXISTS %v %v",
+				s.Quote(t.Name), s.Quote(field.DBName), def))
+			storage, err := s.ColumnStorageSQL(t.Name, field)
+			if err != nil {
+				return "", err
+			}
+			if storage != "" {
+				rest = append(rest, storage)
+			}
+			if comment, ok := field.TagSettings["COMMENT"]; ok {
+				rest = append(rest, fmt.Sprintf("COMMENT ON COLUMN %v.%v IS %v",
+					s.Quote(t.Name), s.Quote(field.DBName), quoteLiteral(comment)))
+			}
+		}
+		for _, idx := range modelIndexes(t) {
+			c, err := s.createIndexChange(t.Name, idx)
+			if err != nil {
+				return "", err
+			}
+			indexes = append(indexes, strings.Replace(c.SQL, "INDEX ", "INDEX IF NOT EXISTS ", 1))
+		}
+		if comment, ok := e.Comments[t.Name]; ok {
+			rest = append(rest, fmt.Sprintf("COMMENT ON TABLE %v IS %v", s.Quote(t.Name), quoteLiteral(comment)))
+		}
+	}
+	for _, c := range e.Constraints {
+		constraints = append(constraints, s.addConstraintIfMissing(c))
+	}
+	stmts = append(stmts, columns...)
+	stmts = append(stmts, constraints...)
+	stmts = append(stmts, indexes...)
+	stmts = append(stmts, rest...)
+
+	var buf strings.Builder
+	for _, stmt := range stmts {
+		buf.WriteString(stmt)
+		buf.WriteString(";\n")
+	}
+	return buf.String(), nil
+}
+
+// addConstraintIfMissing returns a DO block adding c unless a constraint of
+// the same name exists on its table, as ADD CONSTRAINT has no IF NOT EXISTS.
+func (s Postgres) addConstraintIfMissing(c Constraint) string {
+	return fmt.Sprintf(`DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = %v AND conrelid = %v::regclass) THEN
+		ALTER TABLE %v ADD CONSTRAINT %v %v;
+	END IF;
+END
+$$`, quoteLiteral(c.Name), quoteLiteral(s.Quote(c.Table)), s.Quote(c.Table), s.Quote(c.Name), c.Definition)
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// ScriptStatement is a statement of a SQL script.
+type ScriptStatement struct {
+	SQL string
+
+	// Line is the line of the script the statement starts on, from 1.
+	Line int
+}
+
+// SplitScript splits script into its statements, on the semicolons that are
+// not part of a string, a quoted identifier, a comment or a dollar quoted
+// body such as that of a function. Empty statements are dropped.
+func SplitScript(script string) []ScriptStatement {
+	var stmts []ScriptStatement
+	start, line, startLine := 0, 1, 1
+	flush := func(end int) {
+		sql := strings.TrimSpace(script[start:end])
+		if sql != "" && !onlyComments(sql) {
+			// The statement starts after the blank lines preceding it.
+			lead := script[start:end]
+			n := startLine + strings.Count(lead[:len(lead)-len(strings.TrimLeft(lead, " \t\r\n"))], "\n")
+			stmts = append(stmts, ScriptStatement{SQL: sql, Line: n})
+		}
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+		case c == '\'' || c == '"':
+			escapes := c == '\'' && i > 0 && (script[i-1] == 'E' || script[i-1] == 'e') &&
+				(i < 2 || !isIdentChar(script[i-2]))
+			j := i + 1
+			for ; j < len(script); j++ {
+				if escapes && script[j] == '\\' {
+					j++
+					continue
+				}
+				if script[j] == c {
+					if j+1 < len(script) && script[j+1] == c {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			line += strings.Count(script[i:minInt(j, len(script))], "\n")
+			i = j
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			j := strings.IndexByte(script[i:], '\n')
+			if j < 0 {
+				i = len(script)
+				break
+			}
+			i += j - 1
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			depth := 0
+			j := i
+			for ; j < len(script); j++ {
+				if strings.HasPrefix(script[j:], "/*") {
+					depth++
+					j++
+				} else if strings.HasPrefix(script[j:], "*/") {
+					depth--
+					j++
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			line += strings.Count(script[i:minInt(j, len(script))], "\n")
+			i = j
+		case c == '$' && (i == 0 || !isIdentChar(script[i-1])):
+			tag := dollarTag(script[i:])
+			if tag == "" {
+				break
+			}
+			end := strings.Index(script[i+len(tag):], tag)
+			j := len(script)
+			if end >= 0 {
+				j = i + len(tag) + end + len(tag)
+			}
+			line += strings.Count(script[i:j], "\n")
+			i = j - 1
+		case c == ';':
+			flush(i)
+			start, startLine = i+1, line
+		}
+	}
+	flush(len(script))
+	return stmts
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// dollarTag returns the dollar quote opening s, such as $$ or $body$, or the
+// empty string when s starts with a parameter like $1.
+func dollarTag(s string) string {
+	for j := 1; j < len(s); j++ {
+		switch c := s[j]; {
+		case c == '$':
+			return s[:j+1]
+		case c >= '0' && c <= '9' && j == 1:
+			return ""
+		case !isIdentChar(c):
+			return ""
+		}
+	}
+	return ""
+}
+
+// onlyComments reports whether sql holds nothing but comments.
+func onlyComments(sql string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+// ExecScript runs the statements of script, split as by SplitScript, one at
+// a time and without parameters, so hand written migration scripts can be
+// applied through the dialect. They run in a transaction, unless the
+// dialect's connection already is one, so a failing script leaves nothing
+// behind; scripts with statements that can not run in a transaction, such as
+// CREATE INDEX CONCURRENTLY, must be run with ExecScriptNoTx. The error
+// names the line of the failing statement.
+func (s Postgres) ExecScript(script string) error {
+	return s.withTx(func(db model.SQLCommon) error {
+		return execScript(db, script)
+	})
+}
+
+// ExecScriptNoTx is like ExecScript but runs every statement on its own.
+func (s Postgres) ExecScriptNoTx(script string) error {
+	return execScript(s.DB, script)
+}
+
+func execScript(db model.SQLCommon, script string) error {
+	for _, stmt := range SplitScript(script) {
+		if _, err := db.Exec(stmt.SQL); err != nil {
+			return fmt.Errorf("line %d: %v", stmt.Line, TranslateError(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// SeedsTable records the seed sets applied by ApplySeeds.
+const SeedsTable = "schema_seeds"
+
+// Seed is a set of reference rows shipped with the application, such as
+// countries or roles.
+type Seed struct {
+	// Name identifies the seed set in SeedsTable.
+	Name string
+
+	Table   string
+	Columns []string
+	Rows    [][]interface{}
+
+	// OnConflict resolves the rows that already exist. Defaults to
+	// skipping them with DO NOTHING; set Update to overwrite them with the
+	// seeded values.
+	OnConflict OnConflict
+}
+
+// SeedModels returns the seed set name for models, a slice of structs or
+// struct pointers, whose columns are chosen as in InsertModels.
+func SeedModels(name, tableName string, models interface{}, columns ...string) (Seed, error) {
+	columns, rows, err := modelRows(models, columns, false)
+	if err != nil {
+		return Seed{}, err
+	}
+	return Seed{Name: name, Table: tableName, Columns: columns, Rows: rows}, nil
+}
+
+// SeedCSV returns the seed set name read from r, CSV data whose first record
+// holds the column names. Empty fields are seeded as NULL.
+func SeedCSV(name, tableName string, r io.Reader) (Seed, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return Seed{}, err
+	}
+	if len(records) == 0 {
+		return Seed{}, fmt.Errorf("seed %s has no header", name)
+	}
+	seed := Seed{Name: name, Table: tableName, Columns: records[0]}
+	for _, rec := range records[1:] {
+		row := make([]interface{}, len(rec))
+		for i, v := range rec {
+			if v != "" {
+				row[i] = v
+			}
+		}
+		seed.Rows = append(seed.Rows, row)
+	}
+	return seed, nil
+}
+
+// SeedJSON returns the seed set name read from r, a JSON array of objects
+// mapping column names to values. Objects and arrays are seeded as JSON, for
+// json and jsonb columns, and missing keys as NULL.
+func SeedJSON(name, tableName string, r io.Reader) (Seed, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var objects []map[string]json.RawMessage
+	if err := dec.Decode(&objects); err != nil {
+		return Seed{}, err
+	}
+	seen := make(map[string]bool)
+	seed := Seed{Name: name, Table: tableName}
+	for _, o := range objects {
+		for k := range o {
+			if !seen[k] {
+				seen[k] = true
+				seed.Columns = append(seed.Columns, k)
+			}
+		}
+	}
+	sort.Strings(seed.Columns)
+	for _, o := range objects {
+		row := make([]interface{}, len(seed.Columns))
+		for i, col := range seed.Columns {
+			raw, ok := o[col]
+			if !ok {
+				continue
+			}
+			v, err := seedValue(raw)
+			if err != nil {
+				return Seed{}, fmt.Errorf("seed %s: column %s: %v", name, col, err)
+			}
+			row[i] = v
+		}
+		seed.Rows = append(seed.Rows, row)
+	}
+	return seed, nil
+}
+
+func seedValue(raw json.RawMessage) (interface{}, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && (raw[0] == '{' || raw[0] == '[') {
+		return string(raw), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if n, ok := v.(json.Number); ok {
+		return n.String(), nil
+	}
+	return v, nil
+}
+
+// checksum identifies the content of the seed set, so changed sets are
+// applied again.
+// Values are hashed as they are written, so pointers and driver.Valuer
+// values count for what they hold.
+func (seed Seed) checksum() (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %q %q\n", seed.Table, seed.Columns, seed.OnConflict.Update)
+	for _, row := range seed.Rows {
+		for _, v := range row {
+			v, err := driverValue(v)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%T:%v\x00", v, v)
+		}
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ApplySeeds applies seeds in order, each in a transaction of its own
+// together with its record in SeedsTable. A seed set whose content did not
+// change since it was last applied is skipped, so ApplySeeds can run on
+// every deployment. The conflict target of seed sets that update existing
+// rows defaults to the primary key.
+func (s Postgres) ApplySeeds(seeds ...Seed) error {
+	_, err := s.DB.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %v (
+	name text PRIMARY KEY,
+	checksum text NOT NULL,
+	applied_at timestamp with time zone NOT NULL DEFAULT now()
+)`, s.Quote(SeedsTable)))
+	if err != nil {
+		return err
+	}
+	for _, seed := range seeds {
+		if err := s.applySeed(seed); err != nil {
+			return fmt.Errorf("seed %s: %v", seed.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s Postgres) applySeed(seed Seed) error {
+	sum, err := seed.checksum()
+	if err != nil {
+		return err
+	}
+	c := seed.OnConflict
+	if len(c.Update) == 0 && len(c.Set) == 0 {
+		c.DoNothing = true
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		var applied string
+		err := db.QueryRow(fmt.Sprintf("SELECT checksum FROM %v WHERE name = $1 FOR UPDATE",
+			s.Quote(SeedsTable)), seed.Name).Scan(&applied)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		if applied == sum {
+			return nil
+		}
+		tx := s
+		tx.DB = db
+		if len(c.Columns) == 0 && c.Constraint == "" && !c.DoNothing {
+			keys, err := tx.primaryKey(seed.Table)
+			if err != nil {
+				return err
+			}
+			c.Columns = keys
+		}
+		if len(seed.Rows) > 0 {
+			if _, err := tx.BulkUpsert(seed.Table, seed.Columns, seed.Rows, c); err != nil {
+				return err
+			}
+		}
+		_, err = db.Exec(fmt.Sprintf(`INSERT INTO %v (name, checksum) VALUES ($1, $2)
+ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = now()`,
+			s.Quote(SeedsTable)), seed.Name, sum)
+		return err
+	})
+}
+
+// primaryKey returns the primary key columns of tableName.
+func (s Postgres) primaryKey(tableName string) ([]string, error) {
+	query := `
+SELECT a.attname
+FROM   pg_index i
+       JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+WHERE  i.indrelid = $1::regclass
+       AND i.indisprimary
+ORDER  BY array_position(i.indkey::int2[], a.attnum)
+	`
+	rows, err := s.DB.Query(query, s.Quote(tableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("table %s has no primary key", tableName)
+	}
+	return keys, nil
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SequenceOf returns the name of the sequence backing the serial column
+// columnName of tableName.
+func (s Postgres) SequenceOf(tableName, columnName string) (string, error) {
+	var name sql.NullString
+	err := s.DB.QueryRow("SELECT pg_get_serial_sequence($1, $2)",
+		s.Quote(tableName), columnName).Scan(&name)
+	if err != nil {
+		return "", err
+	}
+	if !name.Valid {
+		return "", fmt.Errorf("column %s.%s is not backed by a sequence", tableName, columnName)
+	}
+	return name.String, nil
+}
+
+// NextVal advances sequenceName and returns its new value.
+func (s Postgres) NextVal(sequenceName string) (v int64, err error) {
+	err = s.DB.QueryRow("SELECT nextval($1)", sequenceName).Scan(&v)
+	return
+}
+
+// CurrVal returns the value most recently obtained by nextval for
+// sequenceName in the current session.
+func (s Postgres) CurrVal(sequenceName string) (v int64, err error) {
+	err = s.DB.QueryRow("SELECT currval($1)", sequenceName).Scan(&v)
+	return
+}
+
+// SetVal sets the current value of sequenceName. When isCalled is false the
+// next call to nextval returns value itself instead of value + 1.
+func (s Postgres) SetVal(sequenceName string, value int64, isCalled bool) error {
+	_, err := s.DB.Exec("SELECT setval($1, $2, $3)", sequenceName, value, isCalled)
+	return err
+}
+
+// RestartSequence restarts sequenceName so that nextval returns value.
+// sequenceName is used as is, so it may be schema qualified as returned by
+// SequenceOf.
+func (s Postgres) RestartSequence(sequenceName string, value int64) error {
+	_, err := s.DB.Exec(fmt.Sprintf("ALTER SEQUENCE %v RESTART WITH %d", sequenceName, value))
+	return err
+}
+
+// ResyncSequence moves the sequence behind the serial column columnName past
+// the largest value stored in tableName. It is needed after rows with
+// explicit ids were loaded, since those don't advance the sequence.
+func (s Postgres) ResyncSequence(tableName, columnName string) error {
+	seq, err := s.SequenceOf(tableName, columnName)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("SELECT setval($1, COALESCE(max(%v), 0) + 1, false) FROM %v",
+		s.Quote(columnName), s.Quote(tableName))
+	_, err = s.DB.Exec(query, seq)
+	return err
+}
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SessionParams are runtime parameters of a session. Zero fields are left
+// unchanged.
+type SessionParams struct {
+	TimeZone        string
+	ApplicationName string
+
+	// StatementTimeout cancels statements running longer.
+	StatementTimeout time.Duration
+
+	// LockTimeout cancels statements waiting longer for a lock.
+	LockTimeout time.Duration
+
+	// IdleInTransactionTimeout terminates sessions idling longer in an open
+	// transaction.
+	IdleInTransactionTimeout time.Duration
+}
+
+func (p SessionParams) settings() [][2]string {
+	var settings [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			settings = append(settings, [2]string{name, value})
+		}
+	}
+	duration := func(name string, d time.Duration) {
+		if d > 0 {
+			add(name, durationSetting(d))
+		}
+	}
+	add("timezone", p.TimeZone)
+	add("application_name", p.ApplicationName)
+	duration("statement_timeout", p.StatementTimeout)
+	duration("lock_timeout", p.LockTimeout)
+	duration("idle_in_transaction_session_timeout", p.IdleInTransactionTimeout)
+	return settings
+}
+
+// setSQL returns a statement applying p, for the current transaction only
+// when local is set.
+func (p SessionParams) setSQL(local bool) (string, []interface{}) {
+	settings := p.settings()
+	calls := make([]string, len(settings))
+	args := make([]interface{}, 0, 2*len(settings))
+	for i, kv := range settings {
+		calls[i] = fmt.Sprintf("set_config($%d, $%d, %v)", 2*i+1, 2*i+2, local)
+		args = append(args, kv[0], kv[1])
+	}
+	return "SELECT " + strings.Join(calls, ", "), args
+}
+
+// SetSessionParams applies p to the session behind the dialect's connection
+// with SET semantics: the settings outlive the current transaction. On a pool
+// this only affects one connection; use p.AfterConnect to configure every
+// connection.
+func (s Postgres) SetSessionParams(p SessionParams) error {
+	if len(p.settings()) == 0 {
+		return nil
+	}
+	if s.PgBouncer {
+		return ErrPgBouncer
+	}
+	query, args := p.setSQL(false)
+	_, err := s.DB.Exec(query, args...)
+	return err
+}
+
+// SetLocalParams applies p to the current transaction only, with SET LOCAL
+// semantics. The dialect's connection must be a transaction.
+func (s Postgres) SetLocalParams(p SessionParams) error {
+	if _, ok := s.DB.(txBeginner); ok {
+		return errors.New("local parameters need a transaction")
+	}
+	if len(p.settings()) == 0 {
+		return nil
+	}
+	query, args := p.setSQL(true)
+	_, err := s.DB.Exec(query, args...)
+	return err
+}
+
+// AfterConnect returns a Connector hook applying p to every new connection.
+func (p SessionParams) AfterConnect() func(conn SessionConn) error {
+	return func(conn SessionConn) error {
+		for _, kv := range p.settings() {
+			if err := SetSession(kv[0], kv[1])(conn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// CurrentUser returns the name of the role the session runs as, which
+// SET ROLE may have changed from the role that logged in.
+func (s Postgres) CurrentUser() (string, error) {
+	var name string
+	err := s.DB.QueryRow("SELECT current_user").Scan(&name)
+	return name, err
+}
+
+// BackendPID returns the process id of the server process serving the
+// session, as listed in pg_stat_activity. On a pool it is the process of
+// whichever connection ran the query, so it is only meaningful on a
+// transaction or a dedicated connection.
+func (s Postgres) BackendPID() (int, error) {
+	var pid int
+	err := s.DB.QueryRow("SELECT pg_backend_pid()").Scan(&pid)
+	return pid, err
+}
+
+// SessionInfo describes the session behind a connection.
+type SessionInfo struct {
+	PID         int
+	User        string
+	SessionUser string
+	Database    string
+	Schema      string
+
+	// ClientAddr is the client address seen by the server, empty over a
+	// unix socket.
+	ClientAddr string
+
+	TimeZone        string
+	ApplicationName string
+	ServerVersion   string
+	ReadOnly        bool
+}
+
+// SessionInfo returns the description of the session behind the dialect's
+// connection.
+func (s Postgres) SessionInfo() (*SessionInfo, error) {
+	var i SessionInfo
+	var addr sql.NullString
+	var readOnly string
+	err := s.DB.QueryRow(`SELECT pg_backend_pid(), current_user, session_user,
+	current_database(), coalesce(current_schema(), ''), host(inet_client_addr()),
+	current_setting('TimeZone'), current_setting('application_name'),
+	current_setting('server_version'), current_setting('transaction_read_only')`).Scan(
+		&i.PID, &i.User, &i.SessionUser, &i.Database, &i.Schema, &addr,
+		&i.TimeZone, &i.ApplicationName, &i.ServerVersion, &readOnly)
+	if err != nil {
+		return nil, err
+	}
+	i.ClientAddr = addr.String
+	i.ReadOnly = readOnly == "on"
+	return &i, nil
+}
+
+// CancelBackend cancels the query running in the server process pid,
+// reporting whether the signal could be sent.
+func (s Postgres) CancelBackend(pid int) (bool, error) {
+	var ok bool
+	err := s.DB.QueryRow("SELECT pg_cancel_backend($1)", pid).Scan(&ok)
+	return ok, err
+}
+
+// TerminateBackend ends the session of the server process pid, reporting
+// whether the signal could be sent.
+func (s Postgres) TerminateBackend(pid int) (bool, error) {
+	var ok bool
+	err := s.DB.QueryRow("SELECT pg_terminate_backend($1)", pid).Scan(&ok)
+	return ok, err
+}
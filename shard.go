@@ -0,0 +1,212 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Shards routes models to one of several physical databases by a shard key,
+// such as a customer id, with a dialect per database. Keys are mapped to
+// shards with jump consistent hashing: adding a shard at the end only moves
+// the keys that land on it, about 1/n of them, while removing or reordering
+// shards moves most keys.
+type Shards struct {
+	Dialects []Postgres
+}
+
+// errNoShards is returned when routing keys without any shard.
+var errNoShards = errors.New("no shards")
+
+// NewShards returns a Shards over dialects, one per database, in a fixed
+// order. At least one dialect is needed.
+func NewShards(dialects ...Postgres) (*Shards, error) {
+	if len(dialects) == 0 {
+		return nil, errNoShards
+	}
+	return &Shards{Dialects: dialects}, nil
+}
+
+// ShardKeyer is implemented by models that compute their shard key. Models
+// can instead tag the field holding it with SHARD_KEY.
+type ShardKeyer interface {
+	ShardKey() interface{}
+}
+
+// ShardError is the failure of an operation on one shard.
+type ShardError struct {
+	Shard int
+	Err   error
+}
+
+func (e ShardError) Error() string {
+	return fmt.Sprintf("shard %d: %v", e.Shard, e.Err)
+}
+
+// ShardErrors lists the shards an operation failed on, in shard order.
+type ShardErrors []ShardError
+
+func (e ShardErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, s := range e {
+		msgs[i] = s.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Index returns the shard of key. Keys are hashed by their text form, so
+// the integer 42 and the string "42" land on the same shard. Pointers are
+// dereferenced and driver.Valuer keys hashed by their value, so a key lands
+// on the same shard however it is held.
+func (sh *Shards) Index(key interface{}) (int, error) {
+	if len(sh.Dialects) == 0 {
+		return 0, errNoShards
+	}
+	key, err := shardKeyValue(key)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	switch k := key.(type) {
+	case []byte:
+		h.Write(k)
+	case string:
+		h.Write([]byte(k))
+	default:
+		fmt.Fprint(h, k)
+	}
+	return jumpHash(h.Sum64(), len(sh.Dialects)), nil
+}
+
+// shardKeyValue returns the value key stands for, as driverValue. Nil keys
+// are refused.
+func shardKeyValue(key interface{}) (interface{}, error) {
+	v, err := driverValue(key)
+	if err == nil && v == nil {
+		err = errors.New("shard key is nil")
+	}
+	return v, err
+}
+
+// jumpHash is the jump consistent hash of Lamping and Veach.
+func jumpHash(key uint64, buckets int) int {
+	var b, j int64 = -1, 0
+	for j < int64(buckets) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// For returns the dialect of the shard of key.
+func (sh *Shards) For(key interface{}) (Postgres, error) {
+	i, err := sh.Index(key)
+	if err != nil {
+		return Postgres{}, err
+	}
+	return sh.Dialects[i], nil
+}
+
+// ForModel returns the dialect of the shard of value, a model implementing
+// ShardKeyer or with a field tagged SHARD_KEY.
+func (sh *Shards) ForModel(value interface{}) (Postgres, error) {
+	key, err := shardKey(value)
+	if err != nil {
+		return Postgres{}, err
+	}
+	return sh.For(key)
+}
+
+func shardKey(value interface{}) (interface{}, error) {
+	if k, ok := value.(ShardKeyer); ok {
+		return k.ShardKey(), nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			if _, ok := tagSettings(v.Type().Field(i).Tag)["SHARD_KEY"]; ok {
+				return v.Field(i).Interface(), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%T has no shard key", value)
+}
+
+// Each runs fn on every shard concurrently and waits for all of them. The
+// shards fn failed on are reported in a ShardErrors.
+func (sh *Shards) Each(fn func(shard int, s Postgres) error) error {
+	var (
+		mu   sync.Mutex
+		errs ShardErrors
+		wg   sync.WaitGroup
+	)
+	for i, s := range sh.Dialects {
+		wg.Add(1)
+		go func(i int, s Postgres) {
+			defer wg.Done()
+			if err := fn(i, s); err != nil {
+				mu.Lock()
+				errs = append(errs, ShardError{Shard: i, Err: err})
+				mu.Unlock()
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Shard < errs[j].Shard })
+	return errs
+}
+
+// Query runs the query e on every shard and scans the rows of all shards
+// into dest, a pointer to a slice, in shard order. Ordering and limits apply
+// within each shard only.
+func (sh *Shards) Query(dest interface{}, e Expr) error {
+	out := reflect.ValueOf(dest)
+	if out.Kind() != reflect.Ptr || out.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected a pointer to a slice, got %T", dest)
+	}
+	parts := make([]reflect.Value, len(sh.Dialects))
+	err := sh.Each(func(i int, s Postgres) error {
+		query, args := s.Build(e)
+		rows, err := s.DB.Query(query, args...)
+		if err != nil {
+			return err
+		}
+		part := reflect.New(out.Elem().Type())
+		if err := ScanRows(rows, part.Interface()); err != nil {
+			return err
+		}
+		parts[i] = part.Elem()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	all := out.Elem().Slice(0, 0)
+	for _, part := range parts {
+		all = reflect.AppendSlice(all, part)
+	}
+	out.Elem().Set(all)
+	return nil
+}
+
+// MigrateAll plans and applies the migration of tables on every shard.
+func (sh *Shards) MigrateAll(tables ...Table) error {
+	if len(sh.Dialects) == 0 {
+		return errNoShards
+	}
+	return sh.Each(func(_ int, s Postgres) error {
+		plan, err := s.PlanMigration(tables...)
+		if err != nil {
+			return err
+		}
+		return s.ApplyPlan(plan)
+	})
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DatabaseSize returns the disk space used by the current database, in
+// bytes.
+func (s Postgres) DatabaseSize() (int64, error) {
+	var n int64
+	err := s.DB.QueryRow("SELECT pg_database_size(current_database())").Scan(&n)
+	return n, err
+}
+
+// TableSize describes the disk usage and the row counts of a table, as
+// estimated by the statistics collector.
+type TableSize struct {
+	Table string
+
+	// TotalBytes is the sum of TableBytes, IndexBytes and ToastBytes.
+	TotalBytes int64
+	TableBytes int64
+	IndexBytes int64
+	ToastBytes int64
+
+	LiveTuples int64
+
+	// DeadTuples are the row versions left by updates and deletes that
+	// vacuum has not reclaimed yet.
+	DeadTuples int64
+
+	// LastVacuum and LastAnalyze are the last times the table was vacuumed
+	// and analyzed, manually or by autovacuum, or nil if never.
+	LastVacuum  *time.Time
+	LastAnalyze *time.Time
+}
+
+// DeadRatio returns the share of dead tuples among all tuples of the table.
+func (t TableSize) DeadRatio() float64 {
+	if t.LiveTuples+t.DeadTuples == 0 {
+		return 0
+	}
+	return float64(t.DeadTuples) / float64(t.LiveTuples+t.DeadTuples)
+}
+
+// TableSizes returns the sizes of tables, or of every table visible in the
+// search_path when none is given, largest first.
+func (s Postgres) TableSizes(tables ...string) ([]TableSize, error) {
+	query := `
+SELECT c.relname,
+       pg_total_relation_size(c.oid),
+       pg_relation_size(c.oid),
+       pg_indexes_size(c.oid),
+       coalesce(pg_total_relation_size(nullif(c.reltoastrelid, 0)), 0),
+       coalesce(st.n_live_tup, 0),
+       coalesce(st.n_dead_tup, 0),
+       greatest(st.last_vacuum, st.last_autovacuum),
+       greatest(st.last_analyze, st.last_autoanalyze)
+FROM   pg_class c
+       LEFT JOIN pg_stat_user_tables st ON st.relid = c.oid
+WHERE  c.relkind IN ('r', 'p', 'm')
+       AND pg_table_is_visible(c.oid)
+       AND c.relnamespace NOT IN ('pg_catalog'::regnamespace, 'information_schema'::regnamespace)
+       AND (cardinality($1::text[]) = 0 OR c.relname = ANY($1))
+ORDER  BY 2 DESC
+	`
+	if tables == nil {
+		tables = []string{}
+	}
+	rows, err := s.DB.Query(query, pq.Array(tables))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sizes []TableSize
+	for rows.Next() {
+		var t TableSize
+		var vacuum, analyze sql.NullTime
+		if err := rows.Scan(&t.Table, &t.TotalBytes, &t.TableBytes, &t.IndexBytes, &t.ToastBytes,
+			&t.LiveTuples, &t.DeadTuples, &vacuum, &analyze); err != nil {
+			return nil, err
+		}
+		if vacuum.Valid {
+			t.LastVacuum = &vacuum.Time
+		}
+		if analyze.Valid {
+			t.LastAnalyze = &analyze.Time
+		}
+		sizes = append(sizes, t)
+	}
+	return sizes, rows.Err()
+}
+
+// TableSize returns the size of tableName.
+func (s Postgres) TableSize(tableName string) (*TableSize, error) {
+	sizes, err := s.TableSizes(tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(sizes) == 0 {
+		return nil, ErrNotFound
+	}
+	return &sizes[0], nil
+}
+
+// IndexSize describes the disk usage of an index.
+type IndexSize struct {
+	Table string
+	Index string
+	Bytes int64
+
+	// Scans is the number of index scans since the statistics were reset.
+	// Indexes that are never scanned only slow down writes.
+	Scans int64
+}
+
+// IndexSizes returns the sizes of the indexes of tableName, largest first.
+func (s Postgres) IndexSizes(tableName string) ([]IndexSize, error) {
+	query := `
+SELECT st.relname, st.indexrelname, pg_relation_size(st.indexrelid), st.idx_scan
+FROM   pg_stat_user_indexes st
+WHERE  st.relname = $1
+       AND pg_table_is_visible(st.relid)
+ORDER  BY 3 DESC
+	`
+	rows, err := s.DB.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sizes []IndexSize
+	for rows.Next() {
+		var i IndexSize
+		if err := rows.Scan(&i.Table, &i.Index, &i.Bytes, &i.Scans); err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, i)
+	}
+	return sizes, rows.Err()
+}
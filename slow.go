@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"log"
+)
+
+// SlowQuery is a statement that exceeded LoggedDB.SlowThreshold.
+type SlowQuery struct {
+	QueryEvent
+
+	// Plan is the plan of the statement when LoggedDB.ExplainSlow is set and
+	// it could be obtained.
+	Plan *QueryPlan
+
+	// PlanErr is the error of EXPLAIN, if any.
+	PlanErr error
+}
+
+func (l *LoggedDB) slow(e QueryEvent, args []interface{}) {
+	q := SlowQuery{QueryEvent: e}
+	if l.ExplainSlow && e.Err == nil {
+		q.Plan, q.PlanErr = l.explain(e.Query, args)
+	}
+	if l.OnSlow != nil {
+		l.OnSlow(q)
+		return
+	}
+	// The arguments may hold personal data and are left out of the
+	// standard logger.
+	if q.Plan != nil {
+		log.Printf("slow query (%v, cost %.2f): %v", q.Duration, q.Plan.TotalCost(), q.Query)
+	} else {
+		log.Printf("slow query (%v): %v", q.Duration, q.Query)
+	}
+}
+
+// explain returns the plan of an already bound statement. It runs on the
+// wrapped connection so it is not logged itself.
+func (l *LoggedDB) explain(query string, args []interface{}) (*QueryPlan, error) {
+	var out []byte
+	if err := l.db.QueryRow("EXPLAIN (FORMAT JSON) "+query, args...).Scan(&out); err != nil {
+		return nil, err
+	}
+	return parsePlan(out)
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import "strings"
+
+// SQLSTATE codes reported by the server, for use with HasCode and
+// RetryPolicy.Codes. See Appendix A of the PostgreSQL documentation for the
+// complete list.
+const (
+	// Class 08: connection exception.
+	ConnectionException                           = "08000"
+	ConnectionDoesNotExist                        = "08003"
+	ConnectionFailure                             = "08006"
+	SQLClientUnableToEstablishSQLConnection       = "08001"
+	SQLServerRejectedEstablishmentOfSQLConnection = "08004"
+	ProtocolViolation                             = "08P01"
+
+	// Class 0A: feature not supported.
+	FeatureNotSupported = "0A000"
+
+	// Class 22: data exception.
+	DataException                = "22000"
+	StringDataRightTruncation    = "22001"
+	NumericValueOutOfRange       = "22003"
+	InvalidDatetimeFormat        = "22007"
+	DatetimeFieldOverflow        = "22008"
+	DivisionByZero               = "22012"
+	InvalidTextRepresentation    = "22P02"
+	InvalidParameterValue        = "22023"
+	UntranslatableCharacter      = "22P05"
+	InvalidJSONText              = "22032"
+	CharacterNotInRepertoire     = "22021"
+	NullValueNotAllowed          = "22004"
+	InvalidBinaryRepresentation  = "22P03"
+	InvalidEscapeSequence        = "22025"
+	ArraySubscriptError          = "2202E"
+	InvalidRegularExpression     = "2201B"
+	InvalidTimeZoneDisplacement  = "22009"
+	IntervalFieldOverflow        = "22015"
+	SubstringError               = "22011"
+	InvalidCharacterValueForCast = "22018"
+
+	// Class 23: integrity constraint violation.
+	IntegrityConstraintViolation = "23000"
+	RestrictViolation            = "23001"
+	NotNullViolation             = "23502"
+	ForeignKeyViolation          = "23503"
+	UniqueViolation              = "23505"
+	CheckViolation               = "23514"
+	ExclusionViolation           = "23P01"
+
+	// Class 25: invalid transaction state.
+	InvalidTransactionState         = "25000"
+	ActiveSQLTransaction            = "25001"
+	ReadOnlySQLTransaction          = "25006"
+	InFailedSQLTransaction          = "25P02"
+	IdleInTransactionSessionTimeout = "25P03"
+
+	// Class 28: invalid authorization specification.
+	InvalidAuthorizationSpecification = "28000"
+	InvalidPassword                   = "28P01"
+
+	// Class 3D and 3F: invalid catalog and schema name.
+	InvalidCatalogName = "3D000"
+	InvalidSchemaName  = "3F000"
+
+	// Class 40: transaction rollback.
+	TransactionRollback  = "40000"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+
+	// Class 42: syntax error or access rule violation.
+	SyntaxError           = "42601"
+	InsufficientPrivilege = "42501"
+	UndefinedColumn       = "42703"
+	UndefinedFunction     = "42883"
+	UndefinedTable        = "42P01"
+	UndefinedObject       = "42704"
+	DuplicateColumn       = "42701"
+	DuplicateDatabase     = "42P04"
+	DuplicateObject       = "42710"
+	DuplicateSchema       = "42P06"
+	DuplicateTable        = "42P07"
+	AmbiguousColumn       = "42702"
+	DatatypeMismatch      = "42804"
+	WrongObjectType       = "42809"
+
+	// Class 53: insufficient resources.
+	InsufficientResources = "53000"
+	DiskFull              = "53100"
+	OutOfMemory           = "53200"
+	TooManyConnections    = "53300"
+
+	// Class 54: program limit exceeded.
+	StatementTooComplex = "54001"
+
+	// Class 55: object not in prerequisite state.
+	ObjectNotInPrerequisiteState = "55000"
+	ObjectInUse                  = "55006"
+	LockNotAvailable             = "55P03"
+
+	// Class 57: operator intervention.
+	QueryCanceled      = "57014"
+	AdminShutdown      = "57P01"
+	CrashShutdown      = "57P02"
+	CannotConnectNow   = "57P03"
+	DatabaseDropped    = "57P04"
+	IdleSessionTimeout = "57P05"
+
+	// Class P0: PL/pgSQL error.
+	RaiseException = "P0001"
+	NoDataFound    = "P0002"
+	TooManyRows    = "P0003"
+	AssertFailure  = "P0004"
+
+	// Class XX: internal error.
+	InternalError  = "XX000"
+	DataCorrupted  = "XX001"
+	IndexCorrupted = "XX002"
+)
+
+// HasCode reports whether err was reported by the server with one of codes,
+// e.g. HasCode(err, UniqueViolation). A code ending with "000", such as
+// IntegrityConstraintViolation, stands for its whole class.
+func HasCode(err error, codes ...string) bool {
+	code := sqlState(err)
+	if code == "" {
+		return false
+	}
+	for _, c := range codes {
+		if c == code || strings.HasSuffix(c, "000") && CodeClass(c) == CodeClass(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// CodeClass returns the class of the SQLSTATE code, its first two
+// characters, e.g. "23" for UniqueViolation.
+func CodeClass(code string) string {
+	if len(code) < 2 {
+		return code
+	}
+	return code[:2]
+}
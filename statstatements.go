@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrNoStatStatements is returned when the pg_stat_statements extension is
+// not installed.
+var ErrNoStatStatements = errors.New("pg_stat_statements is not installed")
+
+// StatementStats are the execution statistics of a normalized statement as
+// collected by pg_stat_statements.
+type StatementStats struct {
+	QueryID int64
+	Query   string
+	Calls   int64
+	Rows    int64
+
+	TotalTime  time.Duration
+	MeanTime   time.Duration
+	MinTime    time.Duration
+	MaxTime    time.Duration
+	StddevTime time.Duration
+}
+
+// Percentile estimates the p-th percentile (0 < p < 1) of the execution time
+// from the mean and standard deviation, assuming they are normally
+// distributed. pg_stat_statements does not keep the actual distribution.
+func (st StatementStats) Percentile(p float64) time.Duration {
+	z := math.Sqrt2 * math.Erfinv(2*p-1)
+	d := st.MeanTime + time.Duration(z*float64(st.StddevTime))
+	if d < st.MinTime {
+		return st.MinTime
+	}
+	if d > st.MaxTime {
+		return st.MaxTime
+	}
+	return d
+}
+
+// StatementStats returns the statistics of the limit statements with the
+// highest total execution time in the current database.
+func (s Postgres) StatementStats(limit int) ([]StatementStats, error) {
+	return s.statementStats("", limit)
+}
+
+// StatementStatsFor returns the statistics of queries, keyed by query. The
+// queries are matched with their normalized form, in which constants are
+// replaced by bind variables; statements built by the dialect already use
+// bind variables and match as is. Queries that have not run are missing.
+func (s Postgres) StatementStatsFor(queries ...string) (map[string]StatementStats, error) {
+	if len(queries) == 0 {
+		return map[string]StatementStats{}, nil
+	}
+	normalized := make([]string, len(queries))
+	for i, q := range queries {
+		normalized[i] = normalizeStatement(q)
+	}
+	stats, err := s.statementStats("regexp_replace(btrim(s.query), '\\s+', ' ', 'g') = ANY($1::text[])", 0, pq.Array(normalized))
+	if err != nil {
+		return nil, err
+	}
+	byQuery := make(map[string]StatementStats, len(stats))
+	for _, st := range stats {
+		key := normalizeStatement(st.Query)
+		for i, n := range normalized {
+			if n == key {
+				byQuery[queries[i]] = mergeStats(byQuery[queries[i]], st)
+			}
+		}
+	}
+	return byQuery, nil
+}
+
+// mergeStats combines the statistics of a statement run by several users.
+func mergeStats(a, b StatementStats) StatementStats {
+	if a.Calls == 0 {
+		return b
+	}
+	calls := a.Calls + b.Calls
+	a.MeanTime = time.Duration((float64(a.MeanTime)*float64(a.Calls) + float64(b.MeanTime)*float64(b.Calls)) / float64(calls))
+	a.Calls = calls
+	a.Rows += b.Rows
+	a.TotalTime += b.TotalTime
+	if b.MinTime < a.MinTime {
+		a.MinTime = b.MinTime
+	}
+	if b.MaxTime > a.MaxTime {
+		a.MaxTime = b.MaxTime
+	}
+	if b.StddevTime > a.StddevTime {
+		a.StddevTime = b.StddevTime
+	}
+	return a
+}
+
+func (s Postgres) statementStats(where string, limit int, args ...interface{}) ([]StatementStats, error) {
+	if !s.HasExtension("pg_stat_statements") {
+		return nil, ErrNoStatStatements
+	}
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	}
+	// The timing columns were renamed in Postgres 13.
+	prefix := ""
+	if version.AtLeast(13, 0) {
+		prefix = "_exec"
+	}
+	query := fmt.Sprintf(`SELECT s.queryid, s.query, s.calls, s.rows,
+	s.total%[1]v_time, s.mean%[1]v_time, s.min%[1]v_time, s.max%[1]v_time, s.stddev%[1]v_time
+FROM pg_stat_statements s
+JOIN pg_database d ON d.oid = s.dbid
+WHERE d.datname = current_database()`, prefix)
+	if where != "" {
+		query += " AND " + where
+	}
+	query += fmt.Sprintf(" ORDER BY s.total%v_time DESC", prefix)
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []StatementStats
+	for rows.Next() {
+		var st StatementStats
+		var total, mean, min, max, stddev float64
+		if err := rows.Scan(&st.QueryID, &st.Query, &st.Calls, &st.Rows,
+			&total, &mean, &min, &max, &stddev); err != nil {
+			return nil, err
+		}
+		st.TotalTime = millis(total)
+		st.MeanTime = millis(mean)
+		st.MinTime = millis(min)
+		st.MaxTime = millis(max)
+		st.StddevTime = millis(stddev)
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
+
+// ResetStatementStats discards the statistics gathered so far.
+func (s Postgres) ResetStatementStats() error {
+	_, err := s.DB.Exec("SELECT pg_stat_statements_reset()")
+	return err
+}
+
+func millis(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// normalizeStatement collapses the whitespace of query the way it is
+// compared to pg_stat_statements.
+func normalizeStatement(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// DefaultStmtCacheSize is the capacity of a StmtCache created with a size of
+// zero.
+const DefaultStmtCacheSize = 256
+
+// StmtCache is a connection that prepares the statements it runs and keeps
+// the most recently used ones, so hot queries are parsed and planned once.
+// It can be used wherever a connection is expected.
+//
+// Prepared statements live in server sessions, which transaction pooling
+// PgBouncer shares between clients; use the pool directly in that setup.
+type StmtCache struct {
+	db    *sql.DB
+	size  int
+	mu    sync.Mutex
+	lru   *list.List
+	stmts map[string]*list.Element
+	stats StmtCacheStats
+}
+
+// StmtCacheStats counts the lookups of a StmtCache.
+type StmtCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+}
+
+// HitRate returns the share of lookups served from the cache.
+func (s StmtCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type cachedStmt struct {
+	query string
+	stmt  *sql.Stmt
+
+	// refs counts the callers running the statement. An evicted statement
+	// is closed once none is left, so that it is never closed under them.
+	refs    int
+	evicted bool
+}
+
+// NewStmtCache returns a StmtCache over db keeping up to size statements. A
+// size of zero means DefaultStmtCacheSize, and a negative size disables
+// caching.
+func NewStmtCache(db *sql.DB, size int) *StmtCache {
+	if size == 0 {
+		size = DefaultStmtCacheSize
+	}
+	return &StmtCache{db: db, size: size, lru: list.New(), stmts: map[string]*list.Element{}}
+}
+
+// Stats returns the lookup counters.
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Len returns the number of cached statements.
+func (c *StmtCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// acquire returns the cached statement for query, preparing it on a miss.
+// It must be given back with release once the statement has run.
+func (c *StmtCache) acquire(query string) (*cachedStmt, error) {
+	c.mu.Lock()
+	if e, ok := c.stmts[query]; ok {
+		c.stats.Hits++
+		c.lru.MoveToFront(e)
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		c.mu.Unlock()
+		return cs, nil
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// Preparing is a round trip to the server, made without holding the
+	// lock so that other queries are not held up.
+	stmt, err := c.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.stmts[query]; ok {
+		// Another caller prepared query meanwhile: theirs is kept.
+		c.lru.MoveToFront(e)
+		go stmt.Close()
+		cs := e.Value.(*cachedStmt)
+		cs.refs++
+		return cs, nil
+	}
+	cs := &cachedStmt{query: query, stmt: stmt, refs: 1}
+	c.stmts[query] = c.lru.PushFront(cs)
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		old := c.lru.Remove(e).(*cachedStmt)
+		delete(c.stmts, old.query)
+		c.stats.Evictions++
+		old.evicted = true
+		if old.refs == 0 {
+			// Close waits for the statement's open rows, which the
+			// caller may still be iterating.
+			go old.stmt.Close()
+		}
+	}
+	return cs, nil
+}
+
+// release gives back a statement returned by acquire.
+func (c *StmtCache) release(cs *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cs.refs--
+	if cs.evicted && cs.refs == 0 {
+		go cs.stmt.Close()
+	}
+}
+
+// Exec runs query with a cached statement.
+func (c *StmtCache) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if c.size < 0 {
+		return c.db.Exec(query, args...)
+	}
+	cs, err := c.acquire(query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(cs)
+	return cs.stmt.Exec(args...)
+}
+
+// Prepare prepares query on the underlying pool. The statement is not
+// cached, as cached statements are closed when evicted, and must be closed
+// by the caller.
+func (c *StmtCache) Prepare(query string) (*sql.Stmt, error) {
+	return c.db.Prepare(query)
+}
+
+// Query runs query with a cached statement. The rows keep the statement
+// open until they are closed, even if it is evicted meanwhile.
+func (c *StmtCache) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	if c.size < 0 {
+		return c.db.Query(query, args...)
+	}
+	cs, err := c.acquire(query)
+	if err != nil {
+		return nil, err
+	}
+	defer c.release(cs)
+	return cs.stmt.Query(args...)
+}
+
+// QueryRow runs query with a cached statement.
+func (c *StmtCache) QueryRow(query string, args ...interface{}) *sql.Row {
+	if c.size < 0 {
+		return c.db.QueryRow(query, args...)
+	}
+	cs, err := c.acquire(query)
+	if err != nil {
+		// A failed prepare is reported when the row is scanned.
+		return c.db.QueryRow(query, args...)
+	}
+	defer c.release(cs)
+	return cs.stmt.QueryRow(args...)
+}
+
+// Begin starts a transaction on the underlying pool.
+func (c *StmtCache) Begin() (*sql.Tx, error) {
+	return c.db.Begin()
+}
+
+// Conn returns a dedicated connection from the underlying pool.
+func (c *StmtCache) Conn(ctx context.Context) (*sql.Conn, error) {
+	return c.db.Conn(ctx)
+}
+
+// Close closes every cached statement.
+func (c *StmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		if cerr := e.Value.(*cachedStmt).stmt.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	c.lru.Init()
+	c.stmts = map[string]*list.Element{}
+	return err
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// Column storage modes accepted by the STORAGE tag setting.
+const (
+	StoragePlain    = "PLAIN"
+	StorageExternal = "EXTERNAL"
+	StorageExtended = "EXTENDED"
+	StorageMain     = "MAIN"
+)
+
+func isCompressionMethod(method string) bool {
+	switch method {
+	case "pglz", "lz4", "default":
+		return true
+	}
+	return false
+}
+
+func isStorageMode(mode string) bool {
+	switch mode {
+	case StoragePlain, StorageExternal, StorageExtended, StorageMain:
+		return true
+	}
+	return false
+}
+
+// ColumnStorageSQL returns the ALTER TABLE statement that applies the STORAGE
+// tag setting of field. An empty string is returned when the field has no
+// STORAGE setting.
+//
+// Storage modes can not be declared inline in CREATE TABLE before Postgres 16,
+// so they are applied as a separate statement after the table is created.
+func (s Postgres) ColumnStorageSQL(tableName string, field *model.StructField) (string, error) {
+	mode, ok := field.TagSettings["STORAGE"]
+	if !ok {
+		return "", nil
+	}
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	if !isStorageMode(mode) {
+		return "", fmt.Errorf("invalid storage mode %s for postgres", mode)
+	}
+	return fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET STORAGE %v",
+		s.Quote(tableName), s.Quote(field.DBName), mode), nil
+}
+
+// SetColumnStorage applies the STORAGE tag setting of field to the column in
+// tableName. It is a no-op for fields without a STORAGE setting.
+func (s Postgres) SetColumnStorage(tableName string, field *model.StructField) error {
+	query, err := s.ColumnStorageSQL(tableName, field)
+	if err != nil || query == "" {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
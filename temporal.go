@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// HistoryFunction is the name of the trigger function recording row
+// versions into history tables.
+const HistoryFunction = "record_history"
+
+// History table columns bounding the period a row version was current. A
+// current version has a NULL valid_to.
+const (
+	ValidFromColumn = "valid_from"
+	ValidToColumn   = "valid_to"
+)
+
+// recordHistorySQL creates the history trigger function. Its first argument
+// is the history table, the others are the primary key columns used to find
+// the current version of a row.
+const recordHistorySQL = `
+CREATE OR REPLACE FUNCTION record_history() RETURNS trigger AS $$
+DECLARE
+	key text;
+	cols text;
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		SELECT string_agg(format('%I = $1.%I', k, k), ' AND ')
+		INTO   key
+		FROM   unnest(TG_ARGV[1:]) AS k;
+		EXECUTE format('UPDATE %s SET valid_to = $2 WHERE valid_to IS NULL AND %s', TG_ARGV[0], key)
+		USING OLD, now();
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') THEN
+		SELECT string_agg(quote_ident(attname), ', ' ORDER BY attnum)
+		INTO   cols
+		FROM   pg_attribute
+		WHERE  attrelid = TG_RELID AND attnum > 0 AND NOT attisdropped;
+		EXECUTE format('INSERT INTO %s (%s, valid_from) SELECT ($1).*, $2', TG_ARGV[0], cols)
+		USING NEW, now();
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql
+`
+
+// TemporalModel is implemented by models whose tables keep the history of
+// their rows.
+type TemporalModel interface {
+	KeepsHistory() bool
+}
+
+// HistoryTable returns the name of the table holding the history of
+// tableName.
+func HistoryTable(tableName string) string {
+	return tableName + "_history"
+}
+
+func historyTrigger(tableName string, keys []string) Trigger {
+	return Trigger{
+		Name:       tableName + "_record_history",
+		Timing:     After,
+		Events:     []string{OnInsert, OnUpdate, OnDelete},
+		ForEachRow: true,
+		Function:   HistoryFunction,
+		Args:       append([]string{Postgres{}.Quote(HistoryTable(tableName))}, keys...),
+	}
+}
+
+// EnableHistory makes tableName keep the history of its rows: every version
+// of a row is recorded in HistoryTable(tableName), along with the period it
+// was current, by triggers that also capture changes made outside the ORM.
+// The history table is created with the columns of tableName and seeded with
+// its current rows; columns added to tableName since are added to it. The
+// table must have a primary key. Versions are timestamped with the start of
+// the transaction that wrote them.
+func (s Postgres) EnableHistory(tableName string) error {
+	keys, err := s.primaryKey(tableName)
+	if err != nil {
+		return err
+	}
+	live, err := s.Inspector().Table(tableName)
+	if err != nil {
+		return err
+	}
+	history := HistoryTable(tableName)
+	var existing *TableInfo
+	if s.HasTable(history) {
+		if existing, err = s.Inspector().Table(history); err != nil {
+			return err
+		}
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		if _, err := db.Exec(recordHistorySQL); err != nil {
+			return err
+		}
+		if existing == nil {
+			stmts := []string{
+				fmt.Sprintf("CREATE TABLE %v (LIKE %v, %v timestamptz NOT NULL, %v timestamptz)",
+					s.Quote(history), s.Quote(tableName), ValidFromColumn, ValidToColumn),
+				fmt.Sprintf("CREATE INDEX %v ON %v (%v, %v)", s.Quote(history+"_key_idx"),
+					s.Quote(history), s.quoteColumns(keys), ValidFromColumn),
+				fmt.Sprintf("INSERT INTO %v SELECT *, now() FROM %v", s.Quote(history), s.Quote(tableName)),
+			}
+			for _, stmt := range stmts {
+				if _, err := db.Exec(stmt); err != nil {
+					return err
+				}
+			}
+		} else {
+			for _, col := range live.Columns {
+				if existing.Column(col.Name) != nil {
+					continue
+				}
+				if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v %v",
+					s.Quote(history), s.Quote(col.Name), col.DataType)); err != nil {
+					return err
+				}
+			}
+		}
+		t := historyTrigger(tableName, keys)
+		if _, err := db.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %v ON %v", s.Quote(t.Name), s.Quote(tableName))); err != nil {
+			return err
+		}
+		query, err := s.CreateTriggerSQL(tableName, t)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(query)
+		return err
+	})
+}
+
+// DisableHistory stops recording the history of tableName. The history
+// table is kept.
+func (s Postgres) DisableHistory(tableName string) error {
+	return s.DropTrigger(tableName, historyTrigger(tableName, nil).Name)
+}
+
+// EnsureHistory enables the history of tableName when value implements
+// TemporalModel and asks for it.
+func (s Postgres) EnsureHistory(tableName string, value interface{}) error {
+	if t, ok := value.(TemporalModel); ok && t.KeepsHistory() {
+		return s.EnableHistory(tableName)
+	}
+	return nil
+}
+
+// AsOf returns a query selecting the rows of tableName matching where, which
+// may be empty, as they were at the given time, read from its history
+// table. The rows carry valid_from and valid_to along with the columns of
+// tableName, and can be scanned into the model with ScanRows.
+func (s Postgres) AsOf(tableName string, at time.Time, where Expr) Expr {
+	return s.historyQuery(tableName, And(
+		Expr{SQL: ValidFromColumn + " <= ?", Args: []interface{}{at}},
+		Expr{SQL: fmt.Sprintf("%v IS NULL OR %v > ?", ValidToColumn, ValidToColumn), Args: []interface{}{at}},
+		where,
+	))
+}
+
+// HistoryOf returns a query selecting every version of the rows of tableName
+// matching where, oldest first.
+func (s Postgres) HistoryOf(tableName string, where Expr) Expr {
+	e := s.historyQuery(tableName, where)
+	e.SQL += " ORDER BY " + ValidFromColumn
+	return e
+}
+
+func (s Postgres) historyQuery(tableName string, where Expr) Expr {
+	e := Expr{SQL: "SELECT * FROM " + s.Quote(HistoryTable(tableName))}
+	if where.SQL != "" {
+		e = Join(" WHERE ", e, where)
+	}
+	return e
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// TenantSetting is the session variable holding the current tenant in
+// transactions started by a dialect with a Tenant.
+const TenantSetting = "app.tenant_id"
+
+// TenantPolicyName is the name of the policy created by EnableTenantRLS.
+const TenantPolicyName = "tenant_isolation"
+
+// TenantScoped is implemented by models whose table is shared by tenants
+// and isolated with row level security, returning the column holding the
+// tenant of a row.
+type TenantScoped interface {
+	TenantColumn() string
+}
+
+// ForTenant returns a copy of the dialect whose transactions run on behalf
+// of tenant: each one starts by storing it in TenantSetting with SET LOCAL
+// semantics, so it never leaks to other users of the pooled connection. The
+// policies created by EnableTenantRLS then confine the transaction to the
+// rows of tenant. Statements run outside a transaction see no tenant, and so
+// no rows of the protected tables.
+func (s Postgres) ForTenant(tenant string) Postgres {
+	s.Tenant = tenant
+	return s
+}
+
+// setTenant stores the dialect's tenant in the transaction db.
+func (s Postgres) setTenant(db model.SQLCommon) error {
+	if s.Tenant == "" {
+		return nil
+	}
+	return setLocal(db, TenantSetting, s.Tenant)
+}
+
+// TenantPolicy returns the policy restricting the rows of a table to those
+// whose column, of type sqlType, holds the tenant in TenantSetting, for both
+// reads and writes.
+func TenantPolicy(column, sqlType string) Policy {
+	cond := fmt.Sprintf("%v = nullif(current_setting('%v', true), '')::%v",
+		Postgres{}.Quote(column), TenantSetting, sqlType)
+	return Policy{Name: TenantPolicyName, Using: cond, WithCheck: cond}
+}
+
+// EnableTenantRLS enables and forces row level security on tableName, so
+// the table owner the application usually connects as is subject to it too,
+// and (re)creates the TenantPolicy on column. Superusers and roles with
+// BYPASSRLS are not subject to policies and see every row.
+func (s Postgres) EnableTenantRLS(tableName, column string) error {
+	t, err := s.Inspector().Table(tableName)
+	if err != nil {
+		return err
+	}
+	col := t.Column(column)
+	if col == nil {
+		return errMissingColumn(tableName, column)
+	}
+	create, err := s.CreatePolicySQL(tableName, TenantPolicy(column, col.DataType))
+	if err != nil {
+		return err
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		for _, stmt := range []string{
+			fmt.Sprintf("ALTER TABLE %v ENABLE ROW LEVEL SECURITY", s.Quote(tableName)),
+			fmt.Sprintf("ALTER TABLE %v FORCE ROW LEVEL SECURITY", s.Quote(tableName)),
+			fmt.Sprintf("DROP POLICY IF EXISTS %v ON %v", s.Quote(TenantPolicyName), s.Quote(tableName)),
+			create,
+		} {
+			if _, err := db.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EnsureTenantRLS enables tenant isolation on tableName when value
+// implements TenantScoped.
+func (s Postgres) EnsureTenantRLS(tableName string, value interface{}) error {
+	if t, ok := value.(TenantScoped); ok {
+		return s.EnableTenantRLS(tableName, t.TenantColumn())
+	}
+	return nil
+}
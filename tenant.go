@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// TenantManager manages tenants isolated in schemas of their own, with
+// tables of the same names in every schema. Statements reach a tenant's
+// tables through the search_path, which lists the tenant's schema followed
+// by public, where shared tables and extensions live.
+type TenantManager struct {
+	// Prefix is prepended to tenant names to form schema names, e.g.
+	// "tenant_", so tenant schemas are told apart from the others.
+	Prefix string
+
+	// Migrate brings the schema of a tenant up to date, typically by
+	// running automigrate on db, whose search_path points at the tenant.
+	// It runs in a transaction.
+	Migrate func(db model.SQLCommon) error
+
+	s Postgres
+}
+
+// ErrNoTenantMigration is returned when migrating tenants without
+// TenantManager.Migrate.
+var ErrNoTenantMigration = errors.New("tenant manager has no migration")
+
+// Tenants returns a TenantManager working on the dialect's connection.
+func (s Postgres) Tenants(prefix string, migrate func(db model.SQLCommon) error) *TenantManager {
+	return &TenantManager{Prefix: prefix, Migrate: migrate, s: s}
+}
+
+// Schema returns the schema of tenant.
+func (m *TenantManager) Schema(tenant string) string {
+	return m.Prefix + tenant
+}
+
+func (m *TenantManager) searchPath(tenant string) []string {
+	return []string{m.Schema(tenant), "public"}
+}
+
+// List returns the tenants that have a schema, sorted. The system schemas
+// and public are never listed, even with an empty Prefix.
+func (m *TenantManager) List() ([]string, error) {
+	rows, err := m.s.DB.Query(`
+SELECT substr(nspname, length($1) + 1)
+FROM   pg_namespace
+WHERE  left(nspname, length($1)) = $1
+       AND nspname <> $1
+       AND nspname NOT LIKE 'pg\_%'
+       AND nspname NOT IN ('information_schema', 'public')
+ORDER  BY 1
+	`, m.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tenants []string
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+// Create creates the schema of tenant and migrates it, in a single
+// transaction.
+func (m *TenantManager) Create(tenant string) error {
+	if tenant == "" {
+		return errors.New("tenant name is empty")
+	}
+	return m.s.withTx(func(db model.SQLCommon) error {
+		if _, err := db.Exec("CREATE SCHEMA IF NOT EXISTS " + m.s.Quote(m.Schema(tenant))); err != nil {
+			return err
+		}
+		return m.migrate(db, tenant)
+	})
+}
+
+// Drop drops the schema of tenant along with all its data.
+func (m *TenantManager) Drop(tenant string) error {
+	_, err := m.s.DB.Exec("DROP SCHEMA IF EXISTS " + m.s.Quote(m.Schema(tenant)) + " CASCADE")
+	return err
+}
+
+// MigrateTenant migrates the schema of tenant.
+func (m *TenantManager) MigrateTenant(tenant string) error {
+	return m.s.withTx(func(db model.SQLCommon) error {
+		return m.migrate(db, tenant)
+	})
+}
+
+// MigrateAll migrates the schemas of every tenant, one transaction per
+// tenant, stopping at the first failure. Tenants migrated before it keep
+// their changes.
+func (m *TenantManager) MigrateAll() error {
+	tenants, err := m.List()
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		if err := m.MigrateTenant(tenant); err != nil {
+			return fmt.Errorf("migrate tenant %s: %v", tenant, err)
+		}
+	}
+	return nil
+}
+
+// migrate runs Migrate with the search_path of tenant. The existence checks
+// of the dialect, such as HasTable, only look at the current schema, so
+// tables of public or of other tenants are not taken for the tenant's.
+func (m *TenantManager) migrate(db model.SQLCommon, tenant string) error {
+	if m.Migrate == nil {
+		return ErrNoTenantMigration
+	}
+	if _, err := db.Exec(localSearchPathSQL(m.searchPath(tenant))); err != nil {
+		return err
+	}
+	return m.Migrate(db)
+}
+
+// In runs fn in a transaction whose search_path points at the schema of
+// tenant, e.g. for the duration of a request. The search_path is set with
+// SET LOCAL, so it never leaks to other users of the pooled connection and
+// works behind PgBouncer.
+func (m *TenantManager) In(tenant string, fn func(db model.SQLCommon) error) error {
+	return m.s.withTx(func(db model.SQLCommon) error {
+		if _, err := db.Exec(localSearchPathSQL(m.searchPath(tenant))); err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+// Conn returns a dialect bound to a connection of its own whose search_path
+// points at the schema of tenant, for work that spans several transactions.
+// The release function resets the search_path and returns the connection to
+// the pool, or closes it when the reset fails; it must be called.
+func (m *TenantManager) Conn(ctx context.Context, tenant string) (Postgres, func(), error) {
+	if m.s.PgBouncer {
+		return Postgres{}, nil, ErrPgBouncer
+	}
+	db, release, err := m.s.pin(ctx)
+	if err != nil {
+		return Postgres{}, nil, err
+	}
+	if _, err := db.Exec(searchPathSQL(m.searchPath(tenant))); err != nil {
+		release()
+		return Postgres{}, nil, err
+	}
+	s := m.s
+	s.DB = db
+	return s, func() {
+		if _, err := db.Exec("RESET search_path"); err != nil {
+			discard(db)
+		}
+		release()
+	}, nil
+}
+
+func localSearchPathSQL(schemas []string) string {
+	return "SET LOCAL" + searchPathSQL(schemas)[len("SET"):]
+}
@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// TB is the part of testing.TB used by the test database helpers.
+type TB interface {
+	Helper()
+	Cleanup(fn func())
+	Fatalf(format string, args ...interface{})
+}
+
+// TestDatabase creates databases of their own for integration tests, so
+// tests can run in parallel, even across packages, without clobbering each
+// other's data.
+type TestDatabase struct {
+	// Admin connects to an existing database, such as postgres, as a role
+	// allowed to create databases.
+	Admin Config
+
+	// Prefix starts the name of the databases. Defaults to "test".
+	Prefix string
+
+	// Migrate creates the schema of a new database, typically by running
+	// automigrate on it.
+	Migrate func(db *sql.DB) error
+
+	// Template, when set, names a template database that is created and
+	// migrated once, and cloned with CREATE DATABASE ... TEMPLATE for every
+	// test database, which is much faster than migrating each of them.
+	Template string
+
+	// TemplateVersion identifies the schema of the template, e.g. a hash of
+	// the migrations. A template built for another version is rebuilt.
+	TemplateVersion string
+}
+
+// New creates a database and returns a connection pool to it, which is
+// closed and the database dropped when the test ends. It fails the test on
+// error.
+func (d TestDatabase) New(t TB) *sql.DB {
+	t.Helper()
+	db, drop, err := d.Create()
+	if err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := drop(); err != nil {
+			t.Fatalf("drop test database: %v", err)
+		}
+	})
+	return db
+}
+
+// Create creates a database and returns a connection pool to it along with
+// a function closing the pool and dropping the database.
+func (d TestDatabase) Create() (*sql.DB, func() error, error) {
+	admin, err := d.Admin.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer admin.Close()
+	s := Postgres{}
+	s.DB = admin
+
+	name, err := d.name()
+	if err != nil {
+		return nil, nil, err
+	}
+	create := "CREATE DATABASE " + s.Quote(name)
+	if d.Template != "" {
+		if err := d.ensureTemplate(s); err != nil {
+			return nil, nil, err
+		}
+		create += " TEMPLATE " + s.Quote(d.Template)
+	}
+	if _, err := admin.Exec(create); err != nil {
+		return nil, nil, err
+	}
+	config := d.Admin
+	config.DBName = name
+	drop := func() error {
+		return d.drop(name)
+	}
+	db, err := config.Open()
+	if err != nil {
+		drop()
+		return nil, nil, err
+	}
+	if d.Migrate != nil && d.Template == "" {
+		if err := d.Migrate(db); err != nil {
+			db.Close()
+			drop()
+			return nil, nil, fmt.Errorf("migrate %s: %v", name, err)
+		}
+	}
+	return db, func() error {
+		db.Close()
+		return drop()
+	}, nil
+}
+
+// ensureTemplate creates and migrates the template database unless it
+// exists for TemplateVersion. Test binaries of several packages may run at
+// once, so the template is built under an advisory lock.
+func (d TestDatabase) ensureTemplate(admin Postgres) error {
+	lock, err := admin.AdvisoryLock(context.Background(), KeyFor("template "+d.Template))
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	var version sql.NullString
+	err = admin.DB.QueryRow(`SELECT shobj_description(oid, 'pg_database') FROM pg_database
+WHERE datname = $1`, d.Template).Scan(&version)
+	switch {
+	case err == nil && version.String == d.TemplateVersion:
+		return nil
+	case err == nil:
+		if _, err := admin.DB.Exec("ALTER DATABASE " + admin.Quote(d.Template) + " IS_TEMPLATE false"); err != nil {
+			return err
+		}
+		if err := d.drop(d.Template); err != nil {
+			return err
+		}
+	case !IsNotFound(err):
+		return err
+	}
+
+	if _, err := admin.DB.Exec("CREATE DATABASE " + admin.Quote(d.Template)); err != nil {
+		return err
+	}
+	config := d.Admin
+	config.DBName = d.Template
+	db, err := config.Open()
+	if err != nil {
+		return err
+	}
+	if d.Migrate != nil {
+		err = d.Migrate(db)
+	}
+	// A database can not be cloned while sessions are connected to it.
+	db.Close()
+	if err != nil {
+		d.drop(d.Template)
+		return fmt.Errorf("migrate template %s: %v", d.Template, err)
+	}
+	_, err = admin.DB.Exec(fmt.Sprintf("COMMENT ON DATABASE %v IS %v",
+		admin.Quote(d.Template), quoteLiteral(d.TemplateVersion)))
+	if err != nil {
+		return err
+	}
+	_, err = admin.DB.Exec("ALTER DATABASE " + admin.Quote(d.Template) + " IS_TEMPLATE true")
+	return err
+}
+
+// name returns a database name unique across processes.
+func (d TestDatabase) name() (string, error) {
+	prefix := d.Prefix
+	if prefix == "" {
+		prefix = "test"
+	}
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s_%d_%s", prefix, os.Getpid(), hex.EncodeToString(b)), nil
+}
+
+// drop drops the database name, ending the sessions still connected to it.
+func (d TestDatabase) drop(name string) error {
+	admin, err := d.Admin.Open()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+	s := Postgres{}
+	s.DB = admin
+	if _, err := admin.Exec(`SELECT pg_terminate_backend(pid) FROM pg_stat_activity
+WHERE datname = $1 AND pid <> pg_backend_pid()`, name); err != nil {
+		return err
+	}
+	_, err = admin.Exec("DROP DATABASE IF EXISTS " + s.Quote(name))
+	return err
+}
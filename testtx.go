@@ -0,0 +1,39 @@
+package postgres
+
+// TestTx returns a copy of the dialect whose connection is a transaction
+// that is rolled back when the test ends, so tests sharing a database stay
+// isolated without truncating tables. Transactions begun on the returned
+// dialect are savepoints, and committing the outer transaction is a no-op,
+// so code under test can use transactions as usual. Statements that can not
+// run in a transaction, such as CREATE INDEX CONCURRENTLY, fail.
+func (s Postgres) TestTx(t TB) Postgres {
+	t.Helper()
+	tx, err := s.Begin()
+	if err != nil {
+		t.Fatalf("begin test transaction: %v", err)
+	}
+	tx.rollbackOnly = true
+	t.Cleanup(func() {
+		tx.rollbackOnly = false
+		tx.Rollback()
+	})
+	s.DB = tx
+	return s
+}
+
+// WithRollback runs fn with a dialect whose connection is a transaction, as
+// TestTx does, and rolls it back when fn returns. The error of fn is
+// returned.
+func (s Postgres) WithRollback(fn func(s Postgres) error) error {
+	tx, err := s.Begin()
+	if err != nil {
+		return err
+	}
+	tx.rollbackOnly = true
+	defer func() {
+		tx.rollbackOnly = false
+		tx.Rollback()
+	}()
+	s.DB = tx
+	return fn(s)
+}
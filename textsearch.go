@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// Functions turning user input into a tsquery.
+const (
+	PlainQuery     = "plainto_tsquery"
+	PhraseQuery    = "phraseto_tsquery"
+	WebSearchQuery = "websearch_to_tsquery"
+	RawQuery       = "to_tsquery"
+)
+
+// TSQuery is a full text search query.
+type TSQuery struct {
+	// Text is the search input.
+	Text string
+
+	// Config is the text search configuration, e.g. "english". Defaults to
+	// the server's default_text_search_config.
+	Config string
+
+	// Parser is the function parsing Text, e.g. WebSearchQuery. Defaults to
+	// PlainQuery.
+	Parser string
+}
+
+func (q TSQuery) expr() Expr {
+	parser := q.Parser
+	if parser == "" {
+		parser = PlainQuery
+	}
+	if q.Config == "" {
+		return Expr{SQL: parser + "(?)", Args: []interface{}{q.Text}}
+	}
+	return Expr{SQL: parser + "(?::regconfig, ?)", Args: []interface{}{q.Config, q.Text}}
+}
+
+// Matches returns the predicate column @@ q, where column is a tsvector.
+func (s Postgres) Matches(column string, q TSQuery) Expr {
+	e := q.expr()
+	return Expr{SQL: fmt.Sprintf("%v @@ %v", s.Quote(column), e.SQL), Args: e.Args}
+}
+
+// MatchesText is like Matches for a text column, converted with to_tsvector
+// using the configuration of q. An expression index on the same to_tsvector
+// call is needed for it to be fast.
+func (s Postgres) MatchesText(column string, q TSQuery) Expr {
+	e := q.expr()
+	vector := s.toTSVector(column, q.Config)
+	return Expr{SQL: fmt.Sprintf("%v @@ %v", vector.SQL, e.SQL), Args: e.Args}
+}
+
+// TSRank returns ts_rank(column, q) named alias, for use in a select list or
+// as an ORDER BY key.
+func (s Postgres) TSRank(column string, q TSQuery, alias string) Expr {
+	e := q.expr()
+	return Expr{
+		SQL:  fmt.Sprintf("ts_rank(%v, %v) AS %v", s.Quote(column), e.SQL, s.Quote(alias)),
+		Args: e.Args,
+	}
+}
+
+// TSHeadline returns ts_headline over the text column document, highlighting
+// the matches of q, named alias. options is passed through as the
+// ts_headline options string, e.g. "MaxWords=20, MinWords=5".
+func (s Postgres) TSHeadline(document string, q TSQuery, options, alias string) Expr {
+	e := q.expr()
+	var args []interface{}
+	call := "ts_headline("
+	if q.Config != "" {
+		call += "?::regconfig, "
+		args = append(args, q.Config)
+	}
+	call += fmt.Sprintf("%v, %v", s.Quote(document), e.SQL)
+	args = append(args, e.Args...)
+	if options != "" {
+		call += ", ?"
+		args = append(args, options)
+	}
+	return Expr{SQL: fmt.Sprintf("%v) AS %v", call, s.Quote(alias)), Args: args}
+}
+
+func (s Postgres) toTSVector(column, config string) Expr {
+	if config == "" {
+		return Expr{SQL: fmt.Sprintf("to_tsvector(%v)", s.Quote(column))}
+	}
+	// The configuration is inlined so that the expression matches the one of
+	// an index on to_tsvector.
+	return Expr{SQL: fmt.Sprintf("to_tsvector(%v::regconfig, %v)", quoteLiteral(config), s.Quote(column))}
+}
@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// WithStatementTimeout runs fn in a transaction whose statements are
+// cancelled by the server once they run longer than timeout. The setting is
+// made with SET LOCAL semantics, so it ends with the transaction and never
+// leaks into other users of the pooled connection.
+func (s Postgres) WithStatementTimeout(timeout time.Duration, fn func(db model.SQLCommon) error) error {
+	if timeout <= 0 {
+		return errors.New("statement timeout must be positive")
+	}
+	return s.withTx(func(db model.SQLCommon) error {
+		if err := setLocal(db, "statement_timeout", durationSetting(timeout)); err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+// setLocal changes the setting name for the current transaction only.
+func setLocal(db model.SQLCommon, name, value string) error {
+	_, err := db.Exec("SELECT set_config($1, $2, true)", name, value)
+	return err
+}
+
+// durationSetting formats d for a time valued server setting, in
+// milliseconds.
+func durationSetting(d time.Duration) string {
+	ms := d / time.Millisecond
+	if d > 0 && ms == 0 {
+		ms = 1
+	}
+	return fmt.Sprintf("%dms", ms)
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// TimeZone returns an AfterConnect hook making every new connection work in
+// loc: the session TimeZone is set to it, so timestamps are formatted, and
+// dates truncated, in loc by the server, and the timestamptz values scanned
+// from the connection are in loc rather than in the server's default zone.
+// pgx is told to scan into loc. lib/pq keeps the offset the server sent,
+// which is that of loc, but may return the times in a fixed zone: call In
+// with loc when the zone itself matters, as for date arithmetic across
+// daylight saving changes.
+//
+// loc must be a named IANA zone, such as Europe/Paris, known to both the
+// server and Go; time.Local is not.
+func TimeZone(loc *time.Location) func(conn SessionConn) error {
+	set, scan := SetSession("timezone", loc.String()), scanLocation(loc)
+	return func(conn SessionConn) error {
+		if err := set(conn); err != nil {
+			return err
+		}
+		return scan(conn)
+	}
+}
+
+// scanLocation returns an AfterConnect hook making pgx scan timestamptz
+// values into loc. It does nothing on lib/pq connections.
+func scanLocation(loc *time.Location) func(conn SessionConn) error {
+	return func(conn SessionConn) error {
+		if c, ok := conn.Driver().(*stdlib.Conn); ok {
+			c.Conn().TypeMap().RegisterType(&pgtype.Type{
+				Name:  "timestamptz",
+				OID:   pgtype.TimestamptzOID,
+				Codec: &pgtype.TimestamptzCodec{ScanLocation: loc},
+			})
+		}
+		return nil
+	}
+}
+
+// SetTimeZone sets the TimeZone of the session behind the dialect's
+// connection to loc. On a pool this only affects one connection; use
+// Config.Location or the TimeZone hook to configure every connection. It
+// follows the rules of SetSearchPath in PgBouncer mode.
+func (s Postgres) SetTimeZone(loc *time.Location) error {
+	if loc == nil || loc == time.Local {
+		return errors.New("time zone must be a named location")
+	}
+	set := "SET"
+	if s.PgBouncer {
+		if _, ok := s.DB.(txBeginner); ok {
+			return ErrPgBouncer
+		}
+		set = "SET LOCAL"
+	}
+	_, err := s.DB.Exec(set + " TIME ZONE " + quoteLiteral(loc.String()))
+	return err
+}
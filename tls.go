@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TLSConfig holds the certificates used for SSL connections. Each of them is
+// given either as a file or as PEM data held in memory, e.g. read from a
+// secret store, which takes precedence.
+type TLSConfig struct {
+	// RootCert holds the certificate authorities used to verify the server
+	// with sslmode verify-ca and verify-full.
+	RootCert    string
+	RootCertPEM []byte
+
+	// Cert and Key are the client certificate and its private key.
+	Cert    string
+	CertPEM []byte
+	Key     string
+	KeyPEM  []byte
+
+	// ServerName overrides the host name verified with sslmode verify-full,
+	// e.g. when connecting through a tunnel. It requires the pgx driver.
+	ServerName string
+}
+
+// validate checks that the client certificate and its key are given
+// together.
+func (t *TLSConfig) validate() error {
+	hasCert := t.Cert != "" || len(t.CertPEM) > 0
+	hasKey := t.Key != "" || len(t.KeyPEM) > 0
+	if hasCert != hasKey {
+		return errors.New("the client certificate and its key must be set together")
+	}
+	return nil
+}
+
+func (t *TLSConfig) inline() bool {
+	return len(t.RootCertPEM) > 0 || len(t.CertPEM) > 0 || len(t.KeyPEM) > 0
+}
+
+// inlined returns a copy of t whose certificates are all held in memory
+// when any of them is, reading the others from their files.
+func (t *TLSConfig) inlined() (*TLSConfig, error) {
+	if !t.inline() {
+		return t, nil
+	}
+	c := *t
+	var err error
+	if c.RootCertPEM, err = readPEM(c.RootCert, c.RootCertPEM); err != nil {
+		return nil, err
+	}
+	if c.CertPEM, err = readPEM(c.Cert, c.CertPEM); err != nil {
+		return nil, err
+	}
+	if c.KeyPEM, err = readPEM(c.Key, c.KeyPEM); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// params returns the connection parameters for t. lib/pq takes in-memory
+// certificates with sslinline, in which case every certificate has to be
+// given as PEM: t must have been inlined.
+func (t *TLSConfig) params() map[string]string {
+	params := map[string]string{}
+	set := func(key, file string, pem []byte) {
+		if len(pem) > 0 {
+			params[key] = string(pem)
+		} else if file != "" {
+			params[key] = file
+		}
+	}
+	set("sslrootcert", t.RootCert, t.RootCertPEM)
+	set("sslcert", t.Cert, t.CertPEM)
+	set("sslkey", t.Key, t.KeyPEM)
+	if t.inline() {
+		params["sslinline"] = "true"
+	}
+	return params
+}
+
+func readPEM(file string, pem []byte) ([]byte, error) {
+	if len(pem) > 0 || file == "" {
+		return pem, nil
+	}
+	return os.ReadFile(file)
+}
+
+// tlsConfig returns the crypto/tls configuration matching sslmode for a
+// connection to host. It returns nil when sslmode disables SSL.
+func (t *TLSConfig) tlsConfig(sslmode, host string) (*tls.Config, error) {
+	if sslmode == "disable" {
+		return nil, nil
+	}
+	config := &tls.Config{}
+	certPEM, err := readPEM(t.Cert, t.CertPEM)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := readPEM(t.Key, t.KeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	rootPEM, err := readPEM(t.RootCert, t.RootCertPEM)
+	if err != nil {
+		return nil, err
+	}
+	var roots *x509.CertPool
+	if len(rootPEM) > 0 {
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(rootPEM) {
+			return nil, errors.New("invalid sslrootcert: no certificates found")
+		}
+	}
+	switch sslmode {
+	case "verify-full":
+		config.RootCAs = roots
+		config.ServerName = host
+		if t.ServerName != "" {
+			config.ServerName = t.ServerName
+		}
+		return config, nil
+	case "verify-ca", "require":
+		// Like libpq, require verifies the chain when a root certificate is
+		// given. Neither checks the host name.
+		config.InsecureSkipVerify = true
+		if sslmode == "require" && roots == nil {
+			return config, nil
+		}
+		if roots == nil {
+			return nil, errors.New("sslmode verify-ca needs a root certificate")
+		}
+		config.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(raw, roots)
+		}
+		return config, nil
+	}
+	// allow and prefer use SSL when the server supports it, without
+	// verification.
+	config.InsecureSkipVerify = true
+	return config, nil
+}
+
+func verifyChain(raw [][]byte, roots *x509.CertPool) error {
+	if len(raw) == 0 {
+		return errors.New("server presented no certificate")
+	}
+	certs := make([]*x509.Certificate, len(raw))
+	for i, b := range raw {
+		c, err := x509.ParseCertificate(b)
+		if err != nil {
+			return err
+		}
+		certs[i] = c
+	}
+	opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// pgxTLS applies t to a pgx configuration parsed with sslmode.
+func (t *TLSConfig) pgxTLS(sslmode string) func(*pgx.ConnConfig) error {
+	return func(config *pgx.ConnConfig) error {
+		tc, err := t.tlsConfig(sslmode, config.Host)
+		if err != nil {
+			return err
+		}
+		config.TLSConfig = tc
+		for _, f := range config.Fallbacks {
+			if f.TLSConfig == nil {
+				continue
+			}
+			if f.TLSConfig, err = t.tlsConfig(sslmode, f.Host); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
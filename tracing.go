@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ngorm/ngorm/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the OpenTelemetry tracer used by TracedDB.
+const TracerName = "github.com/ngorm/postgres"
+
+// TracedDB is a connection pool recording an OpenTelemetry span for every
+// statement, with the db.system and db.statement attributes and the SQLSTATE
+// of failed statements. Spans are children of the span in the context given
+// to the *Context methods or to Postgres.WithContext.
+type TracedDB struct {
+	db     *sql.DB
+	tracer trace.Tracer
+}
+
+// NewTracedDB returns a TracedDB over db using a tracer from tp.
+func NewTracedDB(db *sql.DB, tp trace.TracerProvider) *TracedDB {
+	return &TracedDB{db: db, tracer: tp.Tracer(TracerName)}
+}
+
+func startSpan(ctx context.Context, tracer trace.Tracer, name, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("db.system", "postgresql")}
+	if query != "" {
+		attrs = append(attrs, attribute.String("db.statement", query))
+		name = operation(query)
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if code := sqlState(err); code != "" {
+			span.SetAttributes(attribute.String("db.postgresql.sqlstate", code))
+		}
+	}
+	span.End()
+}
+
+// ExecContext runs query in a span.
+func (t *TracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(ctx, t.tracer, "", query)
+	res, err := t.db.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return res, err
+}
+
+// PrepareContext prepares query in a span.
+func (t *TracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startSpan(ctx, t.tracer, "", query)
+	stmt, err := t.db.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+// QueryContext runs query in a span, which ends once the query returns its
+// first rows.
+func (t *TracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startSpan(ctx, t.tracer, "", query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs query in a span. Errors are reported by Scan and are
+// not recorded.
+func (t *TracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startSpan(ctx, t.tracer, "", query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
+
+// Exec implements model.SQLCommon.
+func (t *TracedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+// Prepare implements model.SQLCommon.
+func (t *TracedDB) Prepare(query string) (*sql.Stmt, error) {
+	return t.PrepareContext(context.Background(), query)
+}
+
+// Query implements model.SQLCommon.
+func (t *TracedDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow implements model.SQLCommon.
+func (t *TracedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+// Begin starts a transaction. Its statements are not traced; use
+// Transaction for that.
+func (t *TracedDB) Begin() (*sql.Tx, error) {
+	return t.db.Begin()
+}
+
+// BeginTx is like Begin with a context.
+func (t *TracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return t.db.BeginTx(ctx, opts)
+}
+
+// Conn returns a dedicated connection from the pool.
+func (t *TracedDB) Conn(ctx context.Context) (*sql.Conn, error) {
+	return t.db.Conn(ctx)
+}
+
+// Transaction runs fn in a transaction recorded as a span, which is the
+// parent of the spans of the statements fn runs on db. The transaction is
+// committed if fn succeeds and rolled back otherwise.
+func (t *TracedDB) Transaction(ctx context.Context, fn func(db model.SQLCommon) error) (err error) {
+	ctx, span := startSpan(ctx, t.tracer, "transaction", "")
+	defer func() { endSpan(span, err) }()
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tracedTx{ctx: ctx, tx: tx, tracer: t.tracer}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// tracedTx records a span for every statement of a transaction.
+type tracedTx struct {
+	ctx    context.Context
+	tx     *sql.Tx
+	tracer trace.Tracer
+}
+
+func (t tracedTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startSpan(t.ctx, t.tracer, "", query)
+	res, err := t.tx.ExecContext(ctx, query, args...)
+	endSpan(span, err)
+	return res, err
+}
+
+func (t tracedTx) Prepare(query string) (*sql.Stmt, error) {
+	ctx, span := startSpan(t.ctx, t.tracer, "", query)
+	stmt, err := t.tx.PrepareContext(ctx, query)
+	endSpan(span, err)
+	return stmt, err
+}
+
+func (t tracedTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startSpan(t.ctx, t.tracer, "", query)
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	endSpan(span, err)
+	return rows, err
+}
+
+func (t tracedTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, span := startSpan(t.ctx, t.tracer, "", query)
+	row := t.tx.QueryRowContext(ctx, query, args...)
+	endSpan(span, row.Err())
+	return row
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// Tree describes a traversal of a self referencing table, such as categories
+// or an org chart, for TreeQuery.
+type Tree struct {
+	Table string
+
+	// ID and ParentID name the key and parent columns. They default to id and
+	// parent_id.
+	ID       string
+	ParentID string
+
+	// Start selects the rows the traversal starts from, e.g.
+	// Raw("id = ?", 42). It defaults to the roots, where the parent is NULL.
+	Start Expr
+
+	// Ancestors walks from the start rows towards the roots instead of
+	// towards the leaves.
+	Ancestors bool
+
+	// MaxDepth limits the traversal depth when greater than zero.
+	MaxDepth int
+}
+
+// TreeQuery returns a WITH RECURSIVE query over the rows of t.Table reachable
+// from the start rows. Every row carries two extra columns: depth, counting
+// from 0 at the start rows, and path, the array of ids leading to the row.
+// Rows are ordered by path, so children follow their parents. Cycles in the
+// data are detected through the path and do not loop forever.
+func (s Postgres) TreeQuery(t Tree) Expr {
+	id, parent := t.ID, t.ParentID
+	if id == "" {
+		id = "id"
+	}
+	if parent == "" {
+		parent = "parent_id"
+	}
+	start := t.Start
+	if start.SQL == "" {
+		start = Expr{SQL: s.Quote(parent) + " IS NULL"}
+	}
+	table, name := s.Quote(t.Table), s.Quote("tree")
+	join := fmt.Sprintf("c.%v = %v.%v", s.Quote(parent), name, s.Quote(id))
+	if t.Ancestors {
+		join = fmt.Sprintf("c.%v = %v.%v", s.Quote(id), name, s.Quote(parent))
+	}
+	recurse := fmt.Sprintf("NOT c.%v = ANY(%v.path)", s.Quote(id), name)
+	if t.MaxDepth > 0 {
+		recurse += fmt.Sprintf(" AND %v.depth < %d", name, t.MaxDepth)
+	}
+	body := Join(" ",
+		Expr{SQL: fmt.Sprintf("SELECT %v.*, 0 AS depth, ARRAY[%v.%v] AS path FROM %v WHERE",
+			table, table, s.Quote(id), table)},
+		start,
+		Expr{SQL: fmt.Sprintf("UNION ALL SELECT c.*, %v.depth + 1, %v.path || c.%v FROM %v c JOIN %v ON %v WHERE %v",
+			name, name, s.Quote(id), table, name, join, recurse)},
+	)
+	return s.WithRecursive(
+		Expr{SQL: fmt.Sprintf("SELECT * FROM %v ORDER BY path", name)},
+		CTE{Name: "tree", Query: body},
+	)
+}
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Trigger timings.
+const (
+	Before    = "BEFORE"
+	After     = "AFTER"
+	InsteadOf = "INSTEAD OF"
+)
+
+// Trigger events.
+const (
+	OnInsert   = "INSERT"
+	OnUpdate   = "UPDATE"
+	OnDelete   = "DELETE"
+	OnTruncate = "TRUNCATE"
+)
+
+// Trigger declares a trigger attached to a table.
+type Trigger struct {
+	Name string
+
+	// Timing is one of Before, After or InsteadOf.
+	Timing string
+
+	// Events lists the events that fire the trigger.
+	Events []string
+
+	// ForEachRow fires the trigger once per affected row instead of once per
+	// statement.
+	ForEachRow bool
+
+	// When is an optional condition, e.g. "OLD.* IS DISTINCT FROM NEW.*".
+	When string
+
+	// Function is the name of the trigger function to execute.
+	Function string
+
+	// Args are passed to the trigger function as string literals.
+	Args []string
+}
+
+// TriggerDefiner is implemented by models that declare triggers on their
+// table.
+type TriggerDefiner interface {
+	Triggers() []Trigger
+}
+
+// CreateTriggerSQL returns the CREATE TRIGGER statement attaching t to
+// tableName.
+func (s Postgres) CreateTriggerSQL(tableName string, t Trigger) (string, error) {
+	if t.Name == "" || t.Function == "" {
+		return "", errors.New("trigger name and function are required")
+	}
+	if len(t.Events) == 0 {
+		return "", fmt.Errorf("trigger %s has no events", t.Name)
+	}
+	timing := strings.ToUpper(t.Timing)
+	switch timing {
+	case Before, After, InsteadOf:
+	default:
+		return "", fmt.Errorf("invalid trigger timing %s", t.Timing)
+	}
+	level := "STATEMENT"
+	if t.ForEachRow {
+		level = "ROW"
+	}
+	var when string
+	if t.When != "" {
+		when = fmt.Sprintf(" WHEN (%v)", t.When)
+	}
+	args := make([]string, len(t.Args))
+	for i, a := range t.Args {
+		args[i] = quoteLiteral(a)
+	}
+	return fmt.Sprintf("CREATE TRIGGER %v %v %v ON %v FOR EACH %v%v EXECUTE PROCEDURE %v(%v)",
+		s.Quote(t.Name), timing, strings.Join(t.Events, " OR "), s.Quote(tableName),
+		level, when, t.Function, strings.Join(args, ", ")), nil
+}
+
+// CreateTrigger attaches t to tableName.
+func (s Postgres) CreateTrigger(tableName string, t Trigger) error {
+	query, err := s.CreateTriggerSQL(tableName, t)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
+
+// DropTrigger removes the trigger triggerName from tableName if it exists.
+func (s Postgres) DropTrigger(tableName, triggerName string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS %v ON %v",
+		s.Quote(triggerName), s.Quote(tableName)))
+	return err
+}
+
+// HasTrigger reports whether tableName has a user defined trigger named
+// triggerName.
+func (s Postgres) HasTrigger(tableName string, triggerName string) bool {
+	ok, _ := s.TriggerExists(tableName, triggerName)
+	return ok
+}
+
+// TriggerExists is like HasTrigger but reports the errors of the lookup.
+func (s Postgres) TriggerExists(tableName string, triggerName string) (bool, error) {
+	query := `
+SELECT Count(*)
+FROM   pg_trigger t
+       JOIN pg_class c ON c.oid = t.tgrelid
+       JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE  n.nspname = current_schema()
+       AND c.relname = $1
+       AND t.tgname = $2
+       AND NOT t.tgisinternal
+	`
+	return s.exists(query, tableName, triggerName)
+}
+
+// EnsureTriggers creates the triggers declared by value on tableName that do
+// not exist yet. Values that don't implement TriggerDefiner are ignored.
+func (s Postgres) EnsureTriggers(tableName string, value interface{}) error {
+	d, ok := value.(TriggerDefiner)
+	if !ok {
+		return nil
+	}
+	for _, t := range d.Triggers() {
+		ok, err := s.TriggerExists(tableName, t.Name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+		if err := s.CreateTrigger(tableName, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteLiteral quotes s as a string constant. Backslashes are escaped in an
+// E'...' constant, which reads the same whatever standard_conforming_strings
+// is.
+func quoteLiteral(s string) string {
+	s = strings.Replace(s, "'", "''", -1)
+	if strings.Contains(s, `\`) {
+		return "E'" + strings.Replace(s, `\`, `\\`, -1) + "'"
+	}
+	return "'" + s + "'"
+}
+
+func errMissingColumn(tableName, columnName string) error {
+	return fmt.Errorf("table %s has no column %s", tableName, columnName)
+}
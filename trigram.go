@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"fmt"
+)
+
+// TrigramExtension is the extension providing trigram matching.
+const TrigramExtension = "pg_trgm"
+
+// TrigramIndexSQL returns the statement creating a GIN trigram index named
+// indexName on column of tableName. Such an index speeds up similarity
+// searches as well as LIKE and ILIKE with leading wildcards.
+//
+// Model fields can declare the same index with the TRGM_INDEX tag setting.
+func (s Postgres) TrigramIndexSQL(tableName, column, indexName string) string {
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %v ON %v USING gin (%v gin_trgm_ops)",
+		s.Quote(indexName), s.Quote(tableName), s.Quote(column))
+}
+
+// CreateTrigramIndex installs pg_trgm if needed and creates a GIN trigram
+// index on column of tableName.
+func (s Postgres) CreateTrigramIndex(tableName, column, indexName string) error {
+	if err := s.EnsureExtension(TrigramExtension); err != nil {
+		return err
+	}
+	_, err := s.DB.Exec(s.TrigramIndexSQL(tableName, column, indexName))
+	return err
+}
+
+// Similar returns the predicate column % text, true when the similarity of
+// the two exceeds pg_trgm.similarity_threshold (0.3 unless changed).
+func (s Postgres) Similar(column, text string) Expr {
+	return Expr{SQL: fmt.Sprintf("%v %% ?", s.Quote(column)), Args: []interface{}{text}}
+}
+
+// SimilarAbove returns a predicate true when the similarity of column and
+// text exceeds threshold. Unlike Similar it does not depend on session state,
+// but it can not use a trigram index on its own; combine the two when the
+// table is large.
+func (s Postgres) SimilarAbove(column, text string, threshold float64) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("similarity(%v, ?) > ?", s.Quote(column)),
+		Args: []interface{}{text, threshold},
+	}
+}
+
+// Similarity returns similarity(column, text) named alias, for use in a
+// select list or as an ORDER BY key.
+func (s Postgres) Similarity(column, text, alias string) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("similarity(%v, ?) AS %v", s.Quote(column), s.Quote(alias)),
+		Args: []interface{}{text},
+	}
+}
+
+// SetSimilarityThreshold sets pg_trgm.similarity_threshold, used by the %
+// operator, for the session of the dialect's connection. It follows the
+// rules of SetSearchPath in PgBouncer mode.
+func (s Postgres) SetSimilarityThreshold(threshold float64) error {
+	if threshold < 0 || threshold > 1 {
+		return fmt.Errorf("similarity threshold %v is out of range", threshold)
+	}
+	local := false
+	if s.PgBouncer {
+		if _, ok := s.DB.(txBeginner); ok {
+			return ErrPgBouncer
+		}
+		local = true
+	}
+	_, err := s.DB.Exec("SELECT set_config('pg_trgm.similarity_threshold', $1, $2)",
+		fmt.Sprint(threshold), local)
+	return err
+}
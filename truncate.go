@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TruncateOptions control Truncate.
+type TruncateOptions struct {
+	// RestartIdentity resets the sequences owned by the tables' columns.
+	RestartIdentity bool
+
+	// Cascade also truncates tables referencing the given ones through
+	// foreign keys.
+	Cascade bool
+}
+
+// TruncateSQL returns the TRUNCATE statement emptying tables.
+func (s Postgres) TruncateSQL(opts TruncateOptions, tables ...string) (string, error) {
+	if len(tables) == 0 {
+		return "", errors.New("truncate needs at least one table")
+	}
+	query := fmt.Sprintf("TRUNCATE TABLE %v", s.quoteColumns(tables))
+	if opts.RestartIdentity {
+		query += " RESTART IDENTITY"
+	}
+	if opts.Cascade {
+		query += " CASCADE"
+	}
+	return query, nil
+}
+
+// Truncate empties tables in a single statement, which is much faster than
+// deleting their rows.
+func (s Postgres) Truncate(opts TruncateOptions, tables ...string) error {
+	query, err := s.TruncateSQL(opts, tables...)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.Exec(query)
+	return err
+}
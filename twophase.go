@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"errors"
+	"time"
+)
+
+// PreparedTransaction is a transaction prepared for two-phase commit and
+// waiting to be committed or rolled back.
+type PreparedTransaction struct {
+	GID      string
+	Prepared time.Time
+	Owner    string
+	Database string
+}
+
+// PrepareTransaction prepares t for two-phase commit under the global
+// identifier gid. Afterwards t is finished: its connection is detached from
+// the prepared transaction, which survives crashes until CommitPrepared or
+// RollbackPrepared is called with the same gid, from any session.
+//
+// The server must run with max_prepared_transactions greater than zero.
+func (t *Tx) PrepareTransaction(gid string) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if t.Nested() {
+		return errors.New("a nested transaction can not be prepared")
+	}
+	if _, err := t.Exec("PREPARE TRANSACTION " + quoteLiteral(gid)); err != nil {
+		return err
+	}
+	t.done = true
+	// The session is no longer in a transaction, so the driver reports an
+	// error here and discards the connection; the prepared transaction is
+	// unaffected.
+	t.Tx.Rollback()
+	return nil
+}
+
+// CommitPrepared commits the prepared transaction gid.
+func (s Postgres) CommitPrepared(gid string) error {
+	_, err := s.DB.Exec("COMMIT PREPARED " + quoteLiteral(gid))
+	return err
+}
+
+// RollbackPrepared rolls back the prepared transaction gid.
+func (s Postgres) RollbackPrepared(gid string) error {
+	_, err := s.DB.Exec("ROLLBACK PREPARED " + quoteLiteral(gid))
+	return err
+}
+
+// PreparedTransactions lists the transactions prepared in the current
+// database, for a transaction manager recovering after a crash.
+func (s Postgres) PreparedTransactions() ([]PreparedTransaction, error) {
+	rows, err := s.DB.Query(`
+SELECT gid, prepared, owner, database
+FROM   pg_prepared_xacts
+WHERE  database = current_database()
+ORDER  BY prepared
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var txs []PreparedTransaction
+	for rows.Next() {
+		var p PreparedTransaction
+		if err := rows.Scan(&p.GID, &p.Prepared, &p.Owner, &p.Database); err != nil {
+			return nil, err
+		}
+		txs = append(txs, p)
+	}
+	return txs, rows.Err()
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// txBeginner is implemented by connection pools such as *sql.DB.
+type txBeginner interface {
+	Begin() (*sql.Tx, error)
+}
+
+// withTx runs fn inside a transaction. When the dialect's connection already
+// is a transaction fn runs in it directly; otherwise a transaction is started
+// and committed if fn succeeds or rolled back if it fails.
+func (s Postgres) withTx(fn func(db model.SQLCommon) error) error {
+	b, ok := s.DB.(txBeginner)
+	if !ok {
+		return fn(s.DB)
+	}
+	tx, err := b.Begin()
+	if err != nil {
+		return err
+	}
+	return s.runTx(tx, tx, fn)
+}
+
+// runTx runs fn on db, which issues its statements in tx, and commits tx if
+// fn succeeds or rolls it back if it fails.
+func (s Postgres) runTx(tx *sql.Tx, db model.SQLCommon, fn func(db model.SQLCommon) error) error {
+	if err := s.setTenant(db); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := fn(db); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Tx is a transaction that can be nested: beginning a transaction inside it
+// creates a savepoint, whose commit releases it and whose rollback undoes
+// only the work done since it was created.
+type Tx struct {
+	*sql.Tx
+
+	savepoint string
+	seq       *int
+	done      bool
+
+	// rollbackOnly makes Commit keep the transaction open, see TestTx.
+	rollbackOnly bool
+}
+
+// ErrTxDone is returned when committing or rolling back a Tx twice.
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// Begin starts a transaction on the dialect's connection. When the
+// connection already is a *sql.Tx the returned Tx is a savepoint inside it.
+func (s Postgres) Begin() (*Tx, error) {
+	tx, err := s.begin()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.setTenant(tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (s Postgres) begin() (*Tx, error) {
+	switch db := s.DB.(type) {
+	case *Tx:
+		return db.Begin()
+	case *sql.Tx:
+		return (&Tx{Tx: db, seq: new(int)}).Begin()
+	case ctxDB:
+		if tx, ok := db.db.(*sql.Tx); ok {
+			return (&Tx{Tx: tx, seq: new(int)}).Begin()
+		}
+	case txBeginner:
+		tx, err := db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		return &Tx{Tx: tx, seq: new(int)}, nil
+	}
+	return nil, fmt.Errorf("can not begin a transaction on %T", s.DB)
+}
+
+// Begin creates a savepoint and returns it as a nested transaction.
+func (t *Tx) Begin() (*Tx, error) {
+	*t.seq++
+	name := fmt.Sprintf("ngorm_sp_%d", *t.seq)
+	if _, err := t.Exec("SAVEPOINT " + name); err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: t.Tx, savepoint: name, seq: t.seq}, nil
+}
+
+// Nested reports whether t is a savepoint inside another transaction.
+func (t *Tx) Nested() bool {
+	return t.savepoint != ""
+}
+
+// Commit commits the transaction, or releases the savepoint of a nested one.
+func (t *Tx) Commit() error {
+	if t.done {
+		return ErrTxDone
+	}
+	if t.Nested() {
+		t.done = true
+		_, err := t.Exec("RELEASE SAVEPOINT " + t.savepoint)
+		return err
+	}
+	if t.rollbackOnly {
+		return nil
+	}
+	t.done = true
+	return t.Tx.Commit()
+}
+
+// Rollback aborts the transaction, or rolls back to the savepoint of a
+// nested one leaving the enclosing transaction usable.
+func (t *Tx) Rollback() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	if t.Nested() {
+		_, err := t.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+		return err
+	}
+	return t.Tx.Rollback()
+}
+
+// Transaction runs fn in a transaction, nested in the dialect's transaction
+// if it has one. The transaction is committed when fn returns nil and rolled
+// back otherwise, including when fn panics.
+func (s Postgres) Transaction(fn func(tx *Tx) error) error {
+	tx, err := s.Begin()
+	if err != nil {
+		return err
+	}
+	return runTx(tx, fn)
+}
+
+// Transaction runs fn in a savepoint of t, as Postgres.Transaction does.
+func (t *Tx) Transaction(fn func(tx *Tx) error) error {
+	nested, err := t.Begin()
+	if err != nil {
+		return err
+	}
+	return runTx(nested, fn)
+}
+
+func runTx(tx *Tx, fn func(tx *Tx) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Savepoint creates the savepoint name in the dialect's transaction.
+func (s Postgres) Savepoint(name string) error {
+	_, err := s.DB.Exec("SAVEPOINT " + s.Quote(name))
+	return err
+}
+
+// RollbackToSavepoint undoes the work done since the savepoint name was
+// created.
+func (s Postgres) RollbackToSavepoint(name string) error {
+	_, err := s.DB.Exec("ROLLBACK TO SAVEPOINT " + s.Quote(name))
+	return err
+}
+
+// ReleaseSavepoint forgets the savepoint name, keeping the work done since.
+func (s Postgres) ReleaseSavepoint(name string) error {
+	_, err := s.DB.Exec("RELEASE SAVEPOINT " + s.Quote(name))
+	return err
+}
+
+// Transaction isolation levels.
+const (
+	ReadCommitted  = "READ COMMITTED"
+	RepeatableRead = "REPEATABLE READ"
+	Serializable   = "SERIALIZABLE"
+)
+
+// TxOptions are the characteristics of a transaction started by BeginTx.
+type TxOptions struct {
+	// Isolation is one of ReadCommitted, RepeatableRead or Serializable.
+	// The server default is used when empty.
+	Isolation string
+
+	// ReadOnly rejects writes to tables that are not temporary.
+	ReadOnly bool
+
+	// Deferrable, together with Serializable and ReadOnly, waits for a
+	// snapshot that is guaranteed free of serialization conflicts. Such a
+	// transaction can never fail with a serialization error and does not
+	// burden writers, which suits long running consistent reports.
+	Deferrable bool
+}
+
+func (o TxOptions) sql() (string, error) {
+	var modes []string
+	switch o.Isolation {
+	case "":
+	case ReadCommitted, RepeatableRead, Serializable:
+		modes = append(modes, "ISOLATION LEVEL "+o.Isolation)
+	default:
+		return "", fmt.Errorf("invalid isolation level %q", o.Isolation)
+	}
+	if o.ReadOnly {
+		modes = append(modes, "READ ONLY")
+	}
+	if o.Deferrable {
+		if o.Isolation != Serializable || !o.ReadOnly {
+			return "", errors.New("deferrable transactions must be serializable and read only")
+		}
+		modes = append(modes, "DEFERRABLE")
+	}
+	if len(modes) == 0 {
+		return "", nil
+	}
+	return "SET TRANSACTION " + strings.Join(modes, " "), nil
+}
+
+// ReadOnlySnapshot returns the options of a serializable, read only,
+// deferrable transaction.
+func ReadOnlySnapshot() TxOptions {
+	return TxOptions{Isolation: Serializable, ReadOnly: true, Deferrable: true}
+}
+
+// BeginTx starts a transaction with the characteristics in opts. They are
+// applied as the first statement of the transaction, so they can not be used
+// when the dialect's connection already is a transaction. On a Cluster, read
+// only transactions run on a replica.
+func (s Postgres) BeginTx(opts TxOptions) (*Tx, error) {
+	set, err := opts.sql()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := s.DB.(*Cluster); ok && opts.ReadOnly {
+		s.DB = c.Replica()
+	}
+	if _, ok := s.DB.(txBeginner); !ok && set != "" {
+		return nil, errors.New("transaction characteristics can not be changed in a nested transaction")
+	}
+	tx, err := s.begin()
+	if err != nil {
+		return nil, err
+	}
+	if set != "" {
+		if _, err := tx.Exec(set); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := s.setTenant(tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// TransactionTx is like Transaction but starts the transaction with opts.
+func (s Postgres) TransactionTx(opts TxOptions, fn func(tx *Tx) error) error {
+	tx, err := s.BeginTx(opts)
+	if err != nil {
+		return err
+	}
+	return runTx(tx, fn)
+}
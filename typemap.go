@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// TypeMapper maps a Go type that is neither a sql.Scanner nor a
+// driver.Valuer, such as big.Rat or the decimal type of another package, to
+// a column type. Values go through their text form both ways.
+type TypeMapper struct {
+	// SQLType is the column type of fields of the type, e.g. "numeric".
+	SQLType string
+
+	// Parse returns the value of the type, not a pointer to it, for the
+	// text form of a column value.
+	Parse func(text string) (interface{}, error)
+
+	// Format returns the text form of v, a value of the type.
+	Format func(v interface{}) (string, error)
+}
+
+// typeMappers holds the registered TypeMappers by type.
+var typeMappers sync.Map
+
+// RegisterType registers m for the type of sample. Fields of that type, or
+// pointers to it, are then created with m.SQLType, scanned by ScanRows and
+// written by the batch helpers of the dialect. ngorm itself passes the
+// field values to the driver as they are, so they can only be written
+// through the dialect.
+func RegisterType(sample interface{}, m TypeMapper) {
+	t := reflect.TypeOf(sample)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	typeMappers.Store(t, m)
+}
+
+// mapperOf returns the TypeMapper of t or of the type t points to.
+func mapperOf(t reflect.Type) (TypeMapper, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	m, ok := typeMappers.Load(t)
+	if !ok {
+		return TypeMapper{}, false
+	}
+	return m.(TypeMapper), true
+}
+
+// mappedScanner scans a column into the field v of a registered type.
+type mappedScanner struct {
+	v reflect.Value
+	m TypeMapper
+}
+
+func (s mappedScanner) Scan(src interface{}) error {
+	var text string
+	switch x := src.(type) {
+	case nil:
+		s.v.Set(reflect.Zero(s.v.Type()))
+		return nil
+	case []byte:
+		text = string(x)
+	case string:
+		text = x
+	case float64:
+		text = strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		text = fmt.Sprint(x)
+	}
+	x, err := s.m.Parse(text)
+	if err != nil {
+		return err
+	}
+	value := reflect.ValueOf(x)
+	if s.v.Kind() == reflect.Ptr {
+		p := reflect.New(s.v.Type().Elem())
+		p.Elem().Set(value)
+		value = p
+	}
+	s.v.Set(value)
+	return nil
+}
+
+// mappedValuer writes v, a value of a registered type or a pointer to one.
+type mappedValuer struct {
+	v interface{}
+	m TypeMapper
+}
+
+// Value implements driver.Valuer.
+func (w mappedValuer) Value() (driver.Value, error) {
+	v := reflect.ValueOf(w.v)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	return w.m.Format(v.Interface())
+}
+
+// fieldValue returns the value written for the field v.
+func fieldValue(v reflect.Value) interface{} {
+	if m, ok := mapperOf(v.Type()); ok {
+		return mappedValuer{v: v.Interface(), m: m}
+	}
+	return v.Interface()
+}
+
+// driverValue returns the value v stands for, calling driver.Valuer and
+// following pointers, nil for nil pointers.
+func driverValue(v interface{}) (interface{}, error) {
+	for {
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, nil
+		}
+		if valuer, ok := v.(driver.Valuer); ok {
+			x, err := valuer.Value()
+			if err != nil {
+				return nil, err
+			}
+			v = x
+			continue
+		}
+		if rv.Kind() != reflect.Ptr {
+			return v, nil
+		}
+		v = rv.Elem().Interface()
+	}
+}
+
+// fieldScanner returns the scanner of the fields of type t that can not be
+// scanned into directly, nil for the others.
+func fieldScanner(t reflect.Type) func(field reflect.Value) sql.Scanner {
+	if m, ok := mapperOf(t); ok {
+		return func(field reflect.Value) sql.Scanner { return mappedScanner{v: field, m: m} }
+	}
+	if isNestedType(t) {
+		return func(field reflect.Value) sql.Scanner { return aggScanner{field} }
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+)
+
+// arrayElemType returns the Postgres element type of a Go slice bound with
+// pq.Array.
+func arrayElemType(values interface{}) (string, error) {
+	t := reflect.TypeOf(values)
+	if t == nil || t.Kind() != reflect.Slice {
+		return "", fmt.Errorf("expected a slice, got %T", values)
+	}
+	switch t.Elem().Kind() {
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "bigint", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint16:
+		return "integer", nil
+	case reflect.Float32, reflect.Float64:
+		return "double precision", nil
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.String:
+		return "text", nil
+	}
+	return "", fmt.Errorf("can not infer the array type of %T", values)
+}
+
+// Unnest returns unnest(values) WITH ORDINALITY as the relation alias with
+// the columns column and ord, where ord is the 1 based position of the value
+// in values. values is a slice bound as a single array parameter; its element
+// type is inferred from the Go type.
+func (s Postgres) Unnest(values interface{}, alias, column string) (Expr, error) {
+	typ, err := arrayElemType(values)
+	if err != nil {
+		return Expr{}, err
+	}
+	return Expr{
+		SQL: fmt.Sprintf("unnest(?::%v[]) WITH ORDINALITY AS %v(%v, ord)",
+			typ, s.Quote(alias), s.Quote(column)),
+		Args: []interface{}{pq.Array(values)},
+	}, nil
+}
+
+// JoinUnnest returns a JOIN of the unnested values on column, for the query
+// builder's Joins. Ordering by alias.ord returns rows in the order of values:
+//
+//	j, _ := s.JoinUnnest("users", "id", ids, "input")
+//	db.Joins(j.SQL, j.Args...).Order("input.ord").Find(&users)
+func (s Postgres) JoinUnnest(tableName, column string, values interface{}, alias string) (Expr, error) {
+	u, err := s.Unnest(values, alias, column)
+	if err != nil {
+		return Expr{}, err
+	}
+	u.SQL = fmt.Sprintf("JOIN %v ON %v.%v = %v.%v", u.SQL,
+		s.Quote(tableName), s.Quote(column), s.Quote(alias), s.Quote(column))
+	return u, nil
+}
+
+// FindByKeys returns a query selecting the rows of tableName whose column is
+// one of values, in the order of values. Values without a matching row are
+// skipped.
+func (s Postgres) FindByKeys(tableName, column string, values interface{}) (Expr, error) {
+	j, err := s.JoinUnnest(tableName, column, values, "input")
+	if err != nil {
+		return Expr{}, err
+	}
+	return Join(" ",
+		Expr{SQL: fmt.Sprintf("SELECT %v.* FROM %v", s.Quote(tableName), s.Quote(tableName))},
+		j,
+		Expr{SQL: fmt.Sprintf("ORDER BY %v.ord", s.Quote("input"))},
+	), nil
+}
@@ -0,0 +1,55 @@
+package postgres
+
+// UpdatedAtFunction is the name of the trigger function maintaining
+// updated_at columns.
+const UpdatedAtFunction = "set_updated_at"
+
+const updatedAtFunctionSQL = `
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS $$
+BEGIN
+	NEW.updated_at = now();
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql
+`
+
+// UpdatedAtTrigger returns the BEFORE UPDATE trigger that keeps the
+// updated_at column of tableName current.
+func UpdatedAtTrigger(tableName string) Trigger {
+	return Trigger{
+		Name:       tableName + "_set_updated_at",
+		Timing:     Before,
+		Events:     []string{OnUpdate},
+		ForEachRow: true,
+		Function:   UpdatedAtFunction,
+	}
+}
+
+// InstallUpdatedAtFunction creates or replaces the set_updated_at() trigger
+// function.
+func (s Postgres) InstallUpdatedAtFunction() error {
+	_, err := s.DB.Exec(updatedAtFunctionSQL)
+	return err
+}
+
+// EnsureUpdatedAt installs the set_updated_at() function and attaches the
+// UpdatedAtTrigger to tableName, so updated_at is maintained even for rows
+// modified outside the ORM. The table must have an updated_at column.
+func (s Postgres) EnsureUpdatedAt(tableName string) error {
+	ok, err := s.ColumnExists(tableName, "updated_at")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errMissingColumn(tableName, "updated_at")
+	}
+	if err := s.InstallUpdatedAtFunction(); err != nil {
+		return err
+	}
+	t := UpdatedAtTrigger(tableName)
+	ok, err = s.TriggerExists(tableName, t.Name)
+	if err != nil || ok {
+		return err
+	}
+	return s.CreateTrigger(tableName, t)
+}
@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// OnConflict describes the ON CONFLICT clause of an insert.
+type OnConflict struct {
+	// Columns is the conflict target. It is mutually exclusive with
+	// Constraint.
+	Columns []string
+
+	// Where is the predicate of a partial unique index used as the conflict
+	// target.
+	Where string
+
+	// Constraint names the constraint used as the conflict target.
+	Constraint string
+
+	// DoNothing skips conflicting rows.
+	DoNothing bool
+
+	// Update lists the columns that are overwritten with the values of the
+	// row proposed for insertion.
+	Update []string
+
+	// Set lists additional assignments made on conflict.
+	Set []Assignment
+
+	// OnlyChanged skips the update when every column of Update already
+	// holds the proposed value, so no-op upserts neither rewrite the row nor
+	// fire its update triggers. Skipped rows are not counted as affected.
+	OnlyChanged bool
+}
+
+// Assignment sets Column to Value. Value is bound as a parameter, unless it
+// is an Expr which is inlined along with its arguments.
+type Assignment struct {
+	Column string
+	Value  interface{}
+}
+
+func (s Postgres) assignment(a Assignment) Expr {
+	if e, ok := a.Value.(Expr); ok {
+		return Expr{SQL: fmt.Sprintf("%v = %v", s.Quote(a.Column), e.SQL), Args: e.Args}
+	}
+	return Expr{SQL: fmt.Sprintf("%v = ?", s.Quote(a.Column)), Args: []interface{}{a.Value}}
+}
+
+// OnConflictClause returns the ON CONFLICT clause for c, for an insert into
+// tableName.
+func (s Postgres) OnConflictClause(tableName string, c OnConflict) (Expr, error) {
+	var target string
+	switch {
+	case c.Constraint != "" && len(c.Columns) > 0:
+		return Expr{}, errors.New("on conflict target can not have both columns and a constraint")
+	case c.Constraint != "":
+		target = " ON CONSTRAINT " + s.Quote(c.Constraint)
+	case len(c.Columns) > 0:
+		target = fmt.Sprintf(" (%v)", s.quoteColumns(c.Columns))
+		if c.Where != "" {
+			target += " WHERE " + c.Where
+		}
+	}
+	if c.DoNothing {
+		return Expr{SQL: "ON CONFLICT" + target + " DO NOTHING"}, nil
+	}
+	if target == "" {
+		return Expr{}, errors.New("on conflict do update needs a conflict target")
+	}
+	sets := make([]Expr, 0, len(c.Update)+len(c.Set))
+	for _, col := range c.Update {
+		sets = append(sets, Expr{SQL: fmt.Sprintf("%v = EXCLUDED.%v", s.Quote(col), s.Quote(col))})
+	}
+	for _, a := range c.Set {
+		sets = append(sets, s.assignment(a))
+	}
+	if len(sets) == 0 {
+		return Expr{}, errors.New("on conflict do update has nothing to update")
+	}
+	set := Join(", ", sets...)
+	clause := Expr{SQL: "ON CONFLICT" + target + " DO UPDATE SET " + set.SQL, Args: set.Args}
+	if c.OnlyChanged && len(c.Update) > 0 {
+		current := make([]string, len(c.Update))
+		for i, col := range c.Update {
+			current[i] = s.Quote(tableName) + "." + s.Quote(col)
+		}
+		clause.SQL += fmt.Sprintf(" WHERE (%v) IS DISTINCT FROM (%v)",
+			strings.Join(current, ", "), s.sourceColumns(c.Update, "EXCLUDED"))
+	}
+	return clause, nil
+}
+
+// UpsertSQL returns an INSERT ... ON CONFLICT statement inserting values into
+// columns of tableName.
+func (s Postgres) UpsertSQL(tableName string, columns []string, values []interface{}, c OnConflict) (string, []interface{}, error) {
+	if len(columns) != len(values) {
+		return "", nil, fmt.Errorf("got %d values for %d columns", len(values), len(columns))
+	}
+	clause, err := s.OnConflictClause(tableName, c)
+	if err != nil {
+		return "", nil, err
+	}
+	e := Expr{
+		SQL: fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v) %v", s.Quote(tableName),
+			s.quoteColumns(columns), placeholders(len(values)), clause.SQL),
+		Args: append(append([]interface{}{}, values...), clause.Args...),
+	}
+	query, args := s.Build(e)
+	return query, args, nil
+}
+
+// Upsert inserts values into columns of tableName, resolving conflicts as
+// described by c.
+func (s Postgres) Upsert(tableName string, columns []string, values []interface{}, c OnConflict) (sql.Result, error) {
+	query, args, err := s.UpsertSQL(tableName, columns, values, c)
+	if err != nil {
+		return nil, err
+	}
+	return s.DB.Exec(query, args...)
+}
+
+func (s Postgres) quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = s.Quote(c)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ngorm/ngorm/model"
+)
+
+// VacuumOptions control Vacuum.
+type VacuumOptions struct {
+	// Full rewrites the table to return its free space to the operating
+	// system. It holds an ACCESS EXCLUSIVE lock for the whole operation.
+	Full bool
+
+	// Freeze aggressively freezes the rows.
+	Freeze bool
+
+	// Analyze also updates the planner statistics.
+	Analyze bool
+
+	// Verbose reports the work done in VacuumResult.
+	Verbose bool
+
+	// SkipLocked skips tables that can not be locked right away.
+	SkipLocked bool
+
+	// Parallel is the number of workers vacuuming indexes, PostgreSQL 13.
+	Parallel int
+}
+
+func (o VacuumOptions) sql() string {
+	var opts []string
+	flag := func(on bool, name string) {
+		if on {
+			opts = append(opts, name)
+		}
+	}
+	flag(o.Full, "FULL")
+	flag(o.Freeze, "FREEZE")
+	flag(o.Verbose, "VERBOSE")
+	flag(o.Analyze, "ANALYZE")
+	flag(o.SkipLocked, "SKIP_LOCKED")
+	if o.Parallel > 0 {
+		opts = append(opts, fmt.Sprintf("PARALLEL %d", o.Parallel))
+	}
+	if len(opts) == 0 {
+		return "VACUUM"
+	}
+	return fmt.Sprintf("VACUUM (%v)", strings.Join(opts, ", "))
+}
+
+// VacuumResult is the work reported by a verbose Vacuum, summed over the
+// tables that were vacuumed.
+type VacuumResult struct {
+	// Messages are the INFO messages of the server.
+	Messages []string
+
+	RemovedTuples   int64
+	RemainingTuples int64
+	RemovedPages    int64
+	RemainingPages  int64
+}
+
+var (
+	// PostgreSQL 14 and later.
+	vacuumTuples = regexp.MustCompile(`tuples: (\d+) removed, (\d+) remain`)
+	vacuumPages  = regexp.MustCompile(`pages: (\d+) removed, (\d+) remain`)
+
+	// Earlier versions.
+	vacuumFound = regexp.MustCompile(`found (\d+) removable, (\d+) nonremovable row versions in (\d+) out of (\d+) pages`)
+)
+
+func (r *VacuumResult) parse(notices []Notice) {
+	atoi := func(s string) int64 {
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	for _, n := range notices {
+		msg := n.Message
+		if n.Detail != "" {
+			msg += "\n" + n.Detail
+		}
+		r.Messages = append(r.Messages, msg)
+		if m := vacuumTuples.FindStringSubmatch(msg); m != nil {
+			r.RemovedTuples += atoi(m[1])
+			r.RemainingTuples += atoi(m[2])
+		}
+		if m := vacuumPages.FindStringSubmatch(msg); m != nil {
+			r.RemovedPages += atoi(m[1])
+			r.RemainingPages += atoi(m[2])
+		}
+		if m := vacuumFound.FindStringSubmatch(msg); m != nil {
+			r.RemovedTuples += atoi(m[1])
+			r.RemainingTuples += atoi(m[2])
+			r.RemainingPages += atoi(m[4])
+		}
+	}
+}
+
+// Vacuum vacuums tables, or the whole database when none is given, e.g.
+// after a bulk delete. VACUUM can not run in a transaction, so the dialect's
+// connection must not be one. The result is only filled in with
+// opts.Verbose, whose report is captured on connections made by a Connector.
+func (s Postgres) Vacuum(ctx context.Context, opts VacuumOptions, tables ...string) (*VacuumResult, error) {
+	if _, ok := s.DB.(txBeginner); !ok {
+		return nil, errors.New("VACUUM can not run inside a transaction")
+	}
+	query := opts.sql()
+	if len(tables) > 0 {
+		query += " " + s.quoteColumns(tables)
+	}
+	var r VacuumResult
+	if !opts.Verbose {
+		_, err := s.DB.Exec(query)
+		return &r, err
+	}
+	notices, err := s.captureNotices(ctx, func(db model.SQLCommon) error {
+		_, err := db.Exec(query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.parse(notices)
+	return &r, nil
+}
+
+// Analyze updates the planner statistics of tables, or of the whole database
+// when none is given, e.g. after a bulk load so the new rows are planned
+// for. Unlike VACUUM it may run in a transaction.
+func (s Postgres) Analyze(tables ...string) error {
+	query := "ANALYZE"
+	if len(tables) > 0 {
+		query += " " + s.quoteColumns(tables)
+	}
+	_, err := s.DB.Exec(query)
+	return err
+}
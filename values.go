@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValuesList is a list of rows bound as an inline table.
+type ValuesList struct {
+	Alias   string
+	Columns []string
+
+	// Types optionally gives the SQL type of every column. Parameters of a
+	// VALUES list have no type of their own, so without it comparisons with
+	// non text columns may fail to resolve.
+	Types []string
+
+	Rows [][]interface{}
+}
+
+// Values returns (VALUES (...), (...)) AS alias(columns) for v, for use in
+// the FROM clause or a join:
+//
+//	v, _ := s.Values(postgres.ValuesList{
+//		Alias: "v", Columns: []string{"sku", "qty"}, Types: []string{"text", "int"},
+//		Rows: [][]interface{}{{"a-1", 2}, {"b-7", 1}},
+//	})
+//	db.Joins("JOIN "+v.SQL+" ON v.sku = products.sku", v.Args...)
+func (s Postgres) Values(v ValuesList) (Expr, error) {
+	if len(v.Columns) == 0 || len(v.Rows) == 0 {
+		return Expr{}, errors.New("values list needs columns and rows")
+	}
+	if len(v.Types) != 0 && len(v.Types) != len(v.Columns) {
+		return Expr{}, fmt.Errorf("values list has %d types for %d columns", len(v.Types), len(v.Columns))
+	}
+	if len(v.Columns)*len(v.Rows) > MaxBindParams {
+		return Expr{}, fmt.Errorf("values list of %d rows exceeds %d bind parameters", len(v.Rows), MaxBindParams)
+	}
+	rows := make([]string, len(v.Rows))
+	args := make([]interface{}, 0, len(v.Columns)*len(v.Rows))
+	for i, row := range v.Rows {
+		if len(row) != len(v.Columns) {
+			return Expr{}, fmt.Errorf("row %d has %d values for %d columns", i, len(row), len(v.Columns))
+		}
+		cells := make([]string, len(row))
+		for j := range row {
+			cells[j] = "?"
+			// Casting the first row is enough to type the whole column.
+			if i == 0 && len(v.Types) > 0 {
+				cells[j] += "::" + v.Types[j]
+			}
+		}
+		rows[i] = "(" + strings.Join(cells, ", ") + ")"
+		args = append(args, row...)
+	}
+	return Expr{
+		SQL: fmt.Sprintf("(VALUES %v) AS %v(%v)", strings.Join(rows, ", "),
+			s.Quote(v.Alias), s.quoteColumns(v.Columns)),
+		Args: args,
+	}, nil
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Version is a server version in the form of server_version_num, e.g. 150002
+// for 15.2 and 90624 for 9.6.24, so versions compare as integers.
+type Version int
+
+// Major returns the major version, e.g. 15 or 9.
+func (v Version) Major() int {
+	return int(v) / 10000
+}
+
+// Minor returns the minor version, e.g. 2 for 15.2 and 6 for 9.6.
+func (v Version) Minor() int {
+	if v < 100000 {
+		return int(v) / 100 % 100
+	}
+	return int(v) % 10000
+}
+
+// AtLeast reports whether v is major.minor or later, e.g. AtLeast(11, 0).
+func (v Version) AtLeast(major, minor int) bool {
+	if major < 10 {
+		return v >= Version(major*10000+minor*100)
+	}
+	return v >= Version(major*10000+minor)
+}
+
+func (v Version) String() string {
+	if v < 100000 {
+		return fmt.Sprintf("%d.%d.%d", v.Major(), v.Minor(), int(v)%100)
+	}
+	return fmt.Sprintf("%d.%d", v.Major(), v.Minor())
+}
+
+// versions caches the server version per connection pool.
+var versions sync.Map
+
+// Version returns the version of the server behind the dialect's connection.
+// It is queried once per connection pool and cached; the version of a
+// transaction's server is queried every time.
+func (s Postgres) Version() (Version, error) {
+	_, pool := s.DB.(txBeginner)
+	pool = pool && reflect.ValueOf(s.DB).Kind() == reflect.Ptr
+	if pool {
+		if v, ok := versions.Load(s.DB); ok {
+			return v.(Version), nil
+		}
+	}
+	var v Version
+	if err := s.DB.QueryRow("SHOW server_version_num").Scan(&v); err != nil {
+		return 0, err
+	}
+	if pool {
+		versions.Store(s.DB, v)
+	}
+	return v, nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReadOnly is returned when writing a model that is backed by a view.
+var ErrReadOnly = errors.New("model is read-only: it is backed by a view")
+
+// ReadOnly can be embedded in a model backed by a view. Its hooks reject
+// inserts, updates and deletes with ErrReadOnly before any SQL is sent.
+type ReadOnly struct{}
+
+// BeforeSave implements the save hook.
+func (ReadOnly) BeforeSave() error { return ErrReadOnly }
+
+// BeforeCreate implements the create hook.
+func (ReadOnly) BeforeCreate() error { return ErrReadOnly }
+
+// BeforeUpdate implements the update hook.
+func (ReadOnly) BeforeUpdate() error { return ErrReadOnly }
+
+// BeforeDelete implements the delete hook.
+func (ReadOnly) BeforeDelete() error { return ErrReadOnly }
+
+// ViewDefiner is implemented by view backed models that ship the query
+// defining their view.
+type ViewDefiner interface {
+	ViewDefinition() string
+}
+
+// HasView reports whether a view named viewName exists.
+func (s Postgres) HasView(viewName string) bool {
+	ok, _ := s.ViewExists(viewName)
+	return ok
+}
+
+// ViewExists is like HasView but reports the errors of the lookup.
+func (s Postgres) ViewExists(viewName string) (bool, error) {
+	query := `
+SELECT Count(*)
+FROM   information_schema.views
+WHERE  table_schema = current_schema()
+       AND table_name = $1
+	`
+	return s.exists(query, viewName)
+}
+
+// CreateView creates or replaces the view viewName defined by query.
+func (s Postgres) CreateView(viewName, query string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("CREATE OR REPLACE VIEW %v AS %v", s.Quote(viewName), query))
+	return err
+}
+
+// DropView removes the view viewName if it exists.
+func (s Postgres) DropView(viewName string) error {
+	_, err := s.DB.Exec(fmt.Sprintf("DROP VIEW IF EXISTS %v", s.Quote(viewName)))
+	return err
+}
+
+// EnsureView creates the view for value when it implements ViewDefiner and
+// the view does not exist yet. Models whose views are managed elsewhere only
+// need the view to exist.
+func (s Postgres) EnsureView(viewName string, value interface{}) error {
+	ok, err := s.ViewExists(viewName)
+	if err != nil || ok {
+		return err
+	}
+	d, ok := value.(ViewDefiner)
+	if !ok || d.ViewDefinition() == "" {
+		return fmt.Errorf("view %s does not exist", viewName)
+	}
+	return s.CreateView(viewName, d.ViewDefinition())
+}
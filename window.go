@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Window is the window specification of an OVER clause.
+type Window struct {
+	PartitionBy []string
+
+	// OrderBy is the raw ORDER BY list of the window, e.g. "created_at DESC".
+	OrderBy string
+
+	// Frame is an optional frame clause, e.g.
+	// "ROWS BETWEEN 6 PRECEDING AND CURRENT ROW".
+	Frame string
+}
+
+// Over returns the OVER clause for w.
+func (s Postgres) Over(w Window) string {
+	var parts []string
+	if len(w.PartitionBy) > 0 {
+		parts = append(parts, "PARTITION BY "+s.quoteColumns(w.PartitionBy))
+	}
+	if w.OrderBy != "" {
+		parts = append(parts, "ORDER BY "+w.OrderBy)
+	}
+	if w.Frame != "" {
+		parts = append(parts, w.Frame)
+	}
+	return "OVER (" + strings.Join(parts, " ") + ")"
+}
+
+// WindowFunc returns call evaluated over w and named alias, for use in a
+// select list. Computed columns are scanned like any other column, so a
+// result struct can embed the model and add a field per alias:
+//
+//	type rankedScore struct {
+//		Score
+//		Rank int64
+//	}
+//	sel := s.WindowFunc(postgres.Raw("rank()"), postgres.Window{
+//		PartitionBy: []string{"game_id"}, OrderBy: "points DESC"}, "rank")
+//	db.Select("*, "+sel.SQL, sel.Args...)
+func (s Postgres) WindowFunc(call Expr, w Window, alias string) Expr {
+	return Expr{
+		SQL:  fmt.Sprintf("%v %v AS %v", call.SQL, s.Over(w), s.Quote(alias)),
+		Args: call.Args,
+	}
+}
+
+// RowNumber returns row_number() over w named alias.
+func (s Postgres) RowNumber(w Window, alias string) Expr {
+	return s.WindowFunc(Expr{SQL: "row_number()"}, w, alias)
+}
+
+// Rank returns rank() over w named alias.
+func (s Postgres) Rank(w Window, alias string) Expr {
+	return s.WindowFunc(Expr{SQL: "rank()"}, w, alias)
+}
+
+// DenseRank returns dense_rank() over w named alias.
+func (s Postgres) DenseRank(w Window, alias string) Expr {
+	return s.WindowFunc(Expr{SQL: "dense_rank()"}, w, alias)
+}
+
+// Lag returns the value of column offset rows before the current one within
+// w, or def when there is no such row.
+func (s Postgres) Lag(column string, offset int, def interface{}, w Window, alias string) Expr {
+	return s.WindowFunc(s.offsetCall("lag", column, offset, def), w, alias)
+}
+
+// Lead returns the value of column offset rows after the current one within
+// w, or def when there is no such row.
+func (s Postgres) Lead(column string, offset int, def interface{}, w Window, alias string) Expr {
+	return s.WindowFunc(s.offsetCall("lead", column, offset, def), w, alias)
+}
+
+func (s Postgres) offsetCall(fn, column string, offset int, def interface{}) Expr {
+	if offset <= 0 {
+		offset = 1
+	}
+	if def == nil {
+		return Expr{SQL: fmt.Sprintf("%v(%v, %d)", fn, s.Quote(column), offset)}
+	}
+	return Expr{
+		SQL:  fmt.Sprintf("%v(%v, %d, ?)", fn, s.Quote(column), offset),
+		Args: []interface{}{def},
+	}
+}
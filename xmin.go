@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// XMinColumn is the system column holding the id of the transaction that
+// wrote the current version of a row.
+const XMinColumn = "xmin"
+
+// XID is a transaction id, such as the value of the xmin system column.
+//
+// A model field of type XID holds the version of its row for optimistic
+// locking. It is filled from the xmin column by ScanRows and never written,
+// and should be hidden from ngorm with a sql:"-" tag:
+//
+//	type Account struct {
+//		ID      int64
+//		Balance int64
+//		Version postgres.XID `sql:"-"`
+//	}
+//
+// Select xmin along with the other columns, e.g. SELECT *, xmin FROM
+// accounts, and save the model with UpdateVersioned.
+type XID uint32
+
+var xidType = reflect.TypeOf(XID(0))
+
+// Scan implements sql.Scanner.
+func (x *XID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*x = 0
+		return nil
+	case int64:
+		*x = XID(v)
+		return nil
+	case uint32:
+		*x = XID(v)
+		return nil
+	case []byte:
+		return x.parse(string(v))
+	case string:
+		return x.parse(v)
+	}
+	return fmt.Errorf("can not scan %T into an XID", src)
+}
+
+func (x *XID) parse(s string) error {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return err
+	}
+	*x = XID(n)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (x XID) Value() (driver.Value, error) {
+	return int64(x), nil
+}
+
+// ErrVersionConflict is matched by VersionConflictError with errors.Is.
+var ErrVersionConflict = errors.New("row version conflict")
+
+// VersionConflictError is returned by UpdateVersioned and DeleteVersioned
+// when the row has been changed or deleted since it was read.
+type VersionConflictError struct {
+	Table string
+
+	// Version is the version of the row the caller had read.
+	Version XID
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("row of %s changed since version %d was read", e.Table, e.Version)
+}
+
+// Is reports whether target is ErrVersionConflict.
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+// UpdateVersioned saves columns of the model pointed to by value to its row
+// of tableName, or every column but the primary key when none are given,
+// unless the row changed since the model's XID field was read. The row is
+// matched by primary key and xmin; when no row matches, a
+// *VersionConflictError is returned and the caller is expected to reload and
+// retry. On success the XID field is set to the new version.
+func (s Postgres) UpdateVersioned(tableName string, value interface{}, columns ...string) error {
+	v, version, keys, cols, err := versionedModel(value)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		for _, c := range cols {
+			if !c.primary && !c.system && !c.relation {
+				columns = append(columns, c.name)
+			}
+		}
+	}
+	byName := columnIndex(v.Type())
+	set := make([]Assignment, len(columns))
+	for i, name := range columns {
+		index, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("%s has no field for column %s", v.Type(), name)
+		}
+		set[i] = Assignment{Column: name, Value: valueByIndex(v, index)}
+	}
+	old := XID(v.FieldByIndex(version.index).Uint())
+	e, err := s.updateExpr(tableName, set, s.versionWhere(v, keys, old))
+	if err != nil {
+		return err
+	}
+	e.SQL += " RETURNING " + XMinColumn
+	query, args := s.Build(e)
+	var x XID
+	if err := s.DB.QueryRow(query, args...).Scan(&x); err != nil {
+		if err == sql.ErrNoRows {
+			return &VersionConflictError{Table: tableName, Version: old}
+		}
+		return TranslateError(err)
+	}
+	v.FieldByIndex(version.index).SetUint(uint64(x))
+	return nil
+}
+
+// DeleteVersioned deletes the row of tableName of the model pointed to by
+// value, unless it changed since the model's XID field was read, in which
+// case a *VersionConflictError is returned.
+func (s Postgres) DeleteVersioned(tableName string, value interface{}) error {
+	v, version, keys, _, err := versionedModel(value)
+	if err != nil {
+		return err
+	}
+	old := XID(v.FieldByIndex(version.index).Uint())
+	e := Join(" WHERE ", Expr{SQL: "DELETE FROM " + s.Quote(tableName)}, s.versionWhere(v, keys, old))
+	query, args := s.Build(e)
+	n, err := s.execAffected(query, args...)
+	if err != nil {
+		return TranslateError(err)
+	}
+	if n == 0 {
+		return &VersionConflictError{Table: tableName, Version: old}
+	}
+	return nil
+}
+
+// versionedModel returns the struct pointed to by value along with its XID
+// column, its primary key columns and all its columns.
+func versionedModel(value interface{}) (v reflect.Value, version structColumn, keys, cols []structColumn, err error) {
+	v = reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return v, version, nil, nil, fmt.Errorf("expected a pointer to a model, got %T", value)
+	}
+	v = v.Elem()
+	var found bool
+	cols = structColumns(v.Type())
+	for _, c := range cols {
+		switch {
+		case c.system && c.name == XMinColumn:
+			version, found = c, true
+		case c.primary:
+			keys = append(keys, c)
+		}
+	}
+	if !found {
+		return v, version, nil, nil, fmt.Errorf("%s has no XID field", v.Type())
+	}
+	if len(keys) == 0 {
+		return v, version, nil, nil, fmt.Errorf("%s has no primary key", v.Type())
+	}
+	return v, version, keys, cols, nil
+}
+
+func (s Postgres) versionWhere(v reflect.Value, keys []structColumn, version XID) Expr {
+	conds := make([]Expr, 0, len(keys)+1)
+	for _, k := range keys {
+		conds = append(conds, Expr{SQL: s.Quote(k.name) + " = ?", Args: []interface{}{valueByIndex(v, k.index)}})
+	}
+	return And(append(conds, Expr{SQL: XMinColumn + " = ?::text::xid", Args: []interface{}{version}})...)
+}